@@ -20,9 +20,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/ingress-nginx/internal/ingress/controller"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
 
@@ -32,6 +39,8 @@ const (
 	certsPath    = "/configuration/certs"
 )
 
+var healthzPort int
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "dbg",
@@ -72,6 +81,24 @@ func main() {
 	}
 	backendsCmd.AddCommand(backendsGetCmd)
 
+	backendsHealthCmd := &cobra.Command{
+		Use:   "health",
+		Short: "Output a table with the result of the active health checks of each endpoint",
+		Run: func(cmd *cobra.Command, args []string) {
+			backendsHealth()
+		},
+	}
+	backendsCmd.AddCommand(backendsHealthCmd)
+
+	backendsDrainingCmd := &cobra.Command{
+		Use:   "draining",
+		Short: "Output a table with the endpoints removed from each backend and still draining",
+		Run: func(cmd *cobra.Command, args []string) {
+			backendsDraining()
+		},
+	}
+	backendsCmd.AddCommand(backendsDrainingCmd)
+
 	certCmd := &cobra.Command{
 		Use:   "certs",
 		Short: "Inspect dynamic SSL certificates",
@@ -108,6 +135,41 @@ func main() {
 	}
 	rootCmd.AddCommand(confCmd)
 
+	reloadsCmd := &cobra.Command{
+		Use:   "reloads",
+		Short: "Inspect the history of NGINX reloads",
+	}
+	reloadsCmd.PersistentFlags().IntVar(&healthzPort, "healthz-port", 10254, `Port used by the ingress controller to expose the healthz endpoint.`)
+	rootCmd.AddCommand(reloadsCmd)
+
+	reloadsAllCmd := &cobra.Command{
+		Use:   "all",
+		Short: "Output the history of reloads, including the configuration diff, as a JSON array",
+		Run: func(cmd *cobra.Command, args []string) {
+			reloadsAll()
+		},
+	}
+	reloadsCmd.AddCommand(reloadsAllCmd)
+
+	reloadsListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Output a table with the time, checksum, outcome and triggering events of each reload",
+		Run: func(cmd *cobra.Command, args []string) {
+			reloadsList()
+		},
+	}
+	reloadsCmd.AddCommand(reloadsListCmd)
+
+	reloadsGetCmd := &cobra.Command{
+		Use:   "get [checksum]",
+		Short: "Output the configuration diff of the reload with this checksum",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			reloadsGet(args[0])
+		},
+	}
+	reloadsCmd.AddCommand(reloadsGetCmd)
+
 	rootCmd.PersistentFlags().IntVar(&nginx.StatusPort, "status-port", 10246, `Port to use for the lua HTTP endpoint configuration.`)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -193,6 +255,83 @@ func backendsGet(name string) {
 	fmt.Println("A backend of this name was not found.")
 }
 
+func backendsHealth() {
+	statusCode, body, requestErr := nginx.NewGetStatusRequest(backendsPath)
+	if requestErr != nil {
+		fmt.Println(requestErr)
+		return
+	}
+	if statusCode != 200 {
+		fmt.Printf("Nginx returned code %v\n", statusCode)
+		return
+	}
+
+	var backends []struct {
+		Name      string `json:"name"`
+		Endpoints []struct {
+			Address string `json:"address"`
+			Port    string `json:"port"`
+			Health  string `json:"health"`
+		} `json:"endpoints"`
+	}
+	unmarshalErr := json.Unmarshal(body, &backends)
+	if unmarshalErr != nil {
+		fmt.Println(unmarshalErr)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tENDPOINT\tHEALTH")
+	for _, backend := range backends {
+		for _, endpoint := range backend.Endpoints {
+			// the endpoints of the backends without health check have no health
+			if endpoint.Health == "" {
+				continue
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\n", backend.Name, net.JoinHostPort(endpoint.Address, endpoint.Port), endpoint.Health)
+		}
+	}
+	w.Flush()
+}
+
+func backendsDraining() {
+	statusCode, body, requestErr := nginx.NewGetStatusRequest(backendsPath)
+	if requestErr != nil {
+		fmt.Println(requestErr)
+		return
+	}
+	if statusCode != 200 {
+		fmt.Printf("Nginx returned code %v\n", statusCode)
+		return
+	}
+
+	var backends []struct {
+		Name      string `json:"name"`
+		Endpoints []struct {
+			Address  string `json:"address"`
+			Port     string `json:"port"`
+			Draining bool   `json:"draining"`
+		} `json:"endpoints"`
+	}
+	unmarshalErr := json.Unmarshal(body, &backends)
+	if unmarshalErr != nil {
+		fmt.Println(unmarshalErr)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tENDPOINT")
+	for _, backend := range backends {
+		for _, endpoint := range backend.Endpoints {
+			if !endpoint.Draining {
+				continue
+			}
+			fmt.Fprintf(w, "%v\t%v\n", backend.Name, net.JoinHostPort(endpoint.Address, endpoint.Port))
+		}
+	}
+	w.Flush()
+}
+
 func certGet(host string) {
 	statusCode, body, requestErr := nginx.NewGetStatusRequest(certsPath + "?hostname=" + host)
 	if requestErr != nil {
@@ -245,3 +384,88 @@ func readNginxConf() {
 
 	fmt.Println(conf)
 }
+
+func getReloads() ([]controller.ReloadRecord, []byte, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%v%v", healthzPort, controller.ReloadHistoryPath)
+
+	client := http.Client{}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("ingress controller returned code %v", res.StatusCode)
+	}
+
+	var reloads []controller.ReloadRecord
+	err = json.Unmarshal(body, &reloads)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reloads, body, nil
+}
+
+func reloadsAll() {
+	_, body, err := getReloads()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var prettyBuffer bytes.Buffer
+	indentErr := json.Indent(&prettyBuffer, body, "", "  ")
+	if indentErr != nil {
+		fmt.Println(indentErr)
+		return
+	}
+
+	fmt.Println(prettyBuffer.String())
+}
+
+func reloadsList() {
+	reloads, _, err := getReloads()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIMESTAMP\tCHECKSUM\tOUTCOME\tDURATION\tEVENTS")
+	for _, r := range reloads {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%.3fs\t%v\n",
+			r.Timestamp.Format(time.RFC3339), r.Checksum, r.Outcome, r.Duration, strings.Join(r.Events, ","))
+	}
+	w.Flush()
+}
+
+func reloadsGet(checksum string) {
+	reloads, _, err := getReloads()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for i := len(reloads) - 1; i >= 0; i-- {
+		r := reloads[i]
+		if r.Checksum != checksum {
+			continue
+		}
+
+		fmt.Printf("# %v %v (%v)\n", r.Timestamp.Format(time.RFC3339), r.Outcome, strings.Join(r.Events, ","))
+		if r.Error != "" {
+			fmt.Printf("# error: %v\n", r.Error)
+		}
+		fmt.Println(r.Diff)
+		return
+	}
+
+	fmt.Println("A reload with this checksum was not found.")
+}
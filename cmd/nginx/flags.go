@@ -29,6 +29,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
 	"k8s.io/ingress-nginx/internal/ingress/status"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/nginx"
@@ -197,6 +198,41 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 		shutdownGracePeriod = flags.Int("shutdown-grace-period", 0, "Seconds to wait after receiving the shutdown signal, before stopping the nginx process.")
 
 		deepInspector = flags.Bool("deep-inspect", true, "Enables ingress object security deep inspector")
+
+		reloadHistorySize = flags.Int("reload-history-size", 20,
+			`Number of NGINX reloads, including the configuration diff, kept in memory and exposed in the path /reloads of the healthz port. Zero disables the history.`)
+
+		enableIncrementalConfig = flags.Bool("enable-incremental-config", false,
+			`Rebuild only the servers and backends affected by changes in MultiClusterIngresses and Service endpoints, instead of the whole configuration.`)
+		fullConfigRebuildInterval = flags.Duration("full-config-rebuild-interval", 5*time.Minute,
+			`Maximum time between two full builds of the configuration when the incremental build is enabled. Zero disables the periodic full build.`)
+
+		jwksRefreshInterval = flags.Duration("jwks-refresh-interval", 5*time.Minute,
+			`Time between two downloads of the key sets referenced by the jwt-auth-jwks-url annotation. Zero disables the refresh.`)
+
+		acmeDirectoryURL = flags.String("acme-directory-url", "",
+			`Directory URL of the ACME server used to issue certificates for the hosts of the TLS section of MultiClusterIngresses whose Secret is missing.
+The certificates are stored as Secrets in Karmada and renewed before they expire. Disabled by default.`)
+		acmeEmail = flags.String("acme-email", "",
+			`Contact email of the ACME account.`)
+		acmeNamespace = flags.String("acme-namespace", "",
+			`Karmada namespace, watched by the controller, of the Secrets containing the ACME account key and the responses to the challenges.`)
+		acmeRenewBefore = flags.Duration("acme-renew-before", 720*time.Hour,
+			`Time before their expiration the certificates issued with ACME are renewed.`)
+
+		sslExpiryWarningWindow = flags.Duration("ssl-expiry-warning-window", 240*time.Hour,
+			`Time before their expiration the certificates of MultiClusterIngresses are reported with warning events and admission warnings. Zero disables the warnings about certificates about to expire.`)
+
+		shardBy = flags.String("shard-by", "",
+			`Process only a subset of the MultiClusterIngresses, to split them across several controller deployments.
+Valid values are "host" (consistent hash of the first host), "namespace" (namespaces matching --shard-selector) and "label" (objects matching --shard-selector).
+The status of each shard is updated by its own leader, elected using the suffix "-shard-<name>" in the election ID. Disabled by default.`)
+		shardCount = flags.Int("shard-count", 1,
+			`Number of shards when MultiClusterIngresses are sharded by host.`)
+		shardIndex = flags.Int("shard-index", 0,
+			`Shard, from 0 to --shard-count minus one, processed by this controller when MultiClusterIngresses are sharded by host.`)
+		shardSelector = flags.String("shard-selector", "",
+			`Label selector of the namespaces or MultiClusterIngresses processed by this controller when sharding by namespace or label.`)
 	)
 
 	flags.StringVar(&nginx.MaxmindMirror, "maxmind-mirror", "", `Maxmind mirror url (example: http://geoip.local/databases`)
@@ -288,6 +324,37 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		}
 	}
 
+	var shardConfig *shard.Configuration
+	if *shardBy != "" {
+		shardConfig = &shard.Configuration{
+			By:    *shardBy,
+			Count: *shardCount,
+			Index: *shardIndex,
+		}
+
+		if *shardSelector != "" {
+			var err error
+			shardConfig.Selector, err = labels.Parse(*shardSelector)
+			if err != nil {
+				return false, nil, fmt.Errorf("failed to parse --shard-selector=%s, error: %v", *shardSelector, err)
+			}
+		}
+
+		if err := shardConfig.Validate(); err != nil {
+			return false, nil, fmt.Errorf("invalid sharding configuration: %w", err)
+		}
+	}
+
+	if *acmeDirectoryURL != "" {
+		if *acmeNamespace == "" {
+			return false, nil, fmt.Errorf("flag --acme-namespace is required when --acme-directory-url is set")
+		}
+
+		if *watchNamespace != "" && *watchNamespace != *acmeNamespace {
+			return false, nil, fmt.Errorf("the namespace %v of the flag --acme-namespace is not watched", *acmeNamespace)
+		}
+	}
+
 	ngx_config.EnableSSLChainCompletion = *enableSSLChainCompletion
 
 	config := &controller.Configuration{
@@ -319,6 +386,15 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 		UseNodeInternalIP:          *useNodeInternalIP,
 		SyncRateLimit:              *syncRateLimit,
 		HealthCheckHost:            *healthzHost,
+		ReloadHistorySize:          *reloadHistorySize,
+		EnableIncrementalConfig:    *enableIncrementalConfig,
+		FullConfigRebuildInterval:  *fullConfigRebuildInterval,
+		JWKSRefreshInterval:        *jwksRefreshInterval,
+		ACMEDirectoryURL:           *acmeDirectoryURL,
+		ACMEEmail:                  *acmeEmail,
+		ACMENamespace:              *acmeNamespace,
+		ACMERenewBefore:            *acmeRenewBefore,
+		SSLExpiryWarningWindow:     *sslExpiryWarningWindow,
 		ListenPorts: &ngx_config.ListenPorts{
 			Default:  *defServerPort,
 			Health:   *healthzPort,
@@ -332,6 +408,7 @@ https://blog.maxmind.com/2019/12/18/significant-changes-to-accessing-and-using-g
 			WatchWithoutClass:  *watchWithoutClass,
 			IngressClassByName: *ingressClassByName,
 		},
+		Shard:                     shardConfig,
 		DisableCatchAll:           *disableCatchAll,
 		ValidationWebhook:         *validationWebhook,
 		ValidationWebhookCertPath: *validationWebhookCert,
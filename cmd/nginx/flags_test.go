@@ -80,6 +80,19 @@ func TestFlagConflict(t *testing.T) {
 	}
 }
 
+func TestACMEWithoutNamespace(t *testing.T) {
+	resetForTesting(func() { t.Fatal("Parsing failed") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--acme-directory-url", "https://acme.example.com/directory"}
+
+	_, _, err := parseFlags()
+	if err == nil {
+		t.Fatalf("Expected an error parsing flags but none returned")
+	}
+}
+
 func TestMaxmindEdition(t *testing.T) {
 	resetForTesting(func() { t.Fatal("Parsing failed") })
 
@@ -128,6 +128,9 @@ func main() {
 		klog.Fatalf("Unexpected error obtaining ingress-nginx pod: %v", err)
 	}
 
+	conf.Zone = k8s.GetNodeZone(kubeClient, k8s.IngressPodDetails.NodeName)
+	klog.InfoS("Controller topology zone", "zone", conf.Zone)
+
 	reg := prometheus.NewRegistry()
 
 	reg.MustRegister(prometheus.NewGoCollector())
@@ -156,6 +159,7 @@ func main() {
 	mux := http.NewServeMux()
 	registerHealthz(nginx.HealthPath, ngx, mux)
 	registerMetrics(reg, mux)
+	registerReloadHistory(ngx, mux)
 
 	go startHTTPServer(conf.HealthCheckHost, conf.ListenPorts.Health, mux)
 	go ngx.Start()
@@ -342,6 +346,10 @@ func registerMetrics(reg *prometheus.Registry, mux *http.ServeMux) {
 	)
 }
 
+func registerReloadHistory(ic *controller.NGINXController, mux *http.ServeMux) {
+	mux.Handle(controller.ReloadHistoryPath, ic.ReloadHistory())
+}
+
 func registerProfiler() {
 	mux := http.NewServeMux()
 
@@ -35,6 +35,9 @@ import (
 type Checker interface {
 	CheckIngress(ing *networking.Ingress) error
 	CheckMCI(mci *karmadanetworking.MultiClusterIngress) error
+	// MCIWarnings returns the problems of a valid multiclusteringress
+	// reported to the user without rejecting the object
+	MCIWarnings(mci *karmadanetworking.MultiClusterIngress) []string
 }
 
 // IngressAdmission implements the AdmissionController interface
@@ -109,6 +112,7 @@ func (ia *IngressAdmission) HandleAdmission(obj runtime.Object) (runtime.Object,
 
 	klog.InfoS("successfully validated configuration, accepting", "multiclusteringress", fmt.Sprintf("%v/%v", review.Request.Namespace, review.Request.Name))
 	status.Allowed = true
+	status.Warnings = ia.Checker.MCIWarnings(&mci)
 	review.Response = status
 
 	return review, nil
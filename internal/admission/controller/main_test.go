@@ -43,9 +43,14 @@ func (ftc failTestChecker) CheckMCI(mci *karmadanetworking.MultiClusterIngress)
 	return nil
 }
 
+func (ftc failTestChecker) MCIWarnings(mci *karmadanetworking.MultiClusterIngress) []string {
+	return nil
+}
+
 type testChecker struct {
-	t   *testing.T
-	err error
+	t        *testing.T
+	err      error
+	warnings []string
 }
 
 func (tc testChecker) CheckIngress(ing *networking.Ingress) error {
@@ -62,6 +67,10 @@ func (tc testChecker) CheckMCI(mci *karmadanetworking.MultiClusterIngress) error
 	return tc.err
 }
 
+func (tc testChecker) MCIWarnings(mci *karmadanetworking.MultiClusterIngress) []string {
+	return tc.warnings
+}
+
 func TestHandleAdmission(t *testing.T) {
 	adm := &IngressAdmission{
 		Checker: failTestChecker{t: t},
@@ -120,12 +129,16 @@ func TestHandleAdmission(t *testing.T) {
 	}
 
 	adm.Checker = testChecker{
-		t:   t,
-		err: nil,
+		t:        t,
+		err:      nil,
+		warnings: []string{"this is a test warning"},
 	}
 
 	adm.HandleAdmission(review)
 	if !review.Response.Allowed {
 		t.Fatalf("when the checker returns no error, the request should be allowed")
 	}
+	if len(review.Response.Warnings) != 1 || review.Response.Warnings[0] != "this is a test warning" {
+		t.Fatalf("expected the warnings of the checker but got %v", review.Response.Warnings)
+	}
 }
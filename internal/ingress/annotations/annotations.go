@@ -31,40 +31,52 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/circuitbreaker"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodybuffersize"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customhttperrors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/faultinjection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/headermodifier"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/http2pushpreload"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/jwtauth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/portinredirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/retrypolicy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routematch"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/satisfy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/secureupstream"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serversnippet"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceupstream"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/slowstart"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/snippet"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslpassthrough"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/streamsnippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/trafficsplit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamvhost"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/xforwardedprefix"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/zoneaware"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
@@ -117,6 +129,18 @@ type Ingress struct {
 	ModSecurity        modsecurity.Config
 	Mirror             mirror.Config
 	StreamSnippet      string
+	TrafficSplit       trafficsplit.Config
+	RouteMatch         routematch.Config
+	HeaderModifier     headermodifier.Config
+	JWTAuth            jwtauth.Config
+	OIDC               oidc.Config
+	ProxyCache         proxycache.Config
+	FaultInjection     faultinjection.Config
+	RetryPolicy        retrypolicy.Config
+	HealthCheck        healthcheck.Config
+	SlowStartWindow    int
+	ZoneAwareRouting   zoneaware.Config
+	CircuitBreaker     circuitbreaker.Config
 }
 
 // Extractor defines the annotation parsers to be used in the extraction of annotations
@@ -168,6 +192,18 @@ func NewAnnotationExtractor(cfg resolver.Resolver) Extractor {
 			"ModSecurity":          modsecurity.NewParser(cfg),
 			"Mirror":               mirror.NewParser(cfg),
 			"StreamSnippet":        streamsnippet.NewParser(cfg),
+			"TrafficSplit":         trafficsplit.NewParser(cfg),
+			"RouteMatch":           routematch.NewParser(cfg),
+			"HeaderModifier":       headermodifier.NewParser(cfg),
+			"JWTAuth":              jwtauth.NewParser(cfg),
+			"OIDC":                 oidc.NewParser(cfg),
+			"ProxyCache":           proxycache.NewParser(cfg),
+			"FaultInjection":       faultinjection.NewParser(cfg),
+			"RetryPolicy":          retrypolicy.NewParser(cfg),
+			"HealthCheck":          healthcheck.NewParser(cfg),
+			"SlowStartWindow":      slowstart.NewParser(cfg),
+			"ZoneAwareRouting":     zoneaware.NewParser(cfg),
+			"CircuitBreaker":       circuitbreaker.NewParser(cfg),
 		},
 	}
 }
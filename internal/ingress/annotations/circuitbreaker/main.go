@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"fmt"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	maxRequestsAnnotation = "circuit-breaker-max-requests"
+	maxPendingAnnotation  = "circuit-breaker-max-pending"
+	statusAnnotation      = "circuit-breaker-status"
+
+	defaultStatus = 503
+)
+
+// Config contains the circuit breaker limiting the requests sent to a backend
+type Config struct {
+	// MaxRequests is the maximum number of requests in flight to the backend,
+	// zero disables the circuit breaker
+	MaxRequests int `json:"maxRequests,omitempty"`
+	// MaxPending is the maximum number of requests waiting for one of the
+	// requests in flight to finish
+	MaxPending int `json:"maxPending,omitempty"`
+	// Status is the status of the responses to the requests rejected while
+	// the circuit breaker is open
+	Status int `json:"status,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type circuitBreaker struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new circuit breaker annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return circuitBreaker{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the requests sent to the backends
+func (cb circuitBreaker) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(func(name string) (int, error) {
+		return parser.GetIntAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to limit the requests sent to the backends
+func (cb circuitBreaker) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(func(name string) (int, error) {
+		return parser.GetIntAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the circuit breaker annotations. The maximum number of pending
+// requests and the status are ignored without a maximum number of requests.
+func parse(annotation func(string) (int, error)) (interface{}, error) {
+	maxRequests, err := annotation(maxRequestsAnnotation)
+	if ing_errors.IsMissingAnnotations(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if maxRequests < 0 {
+		return Config{}, ing_errors.NewInvalidAnnotationConfiguration(maxRequestsAnnotation,
+			fmt.Sprintf("expected a positive number of requests but got %v", maxRequests))
+	}
+	if maxRequests == 0 {
+		return Config{}, nil
+	}
+
+	config := Config{
+		MaxRequests: maxRequests,
+		Status:      defaultStatus,
+	}
+
+	maxPending, err := annotation(maxPendingAnnotation)
+	if err == nil {
+		config.MaxPending = maxPending
+	} else if !ing_errors.IsMissingAnnotations(err) {
+		return Config{}, err
+	}
+	if config.MaxPending < 0 {
+		return Config{}, ing_errors.NewInvalidAnnotationConfiguration(maxPendingAnnotation,
+			fmt.Sprintf("expected a positive number of requests but got %v", config.MaxPending))
+	}
+
+	status, err := annotation(statusAnnotation)
+	if err == nil {
+		config.Status = status
+	} else if !ing_errors.IsMissingAnnotations(err) {
+		return Config{}, err
+	}
+	if config.Status < 400 || config.Status > 599 {
+		return Config{}, ing_errors.NewInvalidAnnotationConfiguration(statusAnnotation,
+			fmt.Sprintf("expected an error status between 400 and 599 but got %v", config.Status))
+	}
+
+	return config, nil
+}
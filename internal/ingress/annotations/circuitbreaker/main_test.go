@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package circuitbreaker
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	maxRequests := parser.GetAnnotationWithPrefix(maxRequestsAnnotation)
+	maxPending := parser.GetAnnotationWithPrefix(maxPendingAnnotation)
+	status := parser.GetAnnotationWithPrefix(statusAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    Config
+		expectErr   bool
+	}{
+		"no annotation": {
+			annotations: map[string]string{},
+			expected:    Config{},
+		},
+		"maximum number of requests": {
+			annotations: map[string]string{maxRequests: "100"},
+			expected:    Config{MaxRequests: 100, Status: 503},
+		},
+		"every annotation": {
+			annotations: map[string]string{maxRequests: "100", maxPending: "20", status: "429"},
+			expected:    Config{MaxRequests: 100, MaxPending: 20, Status: 429},
+		},
+		"disabled by a maximum of zero": {
+			annotations: map[string]string{maxRequests: "0", maxPending: "20"},
+			expected:    Config{},
+		},
+		"pending without maximum number of requests": {
+			annotations: map[string]string{maxPending: "20"},
+			expected:    Config{},
+		},
+		"negative maximum number of requests": {
+			annotations: map[string]string{maxRequests: "-1"},
+			expectErr:   true,
+		},
+		"negative maximum number of pending requests": {
+			annotations: map[string]string{maxRequests: "100", maxPending: "-1"},
+			expectErr:   true,
+		},
+		"invalid maximum number of pending requests": {
+			annotations: map[string]string{maxRequests: "100", maxPending: "many"},
+			expectErr:   true,
+		},
+		"status not an error": {
+			annotations: map[string]string{maxRequests: "100", status: "200"},
+			expectErr:   true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	delayAnnotation           = "fault-delay"
+	delayPercentageAnnotation = "fault-delay-percentage"
+	abortStatusAnnotation     = "fault-abort-status"
+	abortPercentageAnnotation = "fault-abort-percentage"
+	headerAnnotation          = "fault-header"
+	headerValueAnnotation     = "fault-header-value"
+
+	// maxDelay is the longest delay accepted, so a mistake cannot keep the
+	// connections of a location open indefinitely
+	maxDelay = 5 * time.Minute
+)
+
+var (
+	headerNameRegexp = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	// values are rendered in a Lua string and compared with the header sent by the client
+	invalidValueRegexp = regexp.MustCompile(`[\x00-\x1f\x7f]`)
+)
+
+// Config contains the faults injected in the requests of a location
+type Config struct {
+	// DelayMin is the shortest delay in milliseconds
+	DelayMin int `json:"delayMin,omitempty"`
+	// DelayMax is the longest delay in milliseconds. The delay of each request
+	// is chosen randomly between DelayMin and DelayMax.
+	DelayMax int `json:"delayMax,omitempty"`
+	// DelayPercentage is the percentage of requests delayed
+	DelayPercentage float64 `json:"delayPercentage,omitempty"`
+	// AbortStatus is the status code returned to the aborted requests
+	AbortStatus int `json:"abortStatus,omitempty"`
+	// AbortPercentage is the percentage of requests aborted
+	AbortPercentage float64 `json:"abortPercentage,omitempty"`
+	// Header restricts the injection to the requests containing this header
+	Header string `json:"header,omitempty"`
+	// HeaderValue restricts the injection to the requests where Header has this value
+	HeaderValue string `json:"headerValue,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type faultInjection struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new fault injection annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return faultInjection{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to inject delays and errors in the requests
+func (fi faultInjection) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to inject delays and errors in the requests
+func (fi faultInjection) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the fault annotations, returning ErrMissingAnnotations when
+// neither a delay nor an abort is defined
+func parse(annotation func(string) (string, error)) (*Config, error) {
+	config := &Config{}
+
+	delay, err := parser.GetOptionalAnnotation(annotation, delayAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	abort, err := parser.GetOptionalAnnotation(annotation, abortStatusAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if delay == "" && abort == "" {
+		return &Config{}, ing_errors.ErrMissingAnnotations
+	}
+
+	if delay != "" {
+		if config.DelayMin, config.DelayMax, err = parseDelay(delay); err != nil {
+			return &Config{}, err
+		}
+		if config.DelayPercentage, err = parsePercentage(annotation, delayPercentageAnnotation); err != nil {
+			return &Config{}, err
+		}
+	}
+
+	if abort != "" {
+		status, err := strconv.Atoi(abort)
+		if err != nil || status < 400 || status > 599 {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(abortStatusAnnotation,
+				fmt.Sprintf("expected a status code between 400 and 599 but got %q", abort))
+		}
+		config.AbortStatus = status
+		if config.AbortPercentage, err = parsePercentage(annotation, abortPercentageAnnotation); err != nil {
+			return &Config{}, err
+		}
+	}
+
+	if config.Header, err = parser.GetOptionalAnnotation(annotation, headerAnnotation); err != nil {
+		return &Config{}, err
+	}
+	if config.Header != "" && !headerNameRegexp.MatchString(config.Header) {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(headerAnnotation,
+			fmt.Sprintf("invalid header name %q", config.Header))
+	}
+
+	if config.HeaderValue, err = parser.GetOptionalAnnotation(annotation, headerValueAnnotation); err != nil {
+		return &Config{}, err
+	}
+	if config.HeaderValue != "" {
+		if config.Header == "" {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(headerValueAnnotation,
+				fmt.Sprintf("requires the annotation %v", headerAnnotation))
+		}
+		if invalidValueRegexp.MatchString(config.HeaderValue) {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(headerValueAnnotation,
+				"the header value cannot contain control characters")
+		}
+	}
+
+	return config, nil
+}
+
+// parseDelay parses a fixed delay, like "500ms", or a random delay between
+// two durations, like "100ms-2s", returning the bounds in milliseconds
+func parseDelay(val string) (int, int, error) {
+	bounds := strings.SplitN(val, "-", 2)
+
+	shortest, err := parseDuration(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	longest := shortest
+	if len(bounds) == 2 {
+		if longest, err = parseDuration(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if shortest > longest {
+		return 0, 0, ing_errors.NewInvalidAnnotationConfiguration(delayAnnotation,
+			fmt.Sprintf("the shortest delay of %q is longer than the longest one", val))
+	}
+
+	return shortest, longest, nil
+}
+
+func parseDuration(val string) (int, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(val))
+	if err != nil || d < time.Millisecond || d > maxDelay {
+		return 0, ing_errors.NewInvalidAnnotationConfiguration(delayAnnotation,
+			fmt.Sprintf("expected a duration between 1ms and %v but got %q", maxDelay, val))
+	}
+
+	return int(d / time.Millisecond), nil
+}
+
+// parsePercentage parses a percentage annotation, which defaults to 100
+func parsePercentage(annotation func(string) (string, error), name string) (float64, error) {
+	val, err := parser.GetOptionalAnnotation(annotation, name)
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return 100, nil
+	}
+
+	percentage, err := strconv.ParseFloat(val, 64)
+	if err != nil || percentage <= 0 || percentage > 100 {
+		return 0, ing_errors.NewInvalidAnnotationConfiguration(name,
+			fmt.Sprintf("expected a percentage greater than 0 and up to 100 but got %q", val))
+	}
+
+	return percentage, nil
+}
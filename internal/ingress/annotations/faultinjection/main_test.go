@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinjection
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	delay := parser.GetAnnotationWithPrefix(delayAnnotation)
+	delayPercentage := parser.GetAnnotationWithPrefix(delayPercentageAnnotation)
+	abortStatus := parser.GetAnnotationWithPrefix(abortStatusAnnotation)
+	abortPercentage := parser.GetAnnotationWithPrefix(abortPercentageAnnotation)
+	header := parser.GetAnnotationWithPrefix(headerAnnotation)
+	headerValue := parser.GetAnnotationWithPrefix(headerValueAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"fixed delay of every request": {
+			annotations: map[string]string{
+				delay: "500ms",
+			},
+			expected: &Config{
+				DelayMin:        500,
+				DelayMax:        500,
+				DelayPercentage: 100,
+			},
+		},
+		"random delay and abort restricted to a header": {
+			annotations: map[string]string{
+				delay:           "100ms-2s",
+				delayPercentage: "50",
+				abortStatus:     "503",
+				abortPercentage: "12.5",
+				header:          "X-Fault-Inject",
+				headerValue:     "checkout",
+			},
+			expected: &Config{
+				DelayMin:        100,
+				DelayMax:        2000,
+				DelayPercentage: 50,
+				AbortStatus:     503,
+				AbortPercentage: 12.5,
+				Header:          "X-Fault-Inject",
+				HeaderValue:     "checkout",
+			},
+		},
+		"abort only": {
+			annotations: map[string]string{
+				abortStatus: "429",
+			},
+			expected: &Config{
+				AbortStatus:     429,
+				AbortPercentage: 100,
+			},
+		},
+		"invalid delay": {
+			annotations: map[string]string{
+				delay: "soon",
+			},
+			expectErr: true,
+		},
+		"delay too long": {
+			annotations: map[string]string{
+				delay: "1h",
+			},
+			expectErr: true,
+		},
+		"inverted delay range": {
+			annotations: map[string]string{
+				delay: "2s-100ms",
+			},
+			expectErr: true,
+		},
+		"invalid abort status": {
+			annotations: map[string]string{
+				abortStatus: "200",
+			},
+			expectErr: true,
+		},
+		"invalid percentage": {
+			annotations: map[string]string{
+				abortStatus:     "503",
+				abortPercentage: "150",
+			},
+			expectErr: true,
+		},
+		"invalid header name": {
+			annotations: map[string]string{
+				abortStatus: "503",
+				header:      "X Fault",
+			},
+			expectErr: true,
+		},
+		"header value without header": {
+			annotations: map[string]string{
+				abortStatus: "503",
+				headerValue: "checkout",
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{header: "X-Fault-Inject"})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headermodifier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/inspector"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	requestHeaderSetAnnotation     = "request-header-set"
+	requestHeaderAddAnnotation     = "request-header-add"
+	requestHeaderRemoveAnnotation  = "request-header-remove"
+	responseHeaderSetAnnotation    = "response-header-set"
+	responseHeaderAddAnnotation    = "response-header-add"
+	responseHeaderRemoveAnnotation = "response-header-remove"
+)
+
+var (
+	headerNameRegexp = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	// values are rendered between double quotes and may reference NGINX variables
+	invalidValueRegexp = regexp.MustCompile(`["'\\\x00-\x1f\x7f]`)
+)
+
+// Header is a header name and its value
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Config contains the headers of the requests sent to the upstream and of the
+// responses sent to the client modified in a location
+type Config struct {
+	// RequestSet replaces the value of request headers
+	RequestSet []Header `json:"requestSet,omitempty"`
+	// RequestAdd adds a value to request headers, keeping the value sent by the client
+	RequestAdd []Header `json:"requestAdd,omitempty"`
+	// RequestRemove removes request headers
+	RequestRemove []string `json:"requestRemove,omitempty"`
+	// ResponseSet replaces the value of response headers
+	ResponseSet []Header `json:"responseSet,omitempty"`
+	// ResponseAdd adds a value to response headers, keeping the value sent by the upstream
+	ResponseAdd []Header `json:"responseAdd,omitempty"`
+	// ResponseRemove removes response headers
+	ResponseRemove []string `json:"responseRemove,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return equalHeaders(c1.RequestSet, c2.RequestSet) &&
+		equalHeaders(c1.RequestAdd, c2.RequestAdd) &&
+		equalNames(c1.RequestRemove, c2.RequestRemove) &&
+		equalHeaders(c1.ResponseSet, c2.ResponseSet) &&
+		equalHeaders(c1.ResponseAdd, c2.ResponseAdd) &&
+		equalNames(c1.ResponseRemove, c2.ResponseRemove)
+}
+
+func equalHeaders(h1, h2 []Header) bool {
+	if len(h1) != len(h2) {
+		return false
+	}
+	for i := range h1 {
+		if h1[i] != h2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalNames(n1, n2 []string) bool {
+	if len(n1) != len(n2) {
+		return false
+	}
+	for i := range n1 {
+		if n1[i] != n2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type headerModifier struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new header modifier annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return headerModifier{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to modify request and response headers
+func (hm headerModifier) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to modify request and response headers
+func (hm headerModifier) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads every header annotation, returning ErrMissingAnnotations when
+// none of them is defined
+func parse(annotation func(string) (string, error)) (*Config, error) {
+	config := &Config{}
+	found := false
+
+	headerLists := []struct {
+		name   string
+		target *[]Header
+	}{
+		{requestHeaderSetAnnotation, &config.RequestSet},
+		{requestHeaderAddAnnotation, &config.RequestAdd},
+		{responseHeaderSetAnnotation, &config.ResponseSet},
+		{responseHeaderAddAnnotation, &config.ResponseAdd},
+	}
+
+	for _, list := range headerLists {
+		val, err := annotation(list.name)
+		if err != nil {
+			if ing_errors.IsMissingAnnotations(err) {
+				continue
+			}
+			return &Config{}, err
+		}
+
+		found = true
+		headers, err := parseHeaders(list.name, val)
+		if err != nil {
+			return &Config{}, err
+		}
+		*list.target = headers
+	}
+
+	nameLists := []struct {
+		name   string
+		target *[]string
+	}{
+		{requestHeaderRemoveAnnotation, &config.RequestRemove},
+		{responseHeaderRemoveAnnotation, &config.ResponseRemove},
+	}
+
+	for _, list := range nameLists {
+		val, err := annotation(list.name)
+		if err != nil {
+			if ing_errors.IsMissingAnnotations(err) {
+				continue
+			}
+			return &Config{}, err
+		}
+
+		found = true
+		names, err := parseNames(list.name, val)
+		if err != nil {
+			return &Config{}, err
+		}
+		*list.target = names
+	}
+
+	if !found {
+		return &Config{}, ing_errors.ErrMissingAnnotations
+	}
+
+	return config, nil
+}
+
+// parseHeaders parses a list of headers with one "Name: value" pair per line
+func parseHeaders(annotation, val string) ([]Header, error) {
+	headers := []Header{}
+	for _, line := range strings.Split(val, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, ing_errors.NewInvalidAnnotationConfiguration(annotation,
+				fmt.Sprintf("expected a header with the format 'Name: value' but got %q", line))
+		}
+
+		header := Header{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		}
+
+		if err := checkName(annotation, header.Name); err != nil {
+			return nil, err
+		}
+
+		if err := checkValue(annotation, header.Value); err != nil {
+			return nil, err
+		}
+
+		headers = append(headers, header)
+	}
+
+	if len(headers) == 0 {
+		return nil, ing_errors.NewInvalidAnnotationConfiguration(annotation, "the list of headers is empty")
+	}
+
+	return headers, nil
+}
+
+// parseNames parses a comma separated list of header names
+func parseNames(annotation, val string) ([]string, error) {
+	names := []string{}
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if err := checkName(annotation, name); err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, ing_errors.NewInvalidAnnotationConfiguration(annotation, "the list of headers is empty")
+	}
+
+	return names, nil
+}
+
+func checkName(annotation, name string) error {
+	if !headerNameRegexp.MatchString(name) {
+		return ing_errors.NewInvalidAnnotationConfiguration(annotation, fmt.Sprintf("invalid header name %q", name))
+	}
+
+	return nil
+}
+
+func checkValue(annotation, value string) error {
+	if invalidValueRegexp.MatchString(value) {
+		return ing_errors.NewInvalidAnnotationConfiguration(annotation,
+			fmt.Sprintf("header value %q cannot contain quotes, backslashes or control characters", value))
+	}
+
+	if err := inspector.CheckRegex(value); err != nil {
+		return ing_errors.NewInvalidAnnotationConfiguration(annotation, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headermodifier
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	requestSet := parser.GetAnnotationWithPrefix(requestHeaderSetAnnotation)
+	requestAdd := parser.GetAnnotationWithPrefix(requestHeaderAddAnnotation)
+	requestRemove := parser.GetAnnotationWithPrefix(requestHeaderRemoveAnnotation)
+	responseSet := parser.GetAnnotationWithPrefix(responseHeaderSetAnnotation)
+	responseAdd := parser.GetAnnotationWithPrefix(responseHeaderAddAnnotation)
+	responseRemove := parser.GetAnnotationWithPrefix(responseHeaderRemoveAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"every annotation": {
+			annotations: map[string]string{
+				requestSet:     "X-Tenant: blue\nX-Client-IP: $remote_addr\n",
+				requestAdd:     "X-Forwarded-Tags: edge",
+				requestRemove:  "X-Debug, Cookie2",
+				responseSet:    "Cache-Control: no-store",
+				responseAdd:    "Link: </style.css>; rel=preload",
+				responseRemove: "Server,X-Powered-By",
+			},
+			expected: &Config{
+				RequestSet:     []Header{{Name: "X-Tenant", Value: "blue"}, {Name: "X-Client-IP", Value: "$remote_addr"}},
+				RequestAdd:     []Header{{Name: "X-Forwarded-Tags", Value: "edge"}},
+				RequestRemove:  []string{"X-Debug", "Cookie2"},
+				ResponseSet:    []Header{{Name: "Cache-Control", Value: "no-store"}},
+				ResponseAdd:    []Header{{Name: "Link", Value: "</style.css>; rel=preload"}},
+				ResponseRemove: []string{"Server", "X-Powered-By"},
+			},
+		},
+		"only response headers": {
+			annotations: map[string]string{
+				responseRemove: "Server",
+			},
+			expected: &Config{
+				ResponseRemove: []string{"Server"},
+			},
+		},
+		"missing value separator": {
+			annotations: map[string]string{
+				requestSet: "X-Tenant blue",
+			},
+			expectErr: true,
+		},
+		"invalid header name": {
+			annotations: map[string]string{
+				responseSet: "X Tenant: blue",
+			},
+			expectErr: true,
+		},
+		"quote in value": {
+			annotations: map[string]string{
+				requestSet: `X-Tenant: blue"; more_set_headers "X-Injected: 1`,
+			},
+			expectErr: true,
+		},
+		"value rejected by the inspector": {
+			annotations: map[string]string{
+				responseAdd: "X-Path: /etc/nginx/nginx.conf",
+			},
+			expectErr: true,
+		},
+		"empty list of names": {
+			annotations: map[string]string{
+				requestRemove: " , ",
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	pathAnnotation               = "health-check-path"
+	intervalAnnotation           = "health-check-interval"
+	timeoutAnnotation            = "health-check-timeout"
+	healthyThresholdAnnotation   = "health-check-healthy-threshold"
+	unhealthyThresholdAnnotation = "health-check-unhealthy-threshold"
+	expectedStatusAnnotation     = "health-check-expected-status"
+
+	defaultInterval           = 10 * time.Second
+	defaultTimeout            = 2 * time.Second
+	defaultHealthyThreshold   = 2
+	defaultUnhealthyThreshold = 3
+	defaultExpectedStatus     = "200-399"
+
+	minInterval  = time.Second
+	maxInterval  = time.Hour
+	maxThreshold = 10
+)
+
+var (
+	pathRegexp = regexp.MustCompile(`^/[^\s\x00-\x1f\x7f]*$`)
+)
+
+// Config contains the active health check of the endpoints of a backend
+type Config struct {
+	// Path is the path of the HTTP request sent to each endpoint
+	Path string `json:"path,omitempty"`
+	// Interval is the time between two probes of an endpoint, in milliseconds
+	Interval int `json:"interval,omitempty"`
+	// Timeout is the time to wait for the response of a probe, in milliseconds
+	Timeout int `json:"timeout,omitempty"`
+	// HealthyThreshold is the number of consecutive successful probes
+	// marking an unhealthy endpoint as healthy
+	HealthyThreshold int `json:"healthyThreshold,omitempty"`
+	// UnhealthyThreshold is the number of consecutive failed probes
+	// marking a healthy endpoint as unhealthy
+	UnhealthyThreshold int `json:"unhealthyThreshold,omitempty"`
+	// ExpectedStatus is a comma separated list of the status codes, or
+	// ranges of status codes, of a successful probe
+	ExpectedStatus string `json:"expectedStatus,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+// Matches returns if a status code is one of the expected status codes
+func (c Config) Matches(code int) bool {
+	for _, status := range strings.Split(c.ExpectedStatus, ",") {
+		lowest, highest, err := parseStatusRange(status)
+		if err != nil {
+			continue
+		}
+		if code >= lowest && code <= highest {
+			return true
+		}
+	}
+
+	return false
+}
+
+type healthCheck struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new active health check annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return healthCheck{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to probe the endpoints of the backends
+func (hc healthCheck) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to probe the endpoints of the backends
+func (hc healthCheck) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the health check annotations, returning ErrMissingAnnotations
+// when no path is defined
+func parse(annotation func(string) (string, error)) (*Config, error) {
+	path, err := annotation(pathAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	path = strings.TrimSpace(path)
+	if !pathRegexp.MatchString(path) {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(pathAnnotation,
+			fmt.Sprintf("expected an absolute path but got %q", path))
+	}
+
+	config := &Config{
+		Path: path,
+	}
+
+	interval, err := parseDuration(annotation, intervalAnnotation, defaultInterval, minInterval, maxInterval)
+	if err != nil {
+		return &Config{}, err
+	}
+	config.Interval = int(interval / time.Millisecond)
+
+	timeout, err := parseDuration(annotation, timeoutAnnotation, defaultTimeout, time.Millisecond, interval)
+	if err != nil {
+		return &Config{}, err
+	}
+	config.Timeout = int(timeout / time.Millisecond)
+
+	if config.HealthyThreshold, err = parseThreshold(annotation, healthyThresholdAnnotation, defaultHealthyThreshold); err != nil {
+		return &Config{}, err
+	}
+	if config.UnhealthyThreshold, err = parseThreshold(annotation, unhealthyThresholdAnnotation, defaultUnhealthyThreshold); err != nil {
+		return &Config{}, err
+	}
+
+	expectedStatus, err := parser.GetOptionalAnnotation(annotation, expectedStatusAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if config.ExpectedStatus, err = parseExpectedStatus(expectedStatus); err != nil {
+		return &Config{}, err
+	}
+
+	return config, nil
+}
+
+func parseDuration(annotation func(string) (string, error), name string, def, shortest, longest time.Duration) (time.Duration, error) {
+	val, err := parser.GetOptionalAnnotation(annotation, name)
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		if def > longest {
+			return longest, nil
+		}
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d < shortest || d > longest {
+		return 0, ing_errors.NewInvalidAnnotationConfiguration(name,
+			fmt.Sprintf("expected a duration between %v and %v but got %q", shortest, longest, val))
+	}
+
+	return d, nil
+}
+
+func parseThreshold(annotation func(string) (string, error), name string, def int) (int, error) {
+	val, err := parser.GetOptionalAnnotation(annotation, name)
+	if err != nil {
+		return 0, err
+	}
+	if val == "" {
+		return def, nil
+	}
+
+	threshold, err := strconv.Atoi(val)
+	if err != nil || threshold < 1 || threshold > maxThreshold {
+		return 0, ing_errors.NewInvalidAnnotationConfiguration(name,
+			fmt.Sprintf("expected a number between 1 and %v but got %q", maxThreshold, val))
+	}
+
+	return threshold, nil
+}
+
+// parseExpectedStatus validates a list of status codes and ranges, like
+// "200,204" or "200-299", and returns it without spaces
+func parseExpectedStatus(val string) (string, error) {
+	if val == "" {
+		return defaultExpectedStatus, nil
+	}
+
+	statuses := []string{}
+	for _, status := range strings.Split(val, ",") {
+		status = strings.Join(strings.Fields(status), "")
+		if _, _, err := parseStatusRange(status); err != nil {
+			return "", ing_errors.NewInvalidAnnotationConfiguration(expectedStatusAnnotation, err.Error())
+		}
+		statuses = append(statuses, status)
+	}
+
+	return strings.Join(statuses, ","), nil
+}
+
+// parseStatusRange returns the lowest and the highest status codes of a
+// status code or of a range of status codes
+func parseStatusRange(val string) (int, int, error) {
+	bounds := strings.SplitN(val, "-", 2)
+
+	lowest, err := strconv.Atoi(bounds[0])
+	if err != nil || lowest < 100 || lowest > 599 {
+		return 0, 0, fmt.Errorf("invalid status code %q", bounds[0])
+	}
+
+	highest := lowest
+	if len(bounds) == 2 {
+		highest, err = strconv.Atoi(bounds[1])
+		if err != nil || highest < lowest || highest > 599 {
+			return 0, 0, fmt.Errorf("invalid range of status codes %q", val)
+		}
+	}
+
+	return lowest, highest, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthcheck
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	path := parser.GetAnnotationWithPrefix(pathAnnotation)
+	interval := parser.GetAnnotationWithPrefix(intervalAnnotation)
+	timeout := parser.GetAnnotationWithPrefix(timeoutAnnotation)
+	healthyThreshold := parser.GetAnnotationWithPrefix(healthyThresholdAnnotation)
+	unhealthyThreshold := parser.GetAnnotationWithPrefix(unhealthyThresholdAnnotation)
+	expectedStatus := parser.GetAnnotationWithPrefix(expectedStatusAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"default values": {
+			annotations: map[string]string{
+				path: "/healthz",
+			},
+			expected: &Config{
+				Path:               "/healthz",
+				Interval:           10000,
+				Timeout:            2000,
+				HealthyThreshold:   2,
+				UnhealthyThreshold: 3,
+				ExpectedStatus:     "200-399",
+			},
+		},
+		"every annotation": {
+			annotations: map[string]string{
+				path:               "/ready?full=1",
+				interval:           "5s",
+				timeout:            "500ms",
+				healthyThreshold:   "1",
+				unhealthyThreshold: "5",
+				expectedStatus:     "200, 204 ,300 - 301",
+			},
+			expected: &Config{
+				Path:               "/ready?full=1",
+				Interval:           5000,
+				Timeout:            500,
+				HealthyThreshold:   1,
+				UnhealthyThreshold: 5,
+				ExpectedStatus:     "200,204,300-301",
+			},
+		},
+		"interval shorter than the default timeout": {
+			annotations: map[string]string{
+				path:     "/healthz",
+				interval: "1s",
+			},
+			expected: &Config{
+				Path:               "/healthz",
+				Interval:           1000,
+				Timeout:            1000,
+				HealthyThreshold:   2,
+				UnhealthyThreshold: 3,
+				ExpectedStatus:     "200-399",
+			},
+		},
+		"relative path": {
+			annotations: map[string]string{
+				path: "healthz",
+			},
+			expectErr: true,
+		},
+		"path with spaces": {
+			annotations: map[string]string{
+				path: "/health z",
+			},
+			expectErr: true,
+		},
+		"interval too short": {
+			annotations: map[string]string{
+				path:     "/healthz",
+				interval: "100ms",
+			},
+			expectErr: true,
+		},
+		"timeout longer than the interval": {
+			annotations: map[string]string{
+				path:     "/healthz",
+				interval: "5s",
+				timeout:  "10s",
+			},
+			expectErr: true,
+		},
+		"invalid threshold": {
+			annotations: map[string]string{
+				path:             "/healthz",
+				healthyThreshold: "0",
+			},
+			expectErr: true,
+		},
+		"invalid status code": {
+			annotations: map[string]string{
+				path:           "/healthz",
+				expectedStatus: "2xx",
+			},
+			expectErr: true,
+		},
+		"inverted range of status codes": {
+			annotations: map[string]string{
+				path:           "/healthz",
+				expectedStatus: "299-200",
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{interval: "5s"})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	config := Config{ExpectedStatus: "200,204,300-399"}
+
+	for code, expected := range map[int]bool{
+		200: true,
+		201: false,
+		204: true,
+		302: true,
+		404: false,
+		503: false,
+	} {
+		if matches := config.Matches(code); matches != expected {
+			t.Errorf("expected %v for the status code %v but returned %v", expected, code, matches)
+		}
+	}
+}
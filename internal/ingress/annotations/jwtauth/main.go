@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwtauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/inspector"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	jwksSecretAnnotation     = "jwt-auth-jwks-secret"
+	jwksURLAnnotation        = "jwt-auth-jwks-url"
+	issuerAnnotation         = "jwt-auth-issuer"
+	audienceAnnotation       = "jwt-auth-audience"
+	requiredClaimsAnnotation = "jwt-auth-required-claims"
+	claimHeadersAnnotation   = "jwt-auth-claim-headers"
+
+	// JWKSSecretKey is the key of the Secret containing the JSON Web Key Set
+	JWKSSecretKey = "jwks.json"
+)
+
+var (
+	headerRegexp = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	claimRegexp  = regexp.MustCompile(`^[a-zA-Z\d\-_.:/]+$`)
+	// values are rendered as Lua strings
+	invalidValueRegexp = regexp.MustCompile(`["'\\\x00-\x1f\x7f]`)
+
+	// supportedKeyTypes are the types of keys that can verify tokens in Lua
+	supportedKeyTypes = map[string]bool{"RSA": true, "EC": true}
+)
+
+// Config contains the JSON Web Token validation of a location
+type Config struct {
+	// JWKSSecret is the Secret (namespace/name) containing the key set
+	JWKSSecret string `json:"jwksSecret,omitempty"`
+	// JWKSURL is the URL of the key set
+	JWKSURL string `json:"jwksURL,omitempty"`
+	// JWKSKey identifies the key set in Lua
+	JWKSKey string `json:"jwksKey,omitempty"`
+	// JWKS is the key set read from the Secret. It is sent to Lua dynamically
+	// and not compared, so rotating the keys does not require a reload.
+	JWKS string `json:"-"`
+	// Issuer is the expected value of the iss claim
+	Issuer string `json:"issuer,omitempty"`
+	// Audiences contains the accepted values of the aud claim
+	Audiences []string `json:"audiences,omitempty"`
+	// RequiredClaims contains the claims, and their values, tokens must contain
+	RequiredClaims map[string]string `json:"requiredClaims,omitempty"`
+	// ClaimHeaders contains the claims sent to the upstream and the name of the header
+	ClaimHeaders map[string]string `json:"claimHeaders,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.JWKSSecret != c2.JWKSSecret || c1.JWKSURL != c2.JWKSURL || c1.JWKSKey != c2.JWKSKey {
+		return false
+	}
+	if c1.Issuer != c2.Issuer {
+		return false
+	}
+	if len(c1.Audiences) != len(c2.Audiences) {
+		return false
+	}
+	for i := range c1.Audiences {
+		if c1.Audiences[i] != c2.Audiences[i] {
+			return false
+		}
+	}
+
+	return equalMaps(c1.RequiredClaims, c2.RequiredClaims) && equalMaps(c1.ClaimHeaders, c2.ClaimHeaders)
+}
+
+func equalMaps(m1, m2 map[string]string) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v := range m1 {
+		if v2, ok := m2[k]; !ok || v != v2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+type jwtAuth struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new JWT validation annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return jwtAuth{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to validate JSON Web Tokens
+func (a jwtAuth) Parse(ing *networking.Ingress) (interface{}, error) {
+	return a.parse(ing.Namespace, func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to validate JSON Web Tokens
+func (a jwtAuth) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return a.parse(mci.Namespace, func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the annotations of an object. Invalid configurations deny the
+// access to the locations instead of disabling the validation.
+func (a jwtAuth) parse(namespace string, annotation func(string) (string, error)) (interface{}, error) {
+	secret, secretErr := annotation(jwksSecretAnnotation)
+	jwksURL, urlErr := annotation(jwksURLAnnotation)
+
+	if ing_errors.IsMissingAnnotations(secretErr) && ing_errors.IsMissingAnnotations(urlErr) {
+		return nil, ing_errors.ErrMissingAnnotations
+	}
+
+	if secretErr == nil && urlErr == nil {
+		return nil, denied("the key set must be read either from a Secret or from a URL")
+	}
+
+	config := &Config{}
+
+	switch {
+	case secretErr == nil:
+		ns, name, err := cache.SplitMetaNamespaceKey(secret)
+		if err != nil || name == "" {
+			return nil, denied(fmt.Sprintf("invalid Secret %q", secret))
+		}
+		if ns == "" {
+			ns = namespace
+		}
+
+		config.JWKSSecret = fmt.Sprintf("%v/%v", ns, name)
+		config.JWKSKey = "secret:" + config.JWKSSecret
+
+		s, err := a.r.GetSecret(config.JWKSSecret)
+		if err != nil {
+			return nil, denied(fmt.Sprintf("unexpected error reading Secret %v: %v", config.JWKSSecret, err))
+		}
+
+		jwks, ok := s.Data[JWKSSecretKey]
+		if !ok {
+			return nil, denied(fmt.Sprintf("Secret %v does not contain the key %v", config.JWKSSecret, JWKSSecretKey))
+		}
+
+		if err := ValidateJWKS(jwks); err != nil {
+			return nil, denied(fmt.Sprintf("invalid key set in Secret %v: %v", config.JWKSSecret, err))
+		}
+		config.JWKS = string(jwks)
+	case urlErr == nil:
+		u, err := url.Parse(jwksURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, denied(fmt.Sprintf("invalid key set URL %q", jwksURL))
+		}
+
+		config.JWKSURL = jwksURL
+		config.JWKSKey = "url:" + jwksURL
+	default:
+		err := secretErr
+		if err == nil || ing_errors.IsMissingAnnotations(err) {
+			err = urlErr
+		}
+		return nil, denied(err.Error())
+	}
+
+	var err error
+
+	if config.Issuer, err = annotation(issuerAnnotation); err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, denied(err.Error())
+	}
+	if err := checkValue(config.Issuer); err != nil {
+		return nil, denied(fmt.Sprintf("invalid issuer: %v", err))
+	}
+
+	audiences, err := annotation(audienceAnnotation)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, denied(err.Error())
+	}
+	for _, audience := range strings.Split(audiences, ",") {
+		audience = strings.TrimSpace(audience)
+		if audience == "" {
+			continue
+		}
+		if err := checkValue(audience); err != nil {
+			return nil, denied(fmt.Sprintf("invalid audience: %v", err))
+		}
+		config.Audiences = append(config.Audiences, audience)
+	}
+
+	if config.RequiredClaims, err = parsePairs(annotation, requiredClaimsAnnotation, "=", checkValue); err != nil {
+		return nil, err
+	}
+
+	if config.ClaimHeaders, err = parsePairs(annotation, claimHeadersAnnotation, ":", checkHeader); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parsePairs parses a comma separated list of claims and values
+func parsePairs(annotation func(string) (string, error), name, separator string, check func(string) error) (map[string]string, error) {
+	val, err := annotation(name)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return nil, nil
+		}
+		return nil, denied(err.Error())
+	}
+
+	pairs := map[string]string{}
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, separator, 2)
+		if len(parts) != 2 {
+			return nil, denied(fmt.Sprintf("expected a value with the format 'claim%svalue' in %v but got %q", separator, name, pair))
+		}
+
+		claim, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !claimRegexp.MatchString(claim) {
+			return nil, denied(fmt.Sprintf("invalid claim %q in %v", claim, name))
+		}
+		if err := check(value); err != nil {
+			return nil, denied(fmt.Sprintf("invalid value of claim %q in %v: %v", claim, name, err))
+		}
+
+		pairs[claim] = value
+	}
+
+	return pairs, nil
+}
+
+func checkValue(value string) error {
+	if invalidValueRegexp.MatchString(value) {
+		return fmt.Errorf("%q cannot contain quotes, backslashes or control characters", value)
+	}
+
+	return inspector.CheckRegex(value)
+}
+
+func checkHeader(header string) error {
+	if !headerRegexp.MatchString(header) {
+		return fmt.Errorf("invalid header name %q", header)
+	}
+
+	return nil
+}
+
+func denied(reason string) error {
+	return ing_errors.NewLocationDenied(reason)
+}
+
+// ValidateJWKS checks the key set is a JSON object containing keys that can
+// verify tokens
+func ValidateJWKS(data []byte) error {
+	var jwks struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return err
+	}
+
+	if len(jwks.Keys) == 0 {
+		return fmt.Errorf("the key set does not contain keys")
+	}
+
+	for i, key := range jwks.Keys {
+		kty, _ := key["kty"].(string)
+		if !supportedKeyTypes[kty] {
+			types := []string{}
+			for t := range supportedKeyTypes {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			return fmt.Errorf("key %v has an unsupported type %q (expected one of %v)", i, kty, strings.Join(types, ", "))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+	secrets map[string]*api.Secret
+}
+
+func (m mockSecret) GetSecret(name string) (*api.Secret, error) {
+	if s, ok := m.secrets[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+// newJWKS returns a key set containing the public keys of a RSA and an ECDSA
+// key generated locally
+func newJWKS(t *testing.T) []byte {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating RSA key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating ECDSA key: %v", err)
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa",
+				"alg": "RS256",
+				"n":   enc(rsaKey.N.Bytes()),
+				"e":   enc(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   enc(ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   enc(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error encoding key set: %v", err)
+	}
+
+	return jwks
+}
+
+func TestParseByMCI(t *testing.T) {
+	secret := parser.GetAnnotationWithPrefix(jwksSecretAnnotation)
+	jwksURL := parser.GetAnnotationWithPrefix(jwksURLAnnotation)
+	issuer := parser.GetAnnotationWithPrefix(issuerAnnotation)
+	audience := parser.GetAnnotationWithPrefix(audienceAnnotation)
+	requiredClaims := parser.GetAnnotationWithPrefix(requiredClaimsAnnotation)
+	claimHeaders := parser.GetAnnotationWithPrefix(claimHeadersAnnotation)
+
+	jwks := newJWKS(t)
+
+	ap := NewParser(mockSecret{
+		secrets: map[string]*api.Secret{
+			"default/jwks": {
+				Data: map[string][]byte{JWKSSecretKey: jwks},
+			},
+			"default/empty": {
+				Data: map[string][]byte{JWKSSecretKey: []byte(`{"keys":[]}`)},
+			},
+			"default/symmetric": {
+				Data: map[string][]byte{JWKSSecretKey: []byte(`{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`)},
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"key set from a secret": {
+			annotations: map[string]string{
+				secret:         "jwks",
+				issuer:         "https://issuer.example.com/",
+				audience:       "api, web",
+				requiredClaims: "scope=write,https://example.com/tenant=blue",
+				claimHeaders:   "sub:X-User,email:X-Email",
+			},
+			expected: &Config{
+				JWKSSecret:     "default/jwks",
+				JWKSKey:        "secret:default/jwks",
+				JWKS:           string(jwks),
+				Issuer:         "https://issuer.example.com/",
+				Audiences:      []string{"api", "web"},
+				RequiredClaims: map[string]string{"scope": "write", "https://example.com/tenant": "blue"},
+				ClaimHeaders:   map[string]string{"sub": "X-User", "email": "X-Email"},
+			},
+		},
+		"key set from a url": {
+			annotations: map[string]string{
+				jwksURL: "https://issuer.example.com/.well-known/jwks.json",
+			},
+			expected: &Config{
+				JWKSURL: "https://issuer.example.com/.well-known/jwks.json",
+				JWKSKey: "url:https://issuer.example.com/.well-known/jwks.json",
+			},
+		},
+		"secret and url": {
+			annotations: map[string]string{
+				secret:  "jwks",
+				jwksURL: "https://issuer.example.com/.well-known/jwks.json",
+			},
+			expectErr: true,
+		},
+		"missing secret": {
+			annotations: map[string]string{
+				secret: "other/jwks",
+			},
+			expectErr: true,
+		},
+		"empty key set": {
+			annotations: map[string]string{
+				secret: "empty",
+			},
+			expectErr: true,
+		},
+		"symmetric key": {
+			annotations: map[string]string{
+				secret: "symmetric",
+			},
+			expectErr: true,
+		},
+		"invalid url": {
+			annotations: map[string]string{
+				jwksURL: "file:///etc/jwks.json",
+			},
+			expectErr: true,
+		},
+		"invalid claim header": {
+			annotations: map[string]string{
+				secret:       "jwks",
+				claimHeaders: "sub:X User",
+			},
+			expectErr: true,
+		},
+		"quote in required claim": {
+			annotations: map[string]string{
+				secret:         "jwks",
+				requiredClaims: `scope=write"`,
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if !ing_errors.IsLocationDenied(err) {
+					t.Errorf("expected a location denied error but returned %v, %v", result, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{issuer: "https://issuer.example.com/"})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
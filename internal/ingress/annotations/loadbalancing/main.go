@@ -21,9 +21,24 @@ import (
 	networking "k8s.io/api/networking/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+// Algorithms are the load balancing algorithms implemented by the Lua balancer
+var Algorithms = []string{"round_robin", "ewma", "least_request"}
+
+// IsValidAlgorithm returns if the load balancing algorithm is supported
+func IsValidAlgorithm(algorithm string) bool {
+	for _, a := range Algorithms {
+		if a == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
 type loadbalancing struct {
 	r resolver.Resolver
 }
@@ -37,12 +52,30 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // used to indicate if the location/s contains a fragment of
 // configuration to be included inside the paths of the rules
 func (a loadbalancing) Parse(ing *networking.Ingress) (interface{}, error) {
-	return parser.GetStringAnnotation("load-balance", ing)
+	algorithm, err := parser.GetStringAnnotation("load-balance", ing)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(algorithm)
 }
 
 // ParseByMCI parses the annotations contained in the multiclusteringress rule
 // used to indicate if the location/s contains a fragment of
 // configuration to be included inside the paths of the rules
 func (a loadbalancing) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
-	return parser.GetStringAnnotationFromMCI("load-balance", mci)
+	algorithm, err := parser.GetStringAnnotationFromMCI("load-balance", mci)
+	if err != nil {
+		return "", err
+	}
+
+	return validate(algorithm)
+}
+
+func validate(algorithm string) (string, error) {
+	if !IsValidAlgorithm(algorithm) {
+		return "", ing_errors.NewInvalidAnnotationContent("load-balance", algorithm)
+	}
+
+	return algorithm, nil
 }
@@ -38,7 +38,9 @@ func TestParse(t *testing.T) {
 		annotations map[string]string
 		expected    string
 	}{
-		{map[string]string{annotation: "ip_hash"}, "ip_hash"},
+		{map[string]string{annotation: "ewma"}, "ewma"},
+		{map[string]string{annotation: "least_request"}, "least_request"},
+		{map[string]string{annotation: "ip_hash"}, ""},
 		{map[string]string{}, ""},
 		{nil, ""},
 	}
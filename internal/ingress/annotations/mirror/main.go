@@ -18,19 +18,51 @@ package mirror
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
+const (
+	requestBodyAnnotation = "mirror-request-body"
+	targetAnnotation      = "mirror-target"
+	clusterAnnotation     = "mirror-cluster"
+	backendAnnotation     = "mirror-backend"
+	sampleAnnotation      = "mirror-sample"
+
+	// defaultSample mirrors every request
+	defaultSample = 100
+)
+
+var clusterRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 // Config returns the mirror to use in a given location
 type Config struct {
 	Source      string `json:"source"`
 	RequestBody string `json:"requestBody"`
 	Target      string `json:"target"`
+
+	// Cluster is the member cluster whose endpoints of the backend of the
+	// location receive the copies of the requests
+	Cluster string `json:"cluster,omitempty"`
+	// Backend is the service (name:port), in the namespace of the object,
+	// receiving the copies of the requests
+	Backend string `json:"backend,omitempty"`
+	// Sample is the percentage of the requests mirrored
+	Sample int `json:"sample,omitempty"`
+	// Upstream is the name of the backend receiving the copies of the
+	// requests, set by the controller for the Cluster and Backend mirrors
+	Upstream string `json:"upstream,omitempty"`
 }
 
 // Equal tests for equality between two Configuration types
@@ -55,7 +87,39 @@ func (m1 *Config) Equal(m2 *Config) bool {
 		return false
 	}
 
-	return true
+	if m1.Cluster != m2.Cluster {
+		return false
+	}
+
+	if m1.Backend != m2.Backend {
+		return false
+	}
+
+	if m1.Sample != m2.Sample {
+		return false
+	}
+
+	return m1.Upstream == m2.Upstream
+}
+
+// ServiceBackend returns the service of the Backend mirror
+func (m Config) ServiceBackend() *networking.IngressServiceBackend {
+	name, port, err := parseBackend(m.Backend)
+	if err != nil {
+		return nil
+	}
+
+	svc := &networking.IngressServiceBackend{
+		Name: name,
+	}
+
+	if port.Type == intstr.String {
+		svc.Port.Name = port.StrVal
+	} else {
+		svc.Port.Number = port.IntVal
+	}
+
+	return svc
 }
 
 type mirror struct {
@@ -67,46 +131,105 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 	return mirror{r}
 }
 
-// Parse parses the annotations contained in the multiclusteringress
+// Parse parses the annotations contained in the ingress
 // rule used to configure mirror
 func (a mirror) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(ing.UID, func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to configure mirror
+func (a mirror) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(mci.UID, func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the mirror annotations. A location mirrors its requests to
+// the target URI, to the endpoints of its backend in another member
+// cluster, or to another backend.
+func parse(uid types.UID, annotation func(string) (string, error)) (*Config, error) {
 	config := &Config{
-		Source: fmt.Sprintf("/_mirror-%v", ing.UID),
+		Source: fmt.Sprintf("/_mirror-%v", uid),
 	}
 
 	var err error
-	config.RequestBody, err = parser.GetStringAnnotation("mirror-request-body", ing)
+	config.RequestBody, err = annotation(requestBodyAnnotation)
 	if err != nil || config.RequestBody != "off" {
 		config.RequestBody = "on"
 	}
 
-	config.Target, err = parser.GetStringAnnotation("mirror-target", ing)
+	config.Target, err = annotation(targetAnnotation)
 	if err != nil {
 		config.Target = ""
+	}
+
+	config.Cluster, err = annotation(clusterAnnotation)
+	if err != nil {
+		config.Cluster = ""
+	}
+	config.Cluster = strings.TrimSpace(config.Cluster)
+	if config.Cluster != "" && !clusterRegexp.MatchString(config.Cluster) {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(clusterAnnotation,
+			fmt.Sprintf("expected the name of a member cluster but got %q", config.Cluster))
+	}
+
+	config.Backend, err = annotation(backendAnnotation)
+	if err != nil {
+		config.Backend = ""
+	}
+	config.Backend = strings.TrimSpace(config.Backend)
+	if config.Backend != "" {
+		if _, _, err := parseBackend(config.Backend); err != nil {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(backendAnnotation, err.Error())
+		}
+	}
+
+	mirrors := 0
+	for _, m := range []string{config.Target, config.Cluster, config.Backend} {
+		if m != "" {
+			mirrors++
+		}
+	}
+	if mirrors > 1 {
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(targetAnnotation,
+			fmt.Sprintf("only one of %v, %v and %v can be defined", targetAnnotation, clusterAnnotation, backendAnnotation))
+	}
+	if mirrors == 0 {
 		config.Source = ""
+		return config, nil
+	}
+
+	config.Sample = defaultSample
+	sample, err := annotation(sampleAnnotation)
+	if err == nil {
+		config.Sample, err = strconv.Atoi(strings.TrimSpace(sample))
+		if err != nil || config.Sample < 1 || config.Sample > 100 {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(sampleAnnotation,
+				fmt.Sprintf("expected a percentage between 1 and 100 but got %q", sample))
+		}
 	}
 
 	return config, nil
 }
 
-// ParseByMCI parses the annotations contained in the ingress
-// rule used to configure mirror
-func (a mirror) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
-	config := &Config{
-		Source: fmt.Sprintf("/_mirror-%v", mci.UID),
+// parseBackend parses a service backend (name:port)
+func parseBackend(backend string) (string, intstr.IntOrString, error) {
+	parts := strings.Split(backend, ":")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", intstr.IntOrString{}, fmt.Errorf("expected a service and its port (name:port) but got %q", backend)
 	}
 
-	var err error
-	config.RequestBody, err = parser.GetStringAnnotationFromMCI("mirror-request-body", mci)
-	if err != nil || config.RequestBody != "off" {
-		config.RequestBody = "on"
+	if errs := validation.IsDNS1035Label(parts[0]); len(errs) > 0 {
+		return "", intstr.IntOrString{}, fmt.Errorf("invalid service name %q: %v", parts[0], strings.Join(errs, ", "))
 	}
 
-	config.Target, err = parser.GetStringAnnotationFromMCI("mirror-target", mci)
-	if err != nil {
-		config.Target = ""
-		config.Source = ""
+	port := intstr.Parse(parts[1])
+	if port.Type == intstr.Int && (port.IntVal < 1 || port.IntVal > 65535) {
+		return "", intstr.IntOrString{}, fmt.Errorf("invalid port %q of service %q", parts[1], parts[0])
 	}
 
-	return config, nil
+	return parts[0], port, nil
 }
@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"testing"
 
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +46,7 @@ func TestParse(t *testing.T) {
 			Source:      ngxURI,
 			RequestBody: "on",
 			Target:      "https://test.env.com/$request_uri",
+			Sample:      100,
 		}},
 		{map[string]string{requestBody: "off"}, &Config{
 			Source:      "",
@@ -70,3 +72,96 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseByMCI(t *testing.T) {
+	target := parser.GetAnnotationWithPrefix(targetAnnotation)
+	cluster := parser.GetAnnotationWithPrefix(clusterAnnotation)
+	backend := parser.GetAnnotationWithPrefix(backendAnnotation)
+	sample := parser.GetAnnotationWithPrefix(sampleAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	ngxURI := "/_mirror-c89a5111-b2e9-4af8-be19-c2a4a924c256"
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"member cluster": {
+			annotations: map[string]string{cluster: "member2", sample: "10"},
+			expected:    &Config{Source: ngxURI, RequestBody: "on", Cluster: "member2", Sample: 10},
+		},
+		"backend": {
+			annotations: map[string]string{backend: "shadow:8080"},
+			expected:    &Config{Source: ngxURI, RequestBody: "on", Backend: "shadow:8080", Sample: 100},
+		},
+		"backend with a named port": {
+			annotations: map[string]string{backend: "shadow:http"},
+			expected:    &Config{Source: ngxURI, RequestBody: "on", Backend: "shadow:http", Sample: 100},
+		},
+		"invalid member cluster": {
+			annotations: map[string]string{cluster: "Member_2"},
+			expectErr:   true,
+		},
+		"backend without port": {
+			annotations: map[string]string{backend: "shadow"},
+			expectErr:   true,
+		},
+		"invalid backend port": {
+			annotations: map[string]string{backend: "shadow:70000"},
+			expectErr:   true,
+		},
+		"several mirrors": {
+			annotations: map[string]string{target: "https://test.env.com/$request_uri", cluster: "member2"},
+			expectErr:   true,
+		},
+		"sample of zero": {
+			annotations: map[string]string{cluster: "member2", sample: "0"},
+			expectErr:   true,
+		},
+		"invalid sample": {
+			annotations: map[string]string{cluster: "member2", sample: "10%"},
+			expectErr:   true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+			UID:       "c89a5111-b2e9-4af8-be19-c2a4a924c256",
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestServiceBackend(t *testing.T) {
+	svc := Config{Backend: "shadow:8080"}.ServiceBackend()
+	if svc == nil || svc.Name != "shadow" || svc.Port.Number != 8080 {
+		t.Errorf("expected service shadow and port 8080 but returned %+v", svc)
+	}
+
+	svc = Config{Backend: "shadow:http"}.ServiceBackend()
+	if svc == nil || svc.Name != "shadow" || svc.Port.Name != "http" {
+		t.Errorf("expected service shadow and port http but returned %+v", svc)
+	}
+}
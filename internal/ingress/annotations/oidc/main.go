@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	issuerAnnotation       = "oidc-issuer"
+	clientIDAnnotation     = "oidc-client-id"
+	secretAnnotation       = "oidc-secret"
+	scopesAnnotation       = "oidc-scopes"
+	redirectPathAnnotation = "oidc-redirect-path"
+
+	// ClientSecretKey is the key of the Secret containing the client secret
+	ClientSecretKey = "client-secret"
+	// CookieSecretKey is the key of the Secret containing the secret used to
+	// encrypt the session cookies
+	CookieSecretKey = "cookie-secret"
+
+	defaultRedirectPath = "/oauth2/callback"
+	// minCookieSecretSize is the minimum size of the cookie secret in bytes
+	minCookieSecretSize = 16
+)
+
+var (
+	clientIDRegexp     = regexp.MustCompile(`^[a-zA-Z\d\-_.:@]+$`)
+	scopeRegexp        = regexp.MustCompile(`^[a-zA-Z\d\-_.:/]+$`)
+	redirectPathRegexp = regexp.MustCompile(`^/[a-zA-Z\d\-_./]*$`)
+)
+
+// Secrets contains the values read from the Secret of a provider. They are
+// sent to Lua dynamically and never written to the configuration file.
+type Secrets struct {
+	ClientSecret string `json:"clientSecret"`
+	CookieSecret string `json:"cookieSecret"`
+}
+
+// Config contains the OpenID Connect login of a location
+type Config struct {
+	// Issuer is the URL of the OpenID provider
+	Issuer string `json:"issuer,omitempty"`
+	// ClientID is the identifier of the client registered in the provider
+	ClientID string `json:"clientID,omitempty"`
+	// Secret is the Secret (namespace/name) containing the client and cookie secrets
+	Secret string `json:"secret,omitempty"`
+	// Scopes contains the scopes requested, always including openid
+	Scopes []string `json:"scopes,omitempty"`
+	// RedirectPath is the path of the server receiving the authorization code
+	RedirectPath string `json:"redirectPath,omitempty"`
+	// Key identifies the provider, its secrets and its session cookie in Lua
+	Key string `json:"key,omitempty"`
+	// Secrets are not compared with the location but configured dynamically,
+	// so rotating them does not require a reload
+	Secrets Secrets `json:"-"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Issuer != c2.Issuer || c1.ClientID != c2.ClientID || c1.Secret != c2.Secret {
+		return false
+	}
+	if c1.RedirectPath != c2.RedirectPath || c1.Key != c2.Key {
+		return false
+	}
+	if len(c1.Scopes) != len(c2.Scopes) {
+		return false
+	}
+	for i := range c1.Scopes {
+		if c1.Scopes[i] != c2.Scopes[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type oidc struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new OpenID Connect annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return oidc{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to log in with an OpenID provider
+func (o oidc) Parse(ing *networking.Ingress) (interface{}, error) {
+	return o.parse(ing.Namespace, func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to log in with an OpenID provider
+func (o oidc) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return o.parse(mci.Namespace, func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the annotations of an object. Invalid configurations deny the
+// access to the locations instead of disabling the login.
+func (o oidc) parse(namespace string, annotation func(string) (string, error)) (interface{}, error) {
+	issuer, err := annotation(issuerAnnotation)
+	if err != nil {
+		if ing_errors.IsMissingAnnotations(err) {
+			return nil, err
+		}
+		return nil, denied(err.Error())
+	}
+
+	u, err := url.Parse(issuer)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, denied(fmt.Sprintf("invalid issuer %q", issuer))
+	}
+
+	config := &Config{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		RedirectPath: defaultRedirectPath,
+	}
+
+	if config.ClientID, err = annotation(clientIDAnnotation); err != nil {
+		return nil, denied(fmt.Sprintf("the client ID is required: %v", err))
+	}
+	if !clientIDRegexp.MatchString(config.ClientID) {
+		return nil, denied(fmt.Sprintf("invalid client ID %q", config.ClientID))
+	}
+
+	secret, err := annotation(secretAnnotation)
+	if err != nil {
+		return nil, denied(fmt.Sprintf("the Secret is required: %v", err))
+	}
+	ns, name, err := cache.SplitMetaNamespaceKey(secret)
+	if err != nil || name == "" {
+		return nil, denied(fmt.Sprintf("invalid Secret %q", secret))
+	}
+	if ns == "" {
+		ns = namespace
+	}
+	config.Secret = fmt.Sprintf("%v/%v", ns, name)
+
+	if config.Secrets, err = o.readSecrets(config.Secret); err != nil {
+		return nil, err
+	}
+
+	scopes, err := annotation(scopesAnnotation)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, denied(err.Error())
+	}
+	config.Scopes = []string{"openid"}
+	for _, scope := range strings.FieldsFunc(scopes, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !scopeRegexp.MatchString(scope) {
+			return nil, denied(fmt.Sprintf("invalid scope %q", scope))
+		}
+		if scope != "openid" {
+			config.Scopes = append(config.Scopes, scope)
+		}
+	}
+
+	redirectPath, err := annotation(redirectPathAnnotation)
+	if err != nil && !ing_errors.IsMissingAnnotations(err) {
+		return nil, denied(err.Error())
+	}
+	if err == nil {
+		if !redirectPathRegexp.MatchString(redirectPath) || strings.Contains(redirectPath, "//") {
+			return nil, denied(fmt.Sprintf("invalid redirect path %q", redirectPath))
+		}
+		config.RedirectPath = redirectPath
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{config.Issuer, config.ClientID, config.Secret}, "\n")))
+	config.Key = hex.EncodeToString(sum[:8])
+
+	return config, nil
+}
+
+func (o oidc) readSecrets(name string) (Secrets, error) {
+	s, err := o.r.GetSecret(name)
+	if err != nil {
+		return Secrets{}, denied(fmt.Sprintf("unexpected error reading Secret %v: %v", name, err))
+	}
+
+	clientSecret, ok := s.Data[ClientSecretKey]
+	if !ok || len(clientSecret) == 0 {
+		return Secrets{}, denied(fmt.Sprintf("Secret %v does not contain the key %v", name, ClientSecretKey))
+	}
+
+	cookieSecret, ok := s.Data[CookieSecretKey]
+	if !ok || len(cookieSecret) < minCookieSecretSize {
+		return Secrets{}, denied(fmt.Sprintf("Secret %v must contain a key %v of at least %v bytes", name, CookieSecretKey, minCookieSecretSize))
+	}
+
+	return Secrets{
+		ClientSecret: string(clientSecret),
+		CookieSecret: string(cookieSecret),
+	}, nil
+}
+
+func denied(reason string) error {
+	return ing_errors.NewLocationDenied(reason)
+}
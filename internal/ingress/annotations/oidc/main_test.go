@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oidc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+	secrets map[string]*api.Secret
+}
+
+func (m mockSecret) GetSecret(name string) (*api.Secret, error) {
+	if s, ok := m.secrets[name]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+func TestParseByMCI(t *testing.T) {
+	issuer := parser.GetAnnotationWithPrefix(issuerAnnotation)
+	clientID := parser.GetAnnotationWithPrefix(clientIDAnnotation)
+	secret := parser.GetAnnotationWithPrefix(secretAnnotation)
+	scopes := parser.GetAnnotationWithPrefix(scopesAnnotation)
+	redirectPath := parser.GetAnnotationWithPrefix(redirectPathAnnotation)
+
+	ap := NewParser(mockSecret{
+		secrets: map[string]*api.Secret{
+			"default/oidc": {
+				Data: map[string][]byte{
+					ClientSecretKey: []byte("client-secret"),
+					CookieSecretKey: []byte("0123456789abcdef0123456789abcdef"),
+				},
+			},
+			"auth/oidc": {
+				Data: map[string][]byte{
+					ClientSecretKey: []byte("other-secret"),
+					CookieSecretKey: []byte("fedcba9876543210"),
+				},
+			},
+			"default/short": {
+				Data: map[string][]byte{
+					ClientSecretKey: []byte("client-secret"),
+					CookieSecretKey: []byte("short"),
+				},
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"default scopes and redirect path": {
+			annotations: map[string]string{
+				issuer:   "https://issuer.example.com/",
+				clientID: "dashboard",
+				secret:   "oidc",
+			},
+			expected: &Config{
+				Issuer:       "https://issuer.example.com",
+				ClientID:     "dashboard",
+				Secret:       "default/oidc",
+				Scopes:       []string{"openid"},
+				RedirectPath: "/oauth2/callback",
+				Secrets: Secrets{
+					ClientSecret: "client-secret",
+					CookieSecret: "0123456789abcdef0123456789abcdef",
+				},
+			},
+		},
+		"every annotation": {
+			annotations: map[string]string{
+				issuer:       "https://issuer.example.com/realms/team",
+				clientID:     "dashboard",
+				secret:       "auth/oidc",
+				scopes:       "email, profile openid",
+				redirectPath: "/login/callback",
+			},
+			expected: &Config{
+				Issuer:       "https://issuer.example.com/realms/team",
+				ClientID:     "dashboard",
+				Secret:       "auth/oidc",
+				Scopes:       []string{"openid", "email", "profile"},
+				RedirectPath: "/login/callback",
+				Secrets: Secrets{
+					ClientSecret: "other-secret",
+					CookieSecret: "fedcba9876543210",
+				},
+			},
+		},
+		"invalid issuer": {
+			annotations: map[string]string{
+				issuer:   "issuer.example.com",
+				clientID: "dashboard",
+				secret:   "oidc",
+			},
+			expectErr: true,
+		},
+		"missing client ID": {
+			annotations: map[string]string{
+				issuer: "https://issuer.example.com",
+				secret: "oidc",
+			},
+			expectErr: true,
+		},
+		"missing secret": {
+			annotations: map[string]string{
+				issuer:   "https://issuer.example.com",
+				clientID: "dashboard",
+				secret:   "missing",
+			},
+			expectErr: true,
+		},
+		"short cookie secret": {
+			annotations: map[string]string{
+				issuer:   "https://issuer.example.com",
+				clientID: "dashboard",
+				secret:   "short",
+			},
+			expectErr: true,
+		},
+		"invalid scope": {
+			annotations: map[string]string{
+				issuer:   "https://issuer.example.com",
+				clientID: "dashboard",
+				secret:   "oidc",
+				scopes:   `email"`,
+			},
+			expectErr: true,
+		},
+		"invalid redirect path": {
+			annotations: map[string]string{
+				issuer:       "https://issuer.example.com",
+				clientID:     "dashboard",
+				secret:       "oidc",
+				redirectPath: "//evil.example.com/callback",
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if !ing_errors.IsLocationDenied(err) {
+					t.Errorf("expected a location denied error but returned %v, %v", result, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config := result.(*Config)
+			if config.Key == "" {
+				t.Errorf("expected a key identifying the provider")
+			}
+			tc.expected.Key = config.Key
+			if !reflect.DeepEqual(config, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, config)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{clientID: "dashboard"})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Issuer: "https://issuer.example.com", Key: "a", Scopes: []string{"openid"}, Secrets: Secrets{ClientSecret: "1"}}
+	c2 := &Config{Issuer: "https://issuer.example.com", Key: "a", Scopes: []string{"openid"}, Secrets: Secrets{ClientSecret: "2"}}
+	if !c1.Equal(c2) {
+		t.Errorf("expected configurations only differing in their secrets to be equal")
+	}
+
+	c2.Scopes = []string{"openid", "email"}
+	if c1.Equal(c2) {
+		t.Errorf("expected configurations with different scopes to be different")
+	}
+}
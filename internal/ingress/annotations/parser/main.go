@@ -166,6 +166,17 @@ func GetIntAnnotationFromMCI(name string, mci *karmadanetworking.MultiClusterIng
 	return ingAnnotations(mci.GetAnnotations()).parseInt(v)
 }
 
+// GetOptionalAnnotation returns the trimmed value of an annotation read with the
+// given function, or an empty string when the annotation is not defined
+func GetOptionalAnnotation(annotation func(string) (string, error), name string) (string, error) {
+	val, err := annotation(name)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return "", err
+	}
+
+	return strings.TrimSpace(val), nil
+}
+
 // GetAnnotationWithPrefix returns the prefix of ingress annotations
 func GetAnnotationWithPrefix(suffix string) string {
 	return fmt.Sprintf("%v/%v", AnnotationsPrefix, suffix)
@@ -130,6 +130,27 @@ rewrite (?i)/arcgis/services/Utilities/Geometry/GeometryServer(.*)$ /arcgis/serv
 	}
 }
 
+func TestGetOptionalAnnotation(t *testing.T) {
+	ing := buildIngress()
+	ing.SetAnnotations(map[string]string{
+		GetAnnotationWithPrefix("string"): "A",
+		GetAnnotationWithPrefix("empty"):  " ",
+	})
+	annotation := func(name string) (string, error) {
+		return GetStringAnnotation(name, ing)
+	}
+
+	if val, err := GetOptionalAnnotation(annotation, "string"); err != nil || val != "A" {
+		t.Errorf("expected \"A\" but \"%v\" was returned with error %v", val, err)
+	}
+	if val, err := GetOptionalAnnotation(annotation, "missing"); err != nil || val != "" {
+		t.Errorf("expected an empty string but \"%v\" was returned with error %v", val, err)
+	}
+	if _, err := GetOptionalAnnotation(annotation, "empty"); err == nil {
+		t.Errorf("expected error but none returned")
+	}
+}
+
 func TestGetIntAnnotation(t *testing.T) {
 	ing := buildIngress()
 
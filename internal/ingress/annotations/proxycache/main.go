@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/inspector"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	enableAnnotation            = "proxy-cache"
+	zoneAnnotation              = "proxy-cache-zone"
+	keyAnnotation               = "proxy-cache-key"
+	validAnnotation             = "proxy-cache-valid"
+	bypassAnnotation            = "proxy-cache-bypass"
+	honorCacheControlAnnotation = "proxy-cache-honor-cache-control"
+
+	// DefaultZone is the cache zone used when the location does not choose one
+	DefaultZone = "default"
+	// DefaultKey identifies a cached response by the request seen by the client.
+	// $proxy_host cannot be used because every location proxies to upstream_balancer.
+	DefaultKey = "$scheme$host$request_uri"
+	// DefaultValid caches the successful responses and redirections when the
+	// upstream does not define the caching time
+	DefaultValid = "200 301 302 10m"
+)
+
+var (
+	zoneRegexp       = regexp.MustCompile(`^[a-z\d_]+$`)
+	headerRegexp     = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	statusCodeRegexp = regexp.MustCompile(`^([1-5]\d{2}|any)$`)
+	durationRegexp   = regexp.MustCompile(`^\d+(ms|s|m|h|d|w|M|y)$`) // see http://nginx.org/en/docs/syntax.html
+	// keys are rendered between double quotes and may reference NGINX variables
+	invalidKeyRegexp = regexp.MustCompile(`["'\\;{}\s\x00-\x1f\x7f]`)
+)
+
+// Config contains the caching of the responses of a location
+type Config struct {
+	// Enabled caches the responses of the location
+	Enabled bool `json:"enabled"`
+	// Zone is the name of the cache zone, configured with the proxy-cache-zones setting
+	Zone string `json:"zone,omitempty"`
+	// Key identifies a cached response
+	Key string `json:"key,omitempty"`
+	// Valid contains the caching time of the responses by status code, in the
+	// format of the proxy_cache_valid directive ("200 302 10m")
+	Valid []string `json:"valid,omitempty"`
+	// Bypass contains the request headers that, when present and not "0",
+	// bypass the cache and prevent the response from being stored
+	Bypass []string `json:"bypass,omitempty"`
+	// HonorCacheControl uses the Cache-Control and Expires headers of the
+	// upstream to decide the caching time of the responses
+	HonorCacheControl bool `json:"honorCacheControl"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Enabled != c2.Enabled || c1.Zone != c2.Zone || c1.Key != c2.Key {
+		return false
+	}
+	if c1.HonorCacheControl != c2.HonorCacheControl {
+		return false
+	}
+
+	return equalStrings(c1.Valid, c2.Valid) && equalStrings(c1.Bypass, c2.Bypass)
+}
+
+func equalStrings(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type proxyCache struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new response cache annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxyCache{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to cache the responses of the upstream
+func (pc proxyCache) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to cache the responses of the upstream
+func (pc proxyCache) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return parse(func(name string) (string, error) {
+		return parser.GetStringAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse reads the cache annotations, returning ErrMissingAnnotations when the
+// cache is not enabled
+func parse(annotation func(string) (string, error)) (*Config, error) {
+	enabled, err := annotation(enableAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if enabled != "true" {
+		if enabled != "false" {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(enableAnnotation,
+				fmt.Sprintf("expected true or false but got %q", enabled))
+		}
+		return &Config{}, nil
+	}
+
+	config := &Config{
+		Enabled:           true,
+		Zone:              DefaultZone,
+		Key:               DefaultKey,
+		Valid:             []string{DefaultValid},
+		HonorCacheControl: true,
+	}
+
+	zone, err := parser.GetOptionalAnnotation(annotation, zoneAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if zone != "" {
+		if !zoneRegexp.MatchString(zone) {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(zoneAnnotation,
+				fmt.Sprintf("invalid cache zone %q", zone))
+		}
+		config.Zone = zone
+	}
+
+	key, err := parser.GetOptionalAnnotation(annotation, keyAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if key != "" {
+		if invalidKeyRegexp.MatchString(key) {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(keyAnnotation,
+				fmt.Sprintf("cache key %q cannot contain quotes, backslashes, semicolons, braces or whitespace", key))
+		}
+		if err := inspector.CheckRegex(key); err != nil {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(keyAnnotation, err.Error())
+		}
+		config.Key = key
+	}
+
+	valid, err := parser.GetOptionalAnnotation(annotation, validAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	if valid != "" {
+		if config.Valid, err = parseValid(valid); err != nil {
+			return &Config{}, err
+		}
+	}
+
+	bypass, err := parser.GetOptionalAnnotation(annotation, bypassAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	for _, name := range strings.Split(bypass, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !headerRegexp.MatchString(name) {
+			return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(bypassAnnotation,
+				fmt.Sprintf("invalid header name %q", name))
+		}
+		config.Bypass = append(config.Bypass, name)
+	}
+
+	honor, err := parser.GetOptionalAnnotation(annotation, honorCacheControlAnnotation)
+	if err != nil {
+		return &Config{}, err
+	}
+	switch honor {
+	case "":
+	case "true", "false":
+		config.HonorCacheControl = honor == "true"
+	default:
+		return &Config{}, ing_errors.NewInvalidAnnotationConfiguration(honorCacheControlAnnotation,
+			fmt.Sprintf("expected true or false but got %q", honor))
+	}
+
+	return config, nil
+}
+
+// parseValid parses a comma separated list of caching times, each one with
+// the status codes followed by the duration, like "200 302 10m, 404 1m"
+func parseValid(val string) ([]string, error) {
+	valid := []string{}
+	for _, entry := range strings.Split(val, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		duration := fields[len(fields)-1]
+		if !durationRegexp.MatchString(duration) {
+			return nil, ing_errors.NewInvalidAnnotationConfiguration(validAnnotation,
+				fmt.Sprintf("invalid duration %q in %q", duration, strings.TrimSpace(entry)))
+		}
+
+		for _, code := range fields[:len(fields)-1] {
+			if !statusCodeRegexp.MatchString(code) {
+				return nil, ing_errors.NewInvalidAnnotationConfiguration(validAnnotation,
+					fmt.Sprintf("invalid status code %q in %q", code, strings.TrimSpace(entry)))
+			}
+		}
+
+		valid = append(valid, strings.Join(fields, " "))
+	}
+
+	if len(valid) == 0 {
+		return nil, ing_errors.NewInvalidAnnotationConfiguration(validAnnotation, "the list of caching times is empty")
+	}
+
+	return valid, nil
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix(enableAnnotation)
+	zone := parser.GetAnnotationWithPrefix(zoneAnnotation)
+	key := parser.GetAnnotationWithPrefix(keyAnnotation)
+	valid := parser.GetAnnotationWithPrefix(validAnnotation)
+	bypass := parser.GetAnnotationWithPrefix(bypassAnnotation)
+	honorCacheControl := parser.GetAnnotationWithPrefix(honorCacheControlAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"default values": {
+			annotations: map[string]string{
+				enable: "true",
+			},
+			expected: &Config{
+				Enabled:           true,
+				Zone:              DefaultZone,
+				Key:               DefaultKey,
+				Valid:             []string{DefaultValid},
+				HonorCacheControl: true,
+			},
+		},
+		"every annotation": {
+			annotations: map[string]string{
+				enable:            "true",
+				zone:              "static_assets",
+				key:               "$host$uri$http_accept_encoding",
+				valid:             "200 302 10m, 404 1m,any 30s",
+				bypass:            "X-No-Cache, Authorization",
+				honorCacheControl: "false",
+			},
+			expected: &Config{
+				Enabled:           true,
+				Zone:              "static_assets",
+				Key:               "$host$uri$http_accept_encoding",
+				Valid:             []string{"200 302 10m", "404 1m", "any 30s"},
+				Bypass:            []string{"X-No-Cache", "Authorization"},
+				HonorCacheControl: false,
+			},
+		},
+		"disabled": {
+			annotations: map[string]string{
+				enable: "false",
+				zone:   "static",
+			},
+			expected: &Config{},
+		},
+		"invalid enable value": {
+			annotations: map[string]string{
+				enable: "yes",
+			},
+			expectErr: true,
+		},
+		"invalid zone": {
+			annotations: map[string]string{
+				enable: "true",
+				zone:   "Static-Assets",
+			},
+			expectErr: true,
+		},
+		"quote in key": {
+			annotations: map[string]string{
+				enable: "true",
+				key:    `$host"; proxy_pass http://evil; #`,
+			},
+			expectErr: true,
+		},
+		"invalid duration": {
+			annotations: map[string]string{
+				enable: "true",
+				valid:  "200 ten",
+			},
+			expectErr: true,
+		},
+		"invalid status code": {
+			annotations: map[string]string{
+				enable: "true",
+				valid:  "2000 10m",
+			},
+			expectErr: true,
+		},
+		"invalid bypass header": {
+			annotations: map[string]string{
+				enable: "true",
+				bypass: "X No Cache",
+			},
+			expectErr: true,
+		},
+		"invalid honor cache control value": {
+			annotations: map[string]string{
+				enable:            "true",
+				honorCacheControl: "sometimes",
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{zone: "static"})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
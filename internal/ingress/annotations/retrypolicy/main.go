@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retrypolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	retryPolicyAnnotation = "retry-policy"
+
+	maxAttempts      = 10
+	maxPerTryTimeout = 10 * time.Minute
+	maxBackoff       = 10 * time.Second
+
+	defaultBackoff = 25 * time.Millisecond
+
+	defaultBudgetPercent    = 20
+	defaultBudgetMinRetries = 3
+)
+
+var (
+	// retryConditions maps the conditions accepted in retryOn to the values of
+	// the proxy_next_upstream directive
+	retryConditions = map[string]string{
+		"error":          "error",
+		"timeout":        "timeout",
+		"invalid_header": "invalid_header",
+		"403":            "http_403",
+		"404":            "http_404",
+		"429":            "http_429",
+		"500":            "http_500",
+		"502":            "http_502",
+		"503":            "http_503",
+		"504":            "http_504",
+	}
+
+	validMethods = sets.NewString("GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE")
+	// nonIdempotentMethods are the methods NGINX never retries unless
+	// proxy_next_upstream contains non_idempotent
+	nonIdempotentMethods = sets.NewString("POST", "PATCH", "LOCK")
+
+	defaultRetryOn = []string{"error", "timeout"}
+	defaultMethods = []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"}
+)
+
+// Config contains the retry policy of the requests of an object
+type Config struct {
+	// Attempts is the maximum number of tries of a request, including the first one
+	Attempts int `json:"attempts,omitempty"`
+	// RetryOn contains the conditions of the proxy_next_upstream directive retrying a request
+	RetryOn []string `json:"retryOn,omitempty"`
+	// Methods contains the HTTP methods of the requests that can be retried
+	Methods []string `json:"methods,omitempty"`
+	// PerTryTimeout is the timeout of each try in milliseconds
+	PerTryTimeout int `json:"perTryTimeout,omitempty"`
+	// Backoff is the base delay in milliseconds during which an endpoint
+	// failing a try receives no retry
+	Backoff int `json:"backoff,omitempty"`
+	// BudgetPercent is the maximum percentage of retries over the requests sent
+	// to a backend
+	BudgetPercent int `json:"budgetPercent,omitempty"`
+	// BudgetMinRetries is the number of retries per second allowed regardless
+	// of the percentage, so backends with little traffic can retry
+	BudgetMinRetries int `json:"budgetMinRetries,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Attempts != c2.Attempts || c1.PerTryTimeout != c2.PerTryTimeout || c1.Backoff != c2.Backoff {
+		return false
+	}
+	if c1.BudgetPercent != c2.BudgetPercent || c1.BudgetMinRetries != c2.BudgetMinRetries {
+		return false
+	}
+
+	return equalStrings(c1.RetryOn, c2.RetryOn) && equalStrings(c1.Methods, c2.Methods)
+}
+
+func equalStrings(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NextUpstream returns the value of the proxy_next_upstream directive
+// retrying the requests of the policy
+func (c Config) NextUpstream() string {
+	conditions := append([]string{}, c.RetryOn...)
+	for _, method := range c.Methods {
+		if nonIdempotentMethods.Has(method) {
+			conditions = append(conditions, "non_idempotent")
+			break
+		}
+	}
+
+	return strings.Join(conditions, " ")
+}
+
+// policy is the format of the annotation
+type policy struct {
+	Attempts         int      `json:"attempts"`
+	RetryOn          []string `json:"retryOn"`
+	Methods          []string `json:"methods"`
+	PerTryTimeout    string   `json:"perTryTimeout"`
+	Backoff          string   `json:"backoff"`
+	BudgetPercent    *int     `json:"budgetPercent"`
+	BudgetMinRetries *int     `json:"budgetMinRetries"`
+}
+
+type retryPolicy struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new retry policy annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return retryPolicy{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to retry the requests sent to the backends
+func (rp retryPolicy) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(retryPolicyAnnotation, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return parse(val)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to retry the requests sent to the backends
+func (rp retryPolicy) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	val, err := parser.GetStringAnnotationFromMCI(retryPolicyAnnotation, mci)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return parse(val)
+}
+
+// parse decodes the policy, applying the default values of the fields not defined
+func parse(val string) (*Config, error) {
+	p := &policy{}
+
+	decoder := json.NewDecoder(bytes.NewBufferString(val))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(p); err != nil {
+		return &Config{}, invalid(fmt.Sprintf("invalid policy: %v", err))
+	}
+
+	if p.Attempts < 1 || p.Attempts > maxAttempts {
+		return &Config{}, invalid(fmt.Sprintf("the attempts must be between 1 and %v", maxAttempts))
+	}
+
+	config := &Config{
+		Attempts:         p.Attempts,
+		Backoff:          int(defaultBackoff / time.Millisecond),
+		BudgetPercent:    defaultBudgetPercent,
+		BudgetMinRetries: defaultBudgetMinRetries,
+	}
+
+	retryOn := p.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = defaultRetryOn
+	}
+	for _, condition := range retryOn {
+		value, ok := retryConditions[strings.ToLower(condition)]
+		if !ok {
+			return &Config{}, invalid(fmt.Sprintf("invalid retry condition %q", condition))
+		}
+		config.RetryOn = append(config.RetryOn, value)
+	}
+
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = defaultMethods
+	}
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		if !validMethods.Has(method) {
+			return &Config{}, invalid(fmt.Sprintf("invalid method %q", method))
+		}
+		config.Methods = append(config.Methods, method)
+	}
+
+	if p.PerTryTimeout != "" {
+		d, err := time.ParseDuration(p.PerTryTimeout)
+		if err != nil || d < time.Millisecond || d > maxPerTryTimeout {
+			return &Config{}, invalid(fmt.Sprintf("the per try timeout must be a duration between 1ms and %v", maxPerTryTimeout))
+		}
+		config.PerTryTimeout = int(d / time.Millisecond)
+	}
+
+	if p.Backoff != "" {
+		d, err := time.ParseDuration(p.Backoff)
+		if err != nil || d < 0 || d > maxBackoff {
+			return &Config{}, invalid(fmt.Sprintf("the backoff must be a duration between 0s and %v", maxBackoff))
+		}
+		config.Backoff = int(d / time.Millisecond)
+	}
+
+	if p.BudgetPercent != nil {
+		if *p.BudgetPercent < 0 || *p.BudgetPercent > 100 {
+			return &Config{}, invalid("the budget percentage must be between 0 and 100")
+		}
+		config.BudgetPercent = *p.BudgetPercent
+	}
+
+	if p.BudgetMinRetries != nil {
+		if *p.BudgetMinRetries < 0 {
+			return &Config{}, invalid("the minimum retries of the budget cannot be negative")
+		}
+		config.BudgetMinRetries = *p.BudgetMinRetries
+	}
+
+	return config, nil
+}
+
+func invalid(reason string) error {
+	return ing_errors.NewInvalidAnnotationConfiguration(retryPolicyAnnotation, reason)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retrypolicy
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix(retryPolicyAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		policy    string
+		expected  *Config
+		expectErr bool
+	}{
+		"default values": {
+			policy: `{"attempts": 3}`,
+			expected: &Config{
+				Attempts:         3,
+				RetryOn:          []string{"error", "timeout"},
+				Methods:          []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"},
+				Backoff:          25,
+				BudgetPercent:    20,
+				BudgetMinRetries: 3,
+			},
+		},
+		"every field": {
+			policy: `{"attempts": 2, "retryOn": ["error", "503"], "methods": ["get", "POST"],
+				"perTryTimeout": "1.5s", "backoff": "100ms", "budgetPercent": 0, "budgetMinRetries": 10}`,
+			expected: &Config{
+				Attempts:         2,
+				RetryOn:          []string{"error", "http_503"},
+				Methods:          []string{"GET", "POST"},
+				PerTryTimeout:    1500,
+				Backoff:          100,
+				BudgetPercent:    0,
+				BudgetMinRetries: 10,
+			},
+		},
+		"invalid JSON": {
+			policy:    `{"attempts": 3`,
+			expectErr: true,
+		},
+		"backoff disabled": {
+			policy: `{"attempts": 3, "backoff": "0s"}`,
+			expected: &Config{
+				Attempts:         3,
+				RetryOn:          []string{"error", "timeout"},
+				Methods:          []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE"},
+				BudgetPercent:    20,
+				BudgetMinRetries: 3,
+			},
+		},
+		"unknown field": {
+			policy:    `{"attempts": 3, "jitter": true}`,
+			expectErr: true,
+		},
+		"missing attempts": {
+			policy:    `{"retryOn": ["503"]}`,
+			expectErr: true,
+		},
+		"too many attempts": {
+			policy:    `{"attempts": 11}`,
+			expectErr: true,
+		},
+		"invalid condition": {
+			policy:    `{"attempts": 3, "retryOn": ["501"]}`,
+			expectErr: true,
+		},
+		"invalid method": {
+			policy:    `{"attempts": 3, "methods": ["FETCH"]}`,
+			expectErr: true,
+		},
+		"invalid per try timeout": {
+			policy:    `{"attempts": 3, "perTryTimeout": "1h"}`,
+			expectErr: true,
+		},
+		"invalid backoff": {
+			policy:    `{"attempts": 3, "backoff": "1m"}`,
+			expectErr: true,
+		},
+		"invalid budget": {
+			policy:    `{"attempts": 3, "budgetPercent": 120}`,
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(map[string]string{annotation: tc.policy})
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+
+	mci.SetAnnotations(map[string]string{})
+	if _, err := ap.ParseByMCI(mci); !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected ErrMissingAnnotations but returned %v", err)
+	}
+}
+
+func TestNextUpstream(t *testing.T) {
+	config := Config{RetryOn: []string{"error", "http_503"}, Methods: []string{"GET"}}
+	if nu := config.NextUpstream(); nu != "error http_503" {
+		t.Errorf("expected 'error http_503' but returned %q", nu)
+	}
+
+	config.Methods = []string{"GET", "POST", "PATCH"}
+	if nu := config.NextUpstream(); nu != "error http_503 non_idempotent" {
+		t.Errorf("expected 'error http_503 non_idempotent' but returned %q", nu)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routematch
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	routeMatchAnnotation = "route-match"
+)
+
+var (
+	validMethods = sets.NewString("GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE")
+
+	// patternEscapes are the letters of the escape sequences Go and PCRE
+	// interpret the same way, the other escaped characters must be punctuation
+	patternEscapes = "dDwWsSbBAztnrfx"
+	// patternGroups are the only groups starting with (? accepted in patterns
+	patternGroups = []string{"(?:", "(?i)"}
+)
+
+// Rule sends the requests satisfying all its conditions to a Service
+type Rule struct {
+	// Service is the name of the Service in the namespace of the object
+	Service string `json:"service"`
+	// Port is the number or the name of the port of the Service
+	Port intstr.IntOrString `json:"port"`
+	// Methods is the list of HTTP methods of the request
+	Methods []string `json:"methods,omitempty"`
+	// Header is the name of a header of the request
+	Header string `json:"header,omitempty"`
+	// HeaderValue is the exact value of the header
+	HeaderValue string `json:"headerValue,omitempty"`
+	// HeaderPattern is a regular expression matching the value of the header
+	HeaderPattern string `json:"headerPattern,omitempty"`
+	// QueryParam is the name of a query parameter of the request
+	QueryParam string `json:"queryParam,omitempty"`
+	// QueryValue is the exact value of the query parameter
+	QueryValue string `json:"queryValue,omitempty"`
+	// QueryPattern is a regular expression matching the value of the query parameter
+	QueryPattern string `json:"queryPattern,omitempty"`
+}
+
+// Config returns the route matches of the paths of an object. The rules are
+// evaluated in order and requests not matching any of them are served by the
+// backend of the path.
+type Config struct {
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Equal tests for equality between two Rule types
+func (r1 *Rule) Equal(r2 *Rule) bool {
+	if r1 == r2 {
+		return true
+	}
+	if r1 == nil || r2 == nil {
+		return false
+	}
+	if r1.Service != r2.Service || r1.Port != r2.Port {
+		return false
+	}
+	if len(r1.Methods) != len(r2.Methods) {
+		return false
+	}
+	for i := range r1.Methods {
+		if r1.Methods[i] != r2.Methods[i] {
+			return false
+		}
+	}
+	if r1.Header != r2.Header || r1.HeaderValue != r2.HeaderValue || r1.HeaderPattern != r2.HeaderPattern {
+		return false
+	}
+	if r1.QueryParam != r2.QueryParam || r1.QueryValue != r2.QueryValue || r1.QueryPattern != r2.QueryPattern {
+		return false
+	}
+
+	return true
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if len(c1.Rules) != len(c2.Rules) {
+		return false
+	}
+	for i := range c1.Rules {
+		if !c1.Rules[i].Equal(&c2.Rules[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ServiceBackend returns the Service backend of the rule
+func (r Rule) ServiceBackend() *networking.IngressServiceBackend {
+	svc := &networking.IngressServiceBackend{
+		Name: r.Service,
+	}
+
+	if r.Port.Type == intstr.String {
+		svc.Port.Name = r.Port.StrVal
+	} else {
+		svc.Port.Number = r.Port.IntVal
+	}
+
+	return svc
+}
+
+type routeMatch struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new route match annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return routeMatch{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to route requests by header, query parameter or method
+func (rm routeMatch) Parse(ing *networking.Ingress) (interface{}, error) {
+	rules, err := parser.GetStringAnnotation(routeMatchAnnotation, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return parse(rules)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to route requests by header, query parameter or method
+func (rm routeMatch) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	rules, err := parser.GetStringAnnotationFromMCI(routeMatchAnnotation, mci)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	return parse(rules)
+}
+
+// parse decodes the list of rules and checks each one defines valid conditions
+func parse(rules string) (*Config, error) {
+	config := &Config{}
+
+	if err := json.Unmarshal([]byte(rules), &config.Rules); err != nil {
+		return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation, fmt.Sprintf("invalid list of rules: %v", err))
+	}
+
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+
+		if rule.Service == "" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation, "the name of the service is required")
+		}
+
+		if rule.Port.String() == "" || rule.Port.String() == "0" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation,
+				fmt.Sprintf("the port of service %v is required", rule.Service))
+		}
+
+		if len(rule.Methods) == 0 && rule.Header == "" && rule.QueryParam == "" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation,
+				fmt.Sprintf("the rule of service %v requires a method, a header or a query parameter", rule.Service))
+		}
+
+		for j, method := range rule.Methods {
+			method = strings.ToUpper(method)
+			if !validMethods.Has(method) {
+				return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation,
+					fmt.Sprintf("invalid method %v in the rule of service %v", rule.Methods[j], rule.Service))
+			}
+			rule.Methods[j] = method
+		}
+
+		if err := checkCondition("header", rule.Header, rule.HeaderValue, rule.HeaderPattern); err != nil {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation,
+				fmt.Sprintf("invalid header condition in the rule of service %v: %v", rule.Service, err))
+		}
+
+		if err := checkCondition("query parameter", rule.QueryParam, rule.QueryValue, rule.QueryPattern); err != nil {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(routeMatchAnnotation,
+				fmt.Sprintf("invalid query parameter condition in the rule of service %v: %v", rule.Service, err))
+		}
+	}
+
+	return config, nil
+}
+
+// checkCondition checks a value or a pattern is only used together with a
+// name, and that the pattern is a valid regular expression
+func checkCondition(kind, name, value, pattern string) error {
+	if (value != "" || pattern != "") && name == "" {
+		return fmt.Errorf("a value or a pattern requires the name of the %v", kind)
+	}
+
+	if value != "" && pattern != "" {
+		return fmt.Errorf("a value and a pattern cannot be used together")
+	}
+
+	if pattern != "" {
+		return checkPattern(pattern)
+	}
+
+	return nil
+}
+
+// checkPattern checks the pattern is a valid regular expression using only the
+// syntax Go, validating the annotation, and PCRE, matching the requests with
+// ngx.re in the balancer, interpret the same way
+func checkPattern(pattern string) error {
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c > unicode.MaxASCII:
+			return fmt.Errorf("the pattern must only contain ASCII characters")
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			e := pattern[i]
+			if !strings.ContainsRune(patternEscapes, rune(e)) && !unicode.IsPunct(rune(e)) && !unicode.IsSymbol(rune(e)) {
+				return fmt.Errorf("unsupported escape sequence \\%c", e)
+			}
+		case strings.HasPrefix(pattern[i:], "(?"):
+			if !hasAnyPrefix(pattern[i:], patternGroups) {
+				return fmt.Errorf("unsupported group at %q, only (?: and (?i) are supported", pattern[i:])
+			}
+		}
+	}
+
+	_, err := regexp.Compile(pattern)
+	return err
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
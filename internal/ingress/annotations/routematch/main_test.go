@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routematch
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	routeMatch := parser.GetAnnotationWithPrefix(routeMatchAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"header, query parameter and method": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerValue":"v2"},{"service":"uploads","port":"http","methods":["post","PUT"],"queryParam":"version","queryPattern":"^2\\."}]`,
+			},
+			expected: &Config{
+				Rules: []Rule{
+					{Service: "api-v2", Port: intstr.FromInt(80), Header: "X-Api", HeaderValue: "v2"},
+					{Service: "uploads", Port: intstr.FromString("http"), Methods: []string{"POST", "PUT"}, QueryParam: "version", QueryPattern: `^2\.`},
+				},
+			},
+		},
+		"header presence": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"debug","port":80,"header":"X-Debug"}]`,
+			},
+			expected: &Config{
+				Rules: []Rule{{Service: "debug", Port: intstr.FromInt(80), Header: "X-Debug"}},
+			},
+		},
+		"missing service": {
+			annotations: map[string]string{
+				routeMatch: `[{"port":80,"header":"X-Api"}]`,
+			},
+			expectErr: true,
+		},
+		"missing port": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","header":"X-Api"}]`,
+			},
+			expectErr: true,
+		},
+		"no condition": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80}]`,
+			},
+			expectErr: true,
+		},
+		"invalid method": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"methods":["FETCH"]}]`,
+			},
+			expectErr: true,
+		},
+		"query value without query parameter": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"methods":["GET"],"queryValue":"2"}]`,
+			},
+			expectErr: true,
+		},
+		"header value and pattern": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerValue":"v2","headerPattern":"v.*"}]`,
+			},
+			expectErr: true,
+		},
+		"invalid header pattern": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerPattern":"v(2"}]`,
+			},
+			expectErr: true,
+		},
+		"header pattern with PCRE compatible syntax": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerPattern":"(?i)^v[0-9]+\\.(?:beta|rc)\\d*$"}]`,
+			},
+			expected: &Config{
+				Rules: []Rule{{Service: "api-v2", Port: intstr.FromInt(80), Header: "X-Api", HeaderPattern: `(?i)^v[0-9]+\.(?:beta|rc)\d*$`}},
+			},
+		},
+		"header pattern with escape sequence specific to Go": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerPattern":"^\\pL+$"}]`,
+			},
+			expectErr: true,
+		},
+		"query pattern with named group": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"queryParam":"v","queryPattern":"(?P<major>[0-9]+)"}]`,
+			},
+			expectErr: true,
+		},
+		"query pattern with flags other than case insensitive": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"queryParam":"v","queryPattern":"(?s)a.b"}]`,
+			},
+			expectErr: true,
+		},
+		"header pattern with non ASCII characters": {
+			annotations: map[string]string{
+				routeMatch: `[{"service":"api-v2","port":80,"header":"X-Api","headerPattern":"é+"}]`,
+			},
+			expectErr: true,
+		},
+		"invalid json": {
+			annotations: map[string]string{
+				routeMatch: `X-Api=v2:api-v2`,
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slowstart
+
+import (
+	"fmt"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	slowStartWindowAnnotation = "slow-start-window"
+
+	// maxWindow is the longest slow start window, in seconds
+	maxWindow = 3600
+)
+
+type slowStart struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new slow start annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return slowStart{r}
+}
+
+// Parse parses the annotation contained in the ingress rule used to
+// ramp up the traffic sent to the new endpoints of the backends
+func (s slowStart) Parse(ing *networking.Ingress) (interface{}, error) {
+	return s.parse(func(name string) (int, error) {
+		return parser.GetIntAnnotation(name, ing)
+	})
+}
+
+// ParseByMCI parses the annotation contained in the multiclusteringress rule
+// used to ramp up the traffic sent to the new endpoints of the backends
+func (s slowStart) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return s.parse(func(name string) (int, error) {
+		return parser.GetIntAnnotationFromMCI(name, mci)
+	})
+}
+
+// parse returns the slow start window in seconds, using the window of the
+// configmap when the annotation is not defined
+func (s slowStart) parse(annotation func(string) (int, error)) (interface{}, error) {
+	window, err := annotation(slowStartWindowAnnotation)
+	if ing_errors.IsMissingAnnotations(err) {
+		return s.r.GetDefaultBackend().SlowStartWindow, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if window < 0 || window > maxWindow {
+		return 0, ing_errors.NewInvalidAnnotationConfiguration(slowStartWindowAnnotation,
+			fmt.Sprintf("expected a number of seconds between 0 and %v but got %v", maxWindow, window))
+	}
+
+	return window, nil
+}
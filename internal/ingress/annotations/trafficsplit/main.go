@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficsplit
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	trafficSplitAnnotation            = "traffic-split"
+	trafficSplitWeightTotalAnnotation = "traffic-split-weight-total"
+
+	defaultWeightTotal = 100
+)
+
+// Branch is an alternative backend receiving part of the traffic of a path
+type Branch struct {
+	// Service is the name of the Service in the namespace of the object
+	Service string `json:"service"`
+	// Port is the number or the name of the port of the Service
+	Port intstr.IntOrString `json:"port"`
+	// Weight (0-<WeightTotal>) of the traffic sent to the Service
+	Weight int `json:"weight"`
+	// Header on which to send requests to the Service
+	Header string `json:"header,omitempty"`
+	// HeaderValue on which to send requests to the Service
+	HeaderValue string `json:"headerValue,omitempty"`
+	// HeaderPattern is a regular expression matching the value of the header
+	HeaderPattern string `json:"headerPattern,omitempty"`
+	// Cookie on which to send requests to the Service
+	Cookie string `json:"cookie,omitempty"`
+}
+
+// Config returns the traffic split of the paths of an object. The traffic not
+// sent to a branch stays with the primary backend of the path.
+type Config struct {
+	Branches    []Branch `json:"branches,omitempty"`
+	WeightTotal int      `json:"weightTotal,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.WeightTotal != c2.WeightTotal {
+		return false
+	}
+	if len(c1.Branches) != len(c2.Branches) {
+		return false
+	}
+	for i := range c1.Branches {
+		if c1.Branches[i] != c2.Branches[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ServiceBackend returns the Service backend of the branch
+func (b Branch) ServiceBackend() *networking.IngressServiceBackend {
+	svc := &networking.IngressServiceBackend{
+		Name: b.Service,
+	}
+
+	if b.Port.Type == intstr.String {
+		svc.Port.Name = b.Port.StrVal
+	} else {
+		svc.Port.Number = b.Port.IntVal
+	}
+
+	return svc
+}
+
+type trafficSplit struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new traffic split annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return trafficSplit{r}
+}
+
+// Parse parses the annotations contained in the ingress
+// rule used to split the traffic between several services
+func (ts trafficSplit) Parse(ing *networking.Ingress) (interface{}, error) {
+	split, err := parser.GetStringAnnotation(trafficSplitAnnotation, ing)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	weightTotal, err := parser.GetIntAnnotation(trafficSplitWeightTotalAnnotation, ing)
+	if err != nil {
+		weightTotal = defaultWeightTotal
+	}
+
+	return parse(split, weightTotal)
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to split the traffic between several services
+func (ts trafficSplit) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	split, err := parser.GetStringAnnotationFromMCI(trafficSplitAnnotation, mci)
+	if err != nil {
+		return &Config{}, err
+	}
+
+	weightTotal, err := parser.GetIntAnnotationFromMCI(trafficSplitWeightTotalAnnotation, mci)
+	if err != nil {
+		weightTotal = defaultWeightTotal
+	}
+
+	return parse(split, weightTotal)
+}
+
+// parse decodes the list of branches and checks the weights do not exceed the total
+func parse(split string, weightTotal int) (*Config, error) {
+	config := &Config{
+		WeightTotal: weightTotal,
+	}
+
+	if weightTotal < defaultWeightTotal {
+		return &Config{}, errors.NewInvalidAnnotationContent(trafficSplitWeightTotalAnnotation, weightTotal)
+	}
+
+	if err := json.Unmarshal([]byte(split), &config.Branches); err != nil {
+		return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation, fmt.Sprintf("invalid list of services: %v", err))
+	}
+
+	sum := 0
+	services := make(map[string]bool, len(config.Branches))
+	for _, branch := range config.Branches {
+		if branch.Service == "" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation, "the name of the service is required")
+		}
+
+		if branch.Port.String() == "" || branch.Port.String() == "0" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+				fmt.Sprintf("the port of service %v is required", branch.Service))
+		}
+
+		key := fmt.Sprintf("%v:%v", branch.Service, branch.Port.String())
+		if services[key] {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+				fmt.Sprintf("service %v is defined more than once", key))
+		}
+		services[key] = true
+
+		if branch.Weight < 0 {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+				fmt.Sprintf("the weight of service %v cannot be negative", branch.Service))
+		}
+
+		if (branch.HeaderValue != "" || branch.HeaderPattern != "") && branch.Header == "" {
+			return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+				fmt.Sprintf("the header value of service %v requires a header", branch.Service))
+		}
+
+		if branch.HeaderPattern != "" {
+			if _, err := regexp.Compile(branch.HeaderPattern); err != nil {
+				return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+					fmt.Sprintf("invalid header pattern of service %v: %v", branch.Service, err))
+			}
+		}
+
+		sum += branch.Weight
+	}
+
+	if sum > weightTotal {
+		return &Config{}, errors.NewInvalidAnnotationConfiguration(trafficSplitAnnotation,
+			fmt.Sprintf("the sum of the weights (%v) exceeds the total weight (%v)", sum, weightTotal))
+	}
+
+	return config, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trafficsplit
+
+import (
+	"reflect"
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParseByMCI(t *testing.T) {
+	split := parser.GetAnnotationWithPrefix(trafficSplitAnnotation)
+	weightTotal := parser.GetAnnotationWithPrefix(trafficSplitWeightTotalAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		"three-way split": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":20},{"service":"app-v3","port":"http","weight":10,"header":"X-Version","headerValue":"v3"}]`,
+			},
+			expected: &Config{
+				WeightTotal: 100,
+				Branches: []Branch{
+					{Service: "app-v2", Port: intstr.FromInt(80), Weight: 20},
+					{Service: "app-v3", Port: intstr.FromString("http"), Weight: 10, Header: "X-Version", HeaderValue: "v3"},
+				},
+			},
+		},
+		"custom weight total": {
+			annotations: map[string]string{
+				split:       `[{"service":"app-v2","port":80,"weight":999}]`,
+				weightTotal: "1000",
+			},
+			expected: &Config{
+				WeightTotal: 1000,
+				Branches:    []Branch{{Service: "app-v2", Port: intstr.FromInt(80), Weight: 999}},
+			},
+		},
+		"weights exceed the total": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":60},{"service":"app-v3","port":80,"weight":50}]`,
+			},
+			expectErr: true,
+		},
+		"negative weight": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":-1}]`,
+			},
+			expectErr: true,
+		},
+		"weight total lower than 100": {
+			annotations: map[string]string{
+				split:       `[{"service":"app-v2","port":80,"weight":10}]`,
+				weightTotal: "10",
+			},
+			expectErr: true,
+		},
+		"missing port": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","weight":10}]`,
+			},
+			expectErr: true,
+		},
+		"duplicated service": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":10},{"service":"app-v2","port":80,"weight":10}]`,
+			},
+			expectErr: true,
+		},
+		"header value without header": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":10,"headerValue":"v2"}]`,
+			},
+			expectErr: true,
+		},
+		"invalid header pattern": {
+			annotations: map[string]string{
+				split: `[{"service":"app-v2","port":80,"weight":10,"header":"X-Version","headerPattern":"v(2"}]`,
+			},
+			expectErr: true,
+		},
+		"invalid json": {
+			annotations: map[string]string{
+				split: `app-v2:80=10`,
+			},
+			expectErr: true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zoneaware
+
+import (
+	"fmt"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	enableAnnotation      = "zone-aware-routing"
+	minCapacityAnnotation = "zone-aware-routing-min-capacity"
+)
+
+// Config contains the zone aware routing of the endpoints of a backend
+type Config struct {
+	// Enabled restricts the endpoints to the zone of the controller
+	Enabled bool `json:"enabled,omitempty"`
+	// MinCapacity is the percentage of its even share of the backend
+	// capacity the zone of the controller needs to keep the traffic local
+	MinCapacity int `json:"minCapacity,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type zoneAware struct {
+	r resolver.Resolver
+}
+
+// NewParser creates a new zone aware routing annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return zoneAware{r}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to prefer the endpoints of the zone of the controller
+func (za zoneAware) Parse(ing *networking.Ingress) (interface{}, error) {
+	return za.parse(
+		func(name string) (bool, error) {
+			return parser.GetBoolAnnotation(name, ing)
+		},
+		func(name string) (int, error) {
+			return parser.GetIntAnnotation(name, ing)
+		})
+}
+
+// ParseByMCI parses the annotations contained in the multiclusteringress
+// rule used to prefer the endpoints of the zone of the controller
+func (za zoneAware) ParseByMCI(mci *karmadanetworking.MultiClusterIngress) (interface{}, error) {
+	return za.parse(
+		func(name string) (bool, error) {
+			return parser.GetBoolAnnotationFromMCI(name, mci)
+		},
+		func(name string) (int, error) {
+			return parser.GetIntAnnotationFromMCI(name, mci)
+		})
+}
+
+// parse reads the zone aware routing annotations, using the values of the
+// configmap for the annotations not defined
+func (za zoneAware) parse(boolAnnotation func(string) (bool, error), intAnnotation func(string) (int, error)) (interface{}, error) {
+	defBackend := za.r.GetDefaultBackend()
+	config := Config{
+		Enabled:     defBackend.ZoneAwareRouting,
+		MinCapacity: defBackend.ZoneAwareRoutingMinCapacity,
+	}
+
+	enabled, err := boolAnnotation(enableAnnotation)
+	if err == nil {
+		config.Enabled = enabled
+	} else if !ing_errors.IsMissingAnnotations(err) {
+		return Config{}, err
+	}
+
+	minCapacity, err := intAnnotation(minCapacityAnnotation)
+	if err == nil {
+		config.MinCapacity = minCapacity
+	} else if !ing_errors.IsMissingAnnotations(err) {
+		return Config{}, err
+	}
+
+	if config.MinCapacity < 0 || config.MinCapacity > 100 {
+		return Config{}, ing_errors.NewInvalidAnnotationConfiguration(minCapacityAnnotation,
+			fmt.Sprintf("expected a percentage between 0 and 100 but got %v", config.MinCapacity))
+	}
+
+	return config, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zoneaware
+
+import (
+	"testing"
+
+	karmadanetworking "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	api "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockBackend struct {
+	resolver.Mock
+}
+
+// GetDefaultBackend returns the backend that must be used as default
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{
+		ZoneAwareRoutingMinCapacity: 50,
+	}
+}
+
+func TestParseByMCI(t *testing.T) {
+	enable := parser.GetAnnotationWithPrefix(enableAnnotation)
+	minCapacity := parser.GetAnnotationWithPrefix(minCapacityAnnotation)
+
+	ap := NewParser(mockBackend{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := map[string]struct {
+		annotations map[string]string
+		expected    Config
+		expectErr   bool
+	}{
+		"defaults of the configmap": {
+			annotations: map[string]string{},
+			expected:    Config{MinCapacity: 50},
+		},
+		"enabled by the annotation": {
+			annotations: map[string]string{enable: "true"},
+			expected:    Config{Enabled: true, MinCapacity: 50},
+		},
+		"minimum capacity of the annotation": {
+			annotations: map[string]string{enable: "true", minCapacity: "0"},
+			expected:    Config{Enabled: true, MinCapacity: 0},
+		},
+		"invalid enable": {
+			annotations: map[string]string{enable: "sometimes"},
+			expectErr:   true,
+		},
+		"negative minimum capacity": {
+			annotations: map[string]string{enable: "true", minCapacity: "-1"},
+			expectErr:   true,
+		},
+		"minimum capacity above 100": {
+			annotations: map[string]string{enable: "true", minCapacity: "101"},
+			expectErr:   true,
+		},
+	}
+
+	mci := &karmadanetworking.MultiClusterIngress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci.SetAnnotations(tc.annotations)
+			result, err := ap.ParseByMCI(mci)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %+v but returned %+v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,515 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+const (
+	// acmeAccountSecretName is the name of the Secret containing the key of the ACME account
+	acmeAccountSecretName = "ingress-nginx-acme-account"
+	// acmeChallengesSecretName is the name of the Secret containing the responses to
+	// the pending HTTP-01 challenges, indexed by token
+	acmeChallengesSecretName = "ingress-nginx-acme-challenges"
+	// acmeAccountKey is the key of the account private key in its Secret
+	acmeAccountKey = "account.key"
+
+	// acmeIssuedAnnotation marks the Secrets created by the controller, the only
+	// ones renewed
+	acmeIssuedAnnotation = "nginx.ingress.kubernetes.io/acme-issued"
+
+	// acmeSyncInterval is the time between two checks of the certificates to order
+	acmeSyncInterval = time.Minute
+	// acmeRetryInterval is the time before ordering again a certificate after a failure
+	acmeRetryInterval = time.Hour
+	// acmeOrderTimeout is the maximum duration of an order
+	acmeOrderTimeout = 5 * time.Minute
+	// acmeChallengesPropagation is the time given to the controllers of all the
+	// member clusters to configure the responses before accepting the challenges
+	acmeChallengesPropagation = 10 * time.Second
+)
+
+// acmeCertificate is a certificate requested by the TLS section of MultiClusterIngresses
+type acmeCertificate struct {
+	Namespace  string
+	SecretName string
+	Hosts      []string
+}
+
+func (c acmeCertificate) key() string {
+	return fmt.Sprintf("%v/%v", c.Namespace, c.SecretName)
+}
+
+// acmeManager orders certificates from an ACME server for the hosts of the
+// MultiClusterIngresses whose TLS Secret is missing, and renews them before
+// they expire. The certificates and the responses to the HTTP-01 challenges
+// are stored as Secrets in Karmada, so the controllers of all the member
+// clusters serve them. Only the leader orders certificates.
+type acmeManager struct {
+	client      clientset.Interface
+	store       store.Storer
+	directory   string
+	email       string
+	namespace   string
+	renewBefore time.Duration
+	propagation time.Duration
+
+	mu sync.Mutex
+	// failures contains the time of the last failed order of each Secret
+	failures map[string]time.Time
+	// challenges contains the responses to the challenges last seen in the store
+	challenges map[string]string
+}
+
+func newACMEManager(client clientset.Interface, store store.Storer, directory, email, namespace string, renewBefore time.Duration) *acmeManager {
+	return &acmeManager{
+		client:      client,
+		store:       store,
+		directory:   directory,
+		email:       email,
+		namespace:   namespace,
+		renewBefore: renewBefore,
+		propagation: acmeChallengesPropagation,
+		failures:    map[string]time.Time{},
+		challenges:  map[string]string{},
+	}
+}
+
+// Challenges returns the responses to the pending HTTP-01 challenges, indexed by token
+func (m *acmeManager) Challenges() map[string]string {
+	challenges := map[string]string{}
+
+	secret, err := m.store.GetSecret(fmt.Sprintf("%v/%v", m.namespace, acmeChallengesSecretName))
+	if err != nil {
+		return challenges
+	}
+
+	for token, keyAuth := range secret.Data {
+		challenges[token] = string(keyAuth)
+	}
+
+	return challenges
+}
+
+// WatchChallenges calls onChange every second the responses to the challenges
+// change, until the channel is closed. It runs in every controller.
+func (m *acmeManager) WatchChallenges(stopCh <-chan struct{}, onChange func()) {
+	wait.Until(func() {
+		challenges := m.Challenges()
+
+		m.mu.Lock()
+		changed := !reflect.DeepEqual(m.challenges, challenges)
+		m.challenges = challenges
+		m.mu.Unlock()
+
+		if changed {
+			onChange()
+		}
+	}, time.Second, stopCh)
+}
+
+// Run orders the pending certificates every acmeSyncInterval until the
+// channel is closed. It runs only in the leader.
+func (m *acmeManager) Run(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		for _, cert := range m.pendingCertificates(time.Now()) {
+			ctx, cancel := context.WithTimeout(context.Background(), acmeOrderTimeout)
+			err := m.order(ctx, cert)
+			cancel()
+
+			m.mu.Lock()
+			if err != nil {
+				klog.ErrorS(err, "Error ordering ACME certificate", "secret", cert.key(), "hosts", cert.Hosts)
+				m.failures[cert.key()] = time.Now()
+			} else {
+				klog.InfoS("ACME certificate issued", "secret", cert.key(), "hosts", cert.Hosts)
+				delete(m.failures, cert.key())
+			}
+			m.mu.Unlock()
+		}
+	}, acmeSyncInterval, stopCh)
+}
+
+// pendingCertificates returns the certificates of the TLS sections whose
+// Secret is missing, or was issued by the controller and expires before
+// renewBefore or does not cover all the hosts. Wildcard hosts are ignored
+// since they cannot be validated with an HTTP-01 challenge.
+func (m *acmeManager) pendingCertificates(now time.Time) []acmeCertificate {
+	certs := map[string]*acmeCertificate{}
+	hosts := map[string]sets.String{}
+
+	for _, mci := range m.store.ListMultiClusterIngresses() {
+		for _, tls := range mci.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+
+			cert := acmeCertificate{Namespace: mci.Namespace, SecretName: tls.SecretName}
+			key := cert.key()
+			if _, ok := certs[key]; !ok {
+				certs[key] = &cert
+				hosts[key] = sets.NewString()
+			}
+
+			for _, host := range tls.Hosts {
+				if host != "" && !strings.HasPrefix(host, "*.") {
+					hosts[key].Insert(host)
+				}
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pending []acmeCertificate
+	for key, cert := range certs {
+		cert.Hosts = hosts[key].List()
+		if len(cert.Hosts) == 0 {
+			continue
+		}
+
+		if failed, ok := m.failures[key]; ok && now.Sub(failed) < acmeRetryInterval {
+			continue
+		}
+
+		if !m.needsCertificate(*cert, now) {
+			continue
+		}
+
+		pending = append(pending, *cert)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].key() < pending[j].key()
+	})
+
+	return pending
+}
+
+// needsCertificate returns if the Secret of a certificate has to be (re)issued
+func (m *acmeManager) needsCertificate(cert acmeCertificate, now time.Time) bool {
+	secret, err := m.store.GetSecret(cert.key())
+	if err != nil {
+		return true
+	}
+
+	if secret.Annotations[acmeIssuedAnnotation] != "true" {
+		// the Secret is managed by someone else
+		return false
+	}
+
+	block, _ := pem.Decode(secret.Data[apiv1.TLSCertKey])
+	if block == nil {
+		return true
+	}
+
+	x509Cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+
+	if now.Add(m.renewBefore).After(x509Cert.NotAfter) {
+		return true
+	}
+
+	return !sets.NewString(x509Cert.DNSNames...).HasAll(cert.Hosts...)
+}
+
+// order requests a certificate from the ACME server, validating the hosts
+// with HTTP-01 challenges, and stores it in the Secret
+func (m *acmeManager) order(ctx context.Context, cert acmeCertificate) error {
+	accountKey, err := m.accountKey(ctx)
+	if err != nil {
+		return fmt.Errorf("reading account key: %w", err)
+	}
+
+	client := &acme.Client{Key: accountKey, DirectoryURL: m.directory}
+
+	account := &acme.Account{}
+	if m.email != "" {
+		account.Contact = []string{"mailto:" + m.email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return fmt.Errorf("registering account: %w", err)
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(cert.Hosts...))
+	if err != nil {
+		return fmt.Errorf("creating order: %w", err)
+	}
+
+	responses := map[string]string{}
+	var authorizations []string
+	var challenges []*acme.Challenge
+	for _, url := range order.AuthzURLs {
+		authz, err := client.GetAuthorization(ctx, url)
+		if err != nil {
+			return fmt.Errorf("reading authorization: %w", err)
+		}
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		var challenge *acme.Challenge
+		for _, c := range authz.Challenges {
+			if c.Type == "http-01" {
+				challenge = c
+				break
+			}
+		}
+		if challenge == nil {
+			return fmt.Errorf("no http-01 challenge offered for %v", authz.Identifier.Value)
+		}
+
+		keyAuth, err := client.HTTP01ChallengeResponse(challenge.Token)
+		if err != nil {
+			return err
+		}
+
+		responses[challenge.Token] = keyAuth
+		authorizations = append(authorizations, authz.URI)
+		challenges = append(challenges, challenge)
+	}
+
+	if len(responses) > 0 {
+		if err := m.updateChallenges(ctx, responses, nil); err != nil {
+			return fmt.Errorf("publishing challenges: %w", err)
+		}
+		defer func() {
+			if err := m.updateChallenges(context.Background(), nil, responses); err != nil {
+				klog.Warningf("Error removing ACME challenges: %v", err)
+			}
+		}()
+
+		select {
+		case <-time.After(m.propagation):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for i, challenge := range challenges {
+		if _, err := client.Accept(ctx, challenge); err != nil {
+			return fmt.Errorf("accepting challenge: %w", err)
+		}
+		if _, err := client.WaitAuthorization(ctx, authorizations[i]); err != nil {
+			return fmt.Errorf("validating authorization: %w", err)
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return fmt.Errorf("waiting order: %w", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: cert.Hosts[0]},
+		DNSNames: cert.Hosts,
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("finalizing order: %w", err)
+	}
+
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return m.storeCertificate(ctx, cert, certPEM, keyPEM)
+}
+
+// accountKey returns the key of the ACME account, creating it the first time
+func (m *acmeManager) accountKey(ctx context.Context) (*ecdsa.PrivateKey, error) {
+	secrets := m.client.CoreV1().Secrets(m.namespace)
+
+	secret, err := secrets.Get(ctx, acmeAccountSecretName, metav1.GetOptions{})
+	if err == nil {
+		block, _ := pem.Decode(secret.Data[acmeAccountKey])
+		if block == nil {
+			return nil, fmt.Errorf("no PEM data in secret %v/%v", m.namespace, acmeAccountSecretName)
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = secrets.Create(ctx, &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: acmeAccountSecretName, Namespace: m.namespace},
+		Data: map[string][]byte{
+			acmeAccountKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}),
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	klog.InfoS("ACME account key created", "secret", fmt.Sprintf("%v/%v", m.namespace, acmeAccountSecretName))
+	return key, nil
+}
+
+// updateChallenges adds and removes responses of the Secret of the challenges
+func (m *acmeManager) updateChallenges(ctx context.Context, add, remove map[string]string) error {
+	secrets := m.client.CoreV1().Secrets(m.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, acmeChallengesSecretName, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			if len(add) == 0 {
+				return nil
+			}
+
+			secret = &apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: acmeChallengesSecretName, Namespace: m.namespace},
+			}
+			secret.Data = challengesData(nil, add, remove)
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		secret.Data = challengesData(secret.Data, add, remove)
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func challengesData(data map[string][]byte, add, remove map[string]string) map[string][]byte {
+	result := map[string][]byte{}
+	for token, keyAuth := range data {
+		if _, ok := remove[token]; !ok {
+			result[token] = keyAuth
+		}
+	}
+	for token, keyAuth := range add {
+		result[token] = []byte(keyAuth)
+	}
+	return result
+}
+
+// storeCertificate creates or updates the TLS Secret of a certificate
+func (m *acmeManager) storeCertificate(ctx context.Context, cert acmeCertificate, certPEM, keyPEM []byte) error {
+	secrets := m.client.CoreV1().Secrets(cert.Namespace)
+	data := map[string][]byte{
+		apiv1.TLSCertKey:       certPEM,
+		apiv1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, cert.SecretName, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, &apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        cert.SecretName,
+					Namespace:   cert.Namespace,
+					Annotations: map[string]string{acmeIssuedAnnotation: "true"},
+				},
+				Type: apiv1.SecretTypeTLS,
+				Data: data,
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if secret.Annotations[acmeIssuedAnnotation] != "true" {
+			return fmt.Errorf("secret %v was not issued by the controller", cert.key())
+		}
+
+		secret.Data = data
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// getACMEChallenges returns the responses to the pending challenges when the
+// ACME client is enabled
+func (n *NGINXController) getACMEChallenges() map[string]string {
+	if n.acmeManager == nil {
+		return nil
+	}
+
+	return n.acmeManager.Challenges()
+}
+
+// configureACMEChallenges POSTs the responses to the challenges to the
+// internal HTTP endpoint handled by Lua
+func configureACMEChallenges(challenges map[string]string) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/acme-challenges", "application/json", challenges)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,434 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// acmeTestStore reads the Secrets from the fake Karmada client
+type acmeTestStore struct {
+	fakeIngressStore
+	client kubernetes.Interface
+	mcis   []*ingress.MultiClusterIngress
+}
+
+func (s acmeTestStore) ListMultiClusterIngresses() []*ingress.MultiClusterIngress {
+	return s.mcis
+}
+
+func (s acmeTestStore) GetSecret(key string) (*corev1.Secret, error) {
+	parts := strings.SplitN(key, "/", 2)
+	return s.client.CoreV1().Secrets(parts[0]).Get(context.TODO(), parts[1], metav1.GetOptions{})
+}
+
+func newACMETestMCI(tls ...networking.IngressTLS) *ingress.MultiClusterIngress {
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       networking.IngressSpec{TLS: tls},
+		},
+	}
+}
+
+// acmeTestServer is a minimal ACME server issuing certificates once the
+// responses to its HTTP-01 challenges are published in the challenges Secret.
+// It does not verify the signatures of the requests.
+type acmeTestServer struct {
+	*httptest.Server
+	t       *testing.T
+	client  kubernetes.Interface
+	caKey   *ecdsa.PrivateKey
+	caCert  *x509.Certificate
+	mu      sync.Mutex
+	hosts   []string
+	valid   map[string]bool
+	certPEM []byte
+}
+
+func newACMETestServer(t *testing.T, client kubernetes.Interface) *acmeTestServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(der)
+
+	s := &acmeTestServer{t: t, client: client, caKey: caKey, caCert: caCert, valid: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *acmeTestServer) payload(r *http.Request, v interface{}) {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		s.t.Errorf("unexpected error decoding request: %v", err)
+		return
+	}
+	if jws.Payload == "" || v == nil {
+		return
+	}
+	data, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		s.t.Errorf("unexpected error decoding payload: %v", err)
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		s.t.Errorf("unexpected error decoding payload: %v", err)
+	}
+}
+
+func (s *acmeTestServer) order() map[string]interface{} {
+	status := "pending"
+	if len(s.valid) == len(s.hosts) {
+		status = "ready"
+	}
+	if s.certPEM != nil {
+		status = "valid"
+	}
+
+	var authorizations []string
+	for _, host := range s.hosts {
+		authorizations = append(authorizations, s.URL+"/authz/"+host)
+	}
+
+	return map[string]interface{}{
+		"status":         status,
+		"authorizations": authorizations,
+		"finalize":       s.URL + "/finalize",
+		"certificate":    s.URL + "/cert",
+	}
+}
+
+func (s *acmeTestServer) challenge(host string) map[string]interface{} {
+	status := "pending"
+	if s.valid[host] {
+		status = "valid"
+	}
+	return map[string]interface{}{
+		"type":   "http-01",
+		"url":    s.URL + "/challenge/" + host,
+		"token":  "token-" + strings.ReplaceAll(host, ".", "-"),
+		"status": status,
+	}
+}
+
+func (s *acmeTestServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%v", time.Now().UnixNano()))
+
+	reply := func(status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/directory":
+		reply(http.StatusOK, map[string]string{
+			"newNonce":   s.URL + "/nonce",
+			"newAccount": s.URL + "/account",
+			"newOrder":   s.URL + "/order",
+		})
+	case path == "/nonce":
+		w.WriteHeader(http.StatusOK)
+	case path == "/account":
+		s.payload(r, nil)
+		w.Header().Set("Location", s.URL+"/account/1")
+		reply(http.StatusCreated, map[string]string{"status": "valid"})
+	case path == "/order":
+		var req struct {
+			Identifiers []struct{ Value string } `json:"identifiers"`
+		}
+		s.payload(r, &req)
+		s.hosts = nil
+		for _, id := range req.Identifiers {
+			s.hosts = append(s.hosts, id.Value)
+		}
+		w.Header().Set("Location", s.URL+"/order/1")
+		reply(http.StatusCreated, s.order())
+	case path == "/order/1":
+		s.payload(r, nil)
+		w.Header().Set("Location", s.URL+"/order/1")
+		reply(http.StatusOK, s.order())
+	case strings.HasPrefix(path, "/authz/"):
+		s.payload(r, nil)
+		host := strings.TrimPrefix(path, "/authz/")
+		status := "pending"
+		if s.valid[host] {
+			status = "valid"
+		}
+		reply(http.StatusOK, map[string]interface{}{
+			"status":     status,
+			"identifier": map[string]string{"type": "dns", "value": host},
+			"challenges": []interface{}{s.challenge(host)},
+		})
+	case strings.HasPrefix(path, "/challenge/"):
+		s.payload(r, nil)
+		host := strings.TrimPrefix(path, "/challenge/")
+		token := s.challenge(host)["token"].(string)
+		// the challenge is valid only if the controllers can answer it
+		secret, err := s.client.CoreV1().Secrets("ingress-nginx").Get(context.TODO(), acmeChallengesSecretName, metav1.GetOptions{})
+		if err == nil && strings.HasPrefix(string(secret.Data[token]), token+".") {
+			s.valid[host] = true
+		}
+		reply(http.StatusOK, s.challenge(host))
+	case path == "/finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		s.payload(r, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			reply(http.StatusBadRequest, map[string]string{"type": "urn:ietf:params:acme:error:badCSR"})
+			return
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      csr.Subject,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		}
+		leaf, err := x509.CreateCertificate(rand.Reader, template, s.caCert, csr.PublicKey, s.caKey)
+		if err != nil {
+			s.t.Errorf("unexpected error signing certificate: %v", err)
+		}
+		s.certPEM = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}),
+			pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...)
+		w.Header().Set("Location", s.URL+"/order/1")
+		reply(http.StatusOK, s.order())
+	case path == "/cert":
+		s.payload(r, nil)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.Write(s.certPEM)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newACMETestSecret(name string, annotations map[string]string, notAfter time.Time, hosts ...string) *corev1.Secret {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     hosts,
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Data: map[string][]byte{
+			corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+func TestACMEPendingCertificates(t *testing.T) {
+	now := time.Now()
+	issued := map[string]string{acmeIssuedAnnotation: "true"}
+
+	client := fake.NewSimpleClientset(
+		newACMETestSecret("valid-tls", issued, now.Add(60*24*time.Hour), "valid.example.com"),
+		newACMETestSecret("expiring-tls", issued, now.Add(10*24*time.Hour), "expiring.example.com"),
+		newACMETestSecret("manual-tls", nil, now.Add(time.Hour), "manual.example.com"),
+		newACMETestSecret("hosts-tls", issued, now.Add(60*24*time.Hour), "a.example.com"),
+	)
+
+	m := newACMEManager(client, acmeTestStore{
+		client: client,
+		mcis: []*ingress.MultiClusterIngress{
+			newACMETestMCI(
+				networking.IngressTLS{Hosts: []string{"missing.example.com"}, SecretName: "missing-tls"},
+				networking.IngressTLS{Hosts: []string{"valid.example.com"}, SecretName: "valid-tls"},
+				networking.IngressTLS{Hosts: []string{"expiring.example.com"}, SecretName: "expiring-tls"},
+				networking.IngressTLS{Hosts: []string{"manual.example.com"}, SecretName: "manual-tls"},
+				networking.IngressTLS{Hosts: []string{"*.example.com"}, SecretName: "wildcard-tls"},
+				networking.IngressTLS{Hosts: []string{"a.example.com"}, SecretName: "hosts-tls"},
+				networking.IngressTLS{Hosts: []string{"failed.example.com"}, SecretName: "failed-tls"},
+			),
+			newACMETestMCI(
+				networking.IngressTLS{Hosts: []string{"b.example.com"}, SecretName: "hosts-tls"},
+			),
+		},
+	}, "", "", "ingress-nginx", 30*24*time.Hour)
+
+	m.failures["default/failed-tls"] = now.Add(-time.Minute)
+
+	var pending []string
+	for _, cert := range m.pendingCertificates(now) {
+		pending = append(pending, fmt.Sprintf("%v=%v", cert.key(), strings.Join(cert.Hosts, ",")))
+	}
+
+	expected := []string{
+		"default/expiring-tls=expiring.example.com",
+		"default/hosts-tls=a.example.com,b.example.com",
+		"default/missing-tls=missing.example.com",
+	}
+	if strings.Join(pending, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected pending certificates %v but got %v", expected, pending)
+	}
+
+	if len(m.pendingCertificates(now.Add(acmeRetryInterval))) != 4 {
+		t.Errorf("expected the failed certificate to be ordered again after %v", acmeRetryInterval)
+	}
+}
+
+func TestACMEOrder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	server := newACMETestServer(t, client)
+	defer server.Close()
+
+	s := acmeTestStore{
+		client: client,
+		mcis: []*ingress.MultiClusterIngress{
+			newACMETestMCI(networking.IngressTLS{Hosts: []string{"foo.example.com", "bar.example.com"}, SecretName: "web-tls"}),
+		},
+	}
+	m := newACMEManager(client, s, server.URL+"/directory", "admin@example.com", "ingress-nginx", 30*24*time.Hour)
+	m.propagation = 0
+
+	pending := m.pendingCertificates(time.Now())
+	if len(pending) != 1 {
+		t.Fatalf("expected one pending certificate but got %v", pending)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := m.order(ctx, pending[0]); err != nil {
+		t.Fatalf("unexpected error ordering certificate: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("default").Get(context.TODO(), "web-tls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the certificate Secret to be created: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeTLS || secret.Annotations[acmeIssuedAnnotation] != "true" {
+		t.Errorf("unexpected Secret %v %v", secret.Type, secret.Annotations)
+	}
+	if len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		t.Errorf("expected a private key in the Secret")
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		t.Fatalf("expected a certificate in the Secret")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unexpected error parsing certificate: %v", err)
+	}
+	if strings.Join(cert.DNSNames, ",") != "bar.example.com,foo.example.com" {
+		t.Errorf("unexpected hosts %v", cert.DNSNames)
+	}
+
+	if _, err := client.CoreV1().Secrets("ingress-nginx").Get(context.TODO(), acmeAccountSecretName, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the account key to be stored: %v", err)
+	}
+	if challenges := m.Challenges(); len(challenges) != 0 {
+		t.Errorf("expected the challenges to be removed but got %v", challenges)
+	}
+	if pending := m.pendingCertificates(time.Now()); len(pending) != 0 {
+		t.Errorf("expected no pending certificate but got %v", pending)
+	}
+}
+
+func TestACMEStoreCertificateManagedSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(newACMETestSecret("web-tls", nil, time.Now(), "foo.example.com"))
+	m := newACMEManager(client, acmeTestStore{client: client}, "", "", "ingress-nginx", 0)
+
+	err := m.storeCertificate(context.TODO(), acmeCertificate{Namespace: "default", SecretName: "web-tls"}, []byte("cert"), []byte("key"))
+	if err == nil {
+		t.Errorf("expected an error replacing a Secret not issued by the controller")
+	}
+}
+
+func TestSyncIngressConfiguresACMEChallenges(t *testing.T) {
+	server := newLuaConfigurationServer(t)
+	defer server.Close()
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: acmeChallengesSecretName, Namespace: "ingress-nginx"},
+		Data:       map[string][]byte{"first-token": []byte("first-token.thumbprint")},
+	})
+
+	n := newSyncTestController(t)
+	n.acmeManager = newACMEManager(client, acmeTestStore{client: client}, "", "", "ingress-nginx", 30*24*time.Hour)
+	n.runningConfig.ACMEChallenges = n.getACMEChallenges()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: acmeChallengesSecretName, Namespace: "ingress-nginx"},
+		Data:       map[string][]byte{"second-token": []byte("second-token.thumbprint")},
+	}
+	if _, err := client.CoreV1().Secrets("ingress-nginx").Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error updating the challenges: %v", err)
+	}
+
+	if err := n.syncIngress(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if challenges := server.Requests()["/configuration/acme-challenges"]; !strings.Contains(challenges, "second-token.thumbprint") {
+		t.Errorf("expected the new challenges to be configured but got %v", challenges)
+	}
+	if _, ok := n.runningConfig.ACMEChallenges["second-token"]; !ok {
+		t.Errorf("expected the running configuration to contain the new challenges")
+	}
+}
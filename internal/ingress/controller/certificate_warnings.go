@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/net/ssl"
+)
+
+const (
+	certificateExpiredReason      = "CertificateExpired"
+	certificateExpiringReason     = "CertificateExpiring"
+	certificateHostMismatchReason = "CertificateHostMismatch"
+
+	// certificateWarningInterval is the time before emitting again the event
+	// of a warning still present
+	certificateWarningInterval = 24 * time.Hour
+)
+
+// certificateWarning is a problem found in a certificate of the TLS section
+// of a MultiClusterIngress
+type certificateWarning struct {
+	Reason  string
+	Message string
+}
+
+// certificateWarnings returns the certificates of the TLS section of a
+// MultiClusterIngress that expired, expire within the warning window or do not
+// match one of their hosts, in which case the default certificate is served.
+// Missing Secrets are ignored.
+func certificateWarnings(mci *karmadanetwork.MultiClusterIngress, window time.Duration, now time.Time,
+	getSSLCert func(string) (*ingress.SSLCert, error)) []certificateWarning {
+
+	var warnings []certificateWarning
+	checked := sets.NewString()
+
+	for _, tls := range mci.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+
+		secrKey := fmt.Sprintf("%v/%v", mci.Namespace, tls.SecretName)
+		cert, err := getSSLCert(secrKey)
+		if err != nil || cert == nil || cert.Certificate == nil {
+			continue
+		}
+
+		if !checked.Has(secrKey) {
+			checked.Insert(secrKey)
+
+			for c := cert; c != nil; c = c.Alternate {
+				name := secrKey
+				if cert.Alternate != nil {
+					name = fmt.Sprintf("%v (%v)", secrKey, c.KeyType)
+				}
+
+				switch {
+				case c.ExpireTime.Before(now):
+					warnings = append(warnings, certificateWarning{
+						Reason:  certificateExpiredReason,
+						Message: fmt.Sprintf("SSL certificate %v expired on %v", name, c.ExpireTime.UTC().Format(time.RFC3339)),
+					})
+				case window > 0 && c.ExpireTime.Before(now.Add(window)):
+					warnings = append(warnings, certificateWarning{
+						Reason:  certificateExpiringReason,
+						Message: fmt.Sprintf("SSL certificate %v expires on %v", name, c.ExpireTime.UTC().Format(time.RFC3339)),
+					})
+				}
+			}
+		}
+
+		for _, host := range tls.Hosts {
+			if strings.HasPrefix(host, "*.") {
+				// wildcard entries only select the certificate of the hosts of the rules
+				continue
+			}
+
+			if cert.Certificate.VerifyHostname(host) == nil || verifyHostname(host, cert.Certificate) == nil {
+				continue
+			}
+
+			warnings = append(warnings, certificateWarning{
+				Reason:  certificateHostMismatchReason,
+				Message: fmt.Sprintf("SSL certificate %v does not match host %v, the default certificate is used", secrKey, host),
+			})
+		}
+	}
+
+	return warnings
+}
+
+// reportCertificateWarnings emits an event on the MultiClusterIngresses for
+// each certificate warning found, repeated every certificateWarningInterval
+// while the problem persists
+func (n *NGINXController) reportCertificateWarnings(mcis []*ingress.MultiClusterIngress) {
+	now := time.Now()
+	reported := map[string]time.Time{}
+
+	for _, mci := range mcis {
+		mciKey := k8s.MetaNamespaceKey(mci)
+		for _, warning := range certificateWarnings(&mci.MultiClusterIngress, n.cfg.SSLExpiryWarningWindow, now, n.store.GetLocalSSLCert) {
+			key := fmt.Sprintf("%v/%v/%v", mciKey, warning.Reason, warning.Message)
+
+			last, ok := n.certificateWarnings[key]
+			if ok && now.Sub(last) < certificateWarningInterval {
+				reported[key] = last
+				continue
+			}
+
+			n.recorder.Event(&mci.MultiClusterIngress, apiv1.EventTypeWarning, warning.Reason, warning.Message)
+			reported[key] = now
+		}
+	}
+
+	n.certificateWarnings = reported
+}
+
+// MCIWarnings returns the certificate warnings of a MultiClusterIngress, to
+// be returned as admission warnings
+func (n *NGINXController) MCIWarnings(mci *karmadanetwork.MultiClusterIngress) []string {
+	if mci == nil || !mci.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	var warnings []string
+	for _, warning := range certificateWarnings(mci, n.cfg.SSLExpiryWarningWindow, time.Now(), n.getSSLCertForCheck) {
+		warnings = append(warnings, warning.Message)
+	}
+
+	return warnings
+}
+
+// getSSLCertForCheck returns the certificate of a Secret, from the local copy
+// when the Secret is already used or reading the Secret otherwise
+func (n *NGINXController) getSSLCertForCheck(key string) (*ingress.SSLCert, error) {
+	if cert, err := n.store.GetLocalSSLCert(key); err == nil {
+		return cert, nil
+	}
+
+	secret, err := n.store.GetSecret(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssl.CreateSSLCert(secret.Data[apiv1.TLSCertKey], secret.Data[apiv1.TLSPrivateKeyKey], string(secret.UID))
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+func TestCertificateWarnings(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	certs := map[string]*ingress.SSLCert{
+		"default/valid": {
+			Certificate: fakeX509Cert([]string{"foo.bar"}),
+			ExpireTime:  now.Add(60 * 24 * time.Hour),
+		},
+		"default/expiring": {
+			Certificate: fakeX509Cert([]string{"foo.bar"}),
+			ExpireTime:  now.Add(5 * 24 * time.Hour),
+		},
+		"default/expired": {
+			Certificate: fakeX509Cert([]string{"foo.bar"}),
+			ExpireTime:  now.Add(-time.Hour),
+		},
+		"default/dual": {
+			Certificate: fakeX509Cert([]string{"foo.bar"}),
+			ExpireTime:  now.Add(60 * 24 * time.Hour),
+			KeyType:     "RSA",
+			Alternate: &ingress.SSLCert{
+				Certificate: fakeX509Cert([]string{"foo.bar"}),
+				ExpireTime:  now.Add(24 * time.Hour),
+				KeyType:     "ECDSA",
+			},
+		},
+	}
+	getSSLCert := func(key string) (*ingress.SSLCert, error) {
+		if cert, ok := certs[key]; ok {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("secret %v not found", key)
+	}
+
+	testCases := map[string]struct {
+		tls      []networking.IngressTLS
+		window   time.Duration
+		expected []certificateWarning
+	}{
+		"valid certificate": {
+			tls:    []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "valid"}},
+			window: 10 * 24 * time.Hour,
+		},
+		"missing secret": {
+			tls:    []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "missing"}},
+			window: 10 * 24 * time.Hour,
+		},
+		"certificate expiring within the window": {
+			tls:    []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "expiring"}},
+			window: 10 * 24 * time.Hour,
+			expected: []certificateWarning{
+				{certificateExpiringReason, "SSL certificate default/expiring expires on 2022-06-06T00:00:00Z"},
+			},
+		},
+		"expiring certificate with the warning disabled": {
+			tls: []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "expiring"}},
+		},
+		"expired certificate": {
+			tls: []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "expired"}},
+			expected: []certificateWarning{
+				{certificateExpiredReason, "SSL certificate default/expired expired on 2022-05-31T23:00:00Z"},
+			},
+		},
+		"host not matching the certificate": {
+			tls: []networking.IngressTLS{
+				{Hosts: []string{"foo.bar", "other.bar"}, SecretName: "valid"},
+				{Hosts: []string{"third.bar"}, SecretName: "valid"},
+			},
+			expected: []certificateWarning{
+				{certificateHostMismatchReason, "SSL certificate default/valid does not match host other.bar, the default certificate is used"},
+				{certificateHostMismatchReason, "SSL certificate default/valid does not match host third.bar, the default certificate is used"},
+			},
+		},
+		"wildcard host": {
+			tls: []networking.IngressTLS{{Hosts: []string{"*.bar"}, SecretName: "valid"}},
+		},
+		"expiring alternate certificate": {
+			tls:    []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "dual"}},
+			window: 10 * 24 * time.Hour,
+			expected: []certificateWarning{
+				{certificateExpiringReason, "SSL certificate default/dual (ECDSA) expires on 2022-06-02T00:00:00Z"},
+			},
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			mci := &karmadanetwork.MultiClusterIngress{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       networking.IngressSpec{TLS: tc.tls},
+			}
+
+			warnings := certificateWarnings(mci, tc.window, now, getSSLCert)
+			if !reflect.DeepEqual(warnings, tc.expected) {
+				t.Errorf("expected warnings %v but got %v", tc.expected, warnings)
+			}
+		})
+	}
+}
+
+// certificateTestStore returns the local copy of a single certificate
+type certificateTestStore struct {
+	fakeIngressStore
+	cert *ingress.SSLCert
+}
+
+func (s certificateTestStore) GetLocalSSLCert(name string) (*ingress.SSLCert, error) {
+	return s.cert, nil
+}
+
+func TestReportCertificateWarnings(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	n := &NGINXController{
+		cfg:      &Configuration{},
+		recorder: recorder,
+		store: certificateTestStore{cert: &ingress.SSLCert{
+			Certificate: fakeX509Cert([]string{"foo.bar"}),
+			ExpireTime:  time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+
+	mcis := []*ingress.MultiClusterIngress{{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: networking.IngressSpec{
+				TLS: []networking.IngressTLS{{Hosts: []string{"foo.bar"}, SecretName: "web-tls"}},
+			},
+		},
+	}}
+
+	n.reportCertificateWarnings(mcis)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but got %v", len(recorder.Events))
+	}
+	expected := "Warning CertificateExpired SSL certificate default/web-tls expired on 2022-06-01T00:00:00Z"
+	if event := <-recorder.Events; event != expected {
+		t.Errorf("expected event %q but got %q", expected, event)
+	}
+
+	n.reportCertificateWarnings(mcis)
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected the warning to be reported once but got %v events", len(recorder.Events))
+	}
+
+	for key := range n.certificateWarnings {
+		n.certificateWarnings[key] = time.Now().Add(-certificateWarningInterval)
+	}
+	n.reportCertificateWarnings(mcis)
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected the warning to be reported again after %v but got %v events", certificateWarningInterval, len(recorder.Events))
+	}
+
+	n.reportCertificateWarnings(nil)
+	if len(n.certificateWarnings) != 0 {
+		t.Errorf("expected the warnings no longer present to be forgotten but got %v", n.certificateWarnings)
+	}
+}
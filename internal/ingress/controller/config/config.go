@@ -477,6 +477,11 @@ type Configuration struct {
 	// http://nginx.org/en/docs/http/ngx_http_upstream_module.html#keepalive_requests
 	UpstreamKeepaliveRequests int `json:"upstream-keepalive-requests,omitempty"`
 
+	// Sets the number of seconds an endpoint removed from a backend is kept in a
+	// draining state, receiving no new requests while its keepalive connections finish.
+	// The zero value removes the endpoints right away.
+	UpstreamDrainingPeriod int `json:"upstream-draining-period,omitempty"`
+
 	// Sets the maximum size of the variables hash table.
 	// http://nginx.org/en/docs/http/ngx_http_map_module.html#variables_hash_max_size
 	LimitConnZoneVariable string `json:"limit-conn-zone-variable,omitempty"`
@@ -764,6 +769,23 @@ type Configuration struct {
 	// GlobalRateLimitStatucCode determines the HTTP status code to return
 	// when limit is exceeding during global rate limiting.
 	GlobalRateLimitStatucCode int `json:"global-rate-limit-status-code"`
+
+	// ProxyCacheZones contains the cache zones locations can store responses in,
+	// indexed by name. They are only created when a location enables the cache.
+	ProxyCacheZones map[string]ProxyCacheZone `json:"proxy-cache-zones"`
+
+	// ProxyCacheInactive is the time after which cached responses not accessed
+	// are removed, regardless of their freshness.
+	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	ProxyCacheInactive string `json:"proxy-cache-inactive"`
+}
+
+// ProxyCacheZone contains the size of a cache zone
+type ProxyCacheZone struct {
+	// KeysZoneSize is the size of the shared memory zone storing the keys
+	KeysZoneSize string `json:"keysZoneSize"`
+	// MaxSize is the maximum size of the responses stored on disk
+	MaxSize string `json:"maxSize"`
 }
 
 // NewDefault returns the default nginx configuration
@@ -865,35 +887,39 @@ func NewDefault() Configuration {
 		ProxyStreamNextUpstreamTimeout:   "600s",
 		ProxyStreamNextUpstreamTries:     3,
 		Backend: defaults.Backend{
-			ProxyBodySize:            bodySize,
-			ProxyConnectTimeout:      5,
-			ProxyReadTimeout:         60,
-			ProxySendTimeout:         60,
-			ProxyBuffersNumber:       4,
-			ProxyBufferSize:          "4k",
-			ProxyCookieDomain:        "off",
-			ProxyCookiePath:          "off",
-			ProxyNextUpstream:        "error timeout",
-			ProxyNextUpstreamTimeout: 0,
-			ProxyNextUpstreamTries:   3,
-			ProxyRequestBuffering:    "on",
-			ProxyRedirectFrom:        "off",
-			ProxyRedirectTo:          "off",
-			PreserveTrailingSlash:    false,
-			SSLRedirect:              true,
-			CustomHTTPErrors:         []int{},
-			WhitelistSourceRange:     []string{},
-			SkipAccessLogURLs:        []string{},
-			LimitRate:                0,
-			LimitRateAfter:           0,
-			ProxyBuffering:           "off",
-			ProxyHTTPVersion:         "1.1",
-			ProxyMaxTempFileSize:     "1024m",
-			ServiceUpstream:          false,
+			ProxyBodySize:               bodySize,
+			ProxyConnectTimeout:         5,
+			ProxyReadTimeout:            60,
+			ProxySendTimeout:            60,
+			ProxyBuffersNumber:          4,
+			ProxyBufferSize:             "4k",
+			ProxyCookieDomain:           "off",
+			ProxyCookiePath:             "off",
+			ProxyNextUpstream:           "error timeout",
+			ProxyNextUpstreamTimeout:    0,
+			ProxyNextUpstreamTries:      3,
+			ProxyRequestBuffering:       "on",
+			ProxyRedirectFrom:           "off",
+			ProxyRedirectTo:             "off",
+			PreserveTrailingSlash:       false,
+			SSLRedirect:                 true,
+			CustomHTTPErrors:            []int{},
+			WhitelistSourceRange:        []string{},
+			SkipAccessLogURLs:           []string{},
+			LimitRate:                   0,
+			LimitRateAfter:              0,
+			ProxyBuffering:              "off",
+			ProxyHTTPVersion:            "1.1",
+			ProxyMaxTempFileSize:        "1024m",
+			ServiceUpstream:             false,
+			SlowStartWindow:             0,
+			ZoneAwareRouting:            false,
+			ZoneAwareRoutingMinCapacity: 50,
 		},
 		UpstreamKeepaliveConnections:           320,
 		UpstreamKeepaliveTimeout:               60,
 		UpstreamKeepaliveRequests:              10000,
+		UpstreamDrainingPeriod:                 0,
 		LimitConnZoneVariable:                  defaultLimitConnZoneVariable,
 		BindAddressIpv4:                        defBindAddress,
 		BindAddressIpv6:                        defBindAddress,
@@ -927,6 +953,7 @@ func NewDefault() Configuration {
 		GlobalRateLimitMemcachedMaxIdleTimeout: 10000,
 		GlobalRateLimitMemcachedPoolSize:       50,
 		GlobalRateLimitStatucCode:              429,
+		ProxyCacheInactive:                     "10m",
 	}
 
 	if klog.V(5).Enabled() {
@@ -964,6 +991,9 @@ type TemplateConfig struct {
 	StatusPort     int
 	StreamPort     int
 	StreamSnippets []string
+
+	// EnableACME serves the responses to the ACME HTTP-01 challenges
+	EnableACME bool
 }
 
 // ListenPorts describe the ports required to run the
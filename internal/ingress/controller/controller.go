@@ -40,6 +40,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/inspector"
@@ -105,12 +106,18 @@ type Configuration struct {
 
 	FakeCertificate *ingress.SSLCert
 
+	// Zone is the topology zone of the node running the controller, used by
+	// the zone aware routing of the backends
+	Zone string
+
 	SyncRateLimit float32
 
 	DisableCatchAll bool
 
 	IngressClassConfiguration *ingressclass.IngressClassConfiguration
 
+	Shard *shard.Configuration
+
 	ValidationWebhook         string
 	ValidationWebhookCertPath string
 	ValidationWebhookKeyPath  string
@@ -124,6 +131,20 @@ type Configuration struct {
 	ShutdownGracePeriod int
 
 	DeepInspector bool
+
+	ReloadHistorySize int
+
+	EnableIncrementalConfig   bool
+	FullConfigRebuildInterval time.Duration
+
+	JWKSRefreshInterval time.Duration
+
+	ACMEDirectoryURL string
+	ACMEEmail        string
+	ACMENamespace    string
+	ACMERenewBefore  time.Duration
+
+	SSLExpiryWarningWindow time.Duration
 }
 
 // GetPublishService returns the Service used to set the load-balancer status of Ingresses.
@@ -146,12 +167,21 @@ func (n *NGINXController) syncIngress(interface{}) error {
 		return nil
 	}
 
+	events, changes := n.syncEvents.Drain()
+
 	//ings := n.store.ListIngresses()
 	//hosts, servers, pcfg := n.getConfiguration(ings)
-	mcis := n.store.ListMultiClusterIngresses()
-	hosts, servers, pcfg := n.getConfigurationFromMCI(mcis)
+	mcis := n.releaseMCIs(n.store.ListMultiClusterIngresses())
+	hosts, servers, pcfg := n.buildConfiguration(mcis, changes)
+	// the key sets are only needed by the running configuration, the
+	// configurations rendered to be tested do not download them
+	pcfg.JWKS = n.getJWKS(servers)
+	n.applyHealthChecks(pcfg.Backends)
+	n.applyZoneAwareRouting(pcfg.Backends)
+	n.applyEndpointDraining(pcfg.Backends)
 
 	n.metricCollector.SetSSLExpireTime(servers)
+	n.reportCertificateWarnings(mcis)
 
 	if n.runningConfig.Equal(pcfg) {
 		klog.V(3).Infof("No configuration change detected, skipping backend reload")
@@ -169,12 +199,24 @@ func (n *NGINXController) syncIngress(interface{}) error {
 
 		pcfg.ConfigurationChecksum = fmt.Sprintf("%v", hash)
 
-		err := n.OnUpdate(*pcfg)
+		start := time.Now()
+		diff, err := n.OnUpdate(*pcfg)
+		n.reloadHistory.Add(newReloadRecord(start, pcfg.ConfigurationChecksum, events, diff, err))
 		if err != nil {
 			n.metricCollector.IncReloadErrorCount()
 			n.metricCollector.ConfigSuccess(hash, false)
 			klog.Errorf("Unexpected failure reloading the backend:\n%v", err)
 			n.recorder.Eventf(k8s.IngressPodDetails, apiv1.EventTypeWarning, "RELOAD", fmt.Sprintf("Error reloading NGINX: %v", err))
+
+			// excluded objects do not generate events
+			n.configCache = nil
+
+			if isInvalidConfiguration(err) {
+				n.excludeInvalidMCIs(mcis)
+			} else {
+				n.quarantineMCIs(mcis, err)
+			}
+
 			return err
 		}
 
@@ -221,6 +263,7 @@ func (n *NGINXController) syncIngress(interface{}) error {
 	n.metricCollector.RemoveMetrics(ri, re)
 
 	n.runningConfig = pcfg
+	n.quarantine.Applied(mcis)
 
 	return nil
 }
@@ -552,8 +595,10 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 		UDPEndpoints:          n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
 		PassthroughBackends:   passUpstreams,
 		BackendConfigChecksum: n.store.GetBackendConfiguration().Checksum,
-		DefaultSSLCertificate: n.getDefaultSSLCertificate(),
+		DefaultSSLCertificate: n.getDefaultSSLCertificate(nil),
 		StreamSnippets:        n.getStreamSnippets(ingresses),
+		OIDCSecrets:           getOIDCSecrets(servers),
+		ACMEChallenges:        n.getACMEChallenges(),
 	}
 }
 
@@ -900,6 +945,12 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 				upstreams[defBackend].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 			}
 
+			upstreams[defBackend].RetryPolicy = newRetryPolicy(anns.RetryPolicy)
+			upstreams[defBackend].HealthCheck = anns.HealthCheck
+			upstreams[defBackend].SlowStartWindow = anns.SlowStartWindow
+			upstreams[defBackend].ZoneAwareRouting = anns.ZoneAwareRouting
+			upstreams[defBackend].CircuitBreaker = anns.CircuitBreaker
+
 			svcKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Spec.DefaultBackend.Service.Name)
 
 			// add the service ClusterIP as a single Endpoint instead of individual Endpoints
@@ -972,6 +1023,12 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 					upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 				}
 
+				upstreams[name].RetryPolicy = newRetryPolicy(anns.RetryPolicy)
+				upstreams[name].HealthCheck = anns.HealthCheck
+				upstreams[name].SlowStartWindow = anns.SlowStartWindow
+				upstreams[name].ZoneAwareRouting = anns.ZoneAwareRouting
+				upstreams[name].CircuitBreaker = anns.CircuitBreaker
+
 				svcKey := fmt.Sprintf("%v/%v", ing.Namespace, svcName)
 
 				// add the service ClusterIP as a single Endpoint instead of individual Endpoints
@@ -1106,7 +1163,18 @@ func (n *NGINXController) serviceEndpoints(svcKey, backendPort string) ([]ingres
 	return upstreams, nil
 }
 
-func (n *NGINXController) getDefaultSSLCertificate() *ingress.SSLCert {
+func (n *NGINXController) getDefaultSSLCertificate(params *ingressclass.Parameters) *ingress.SSLCert {
+	// read the default SSL certificate of the IngressClass, fall back to the
+	// default certificate of the controller
+	if params != nil && params.DefaultSSLCertificate != "" {
+		certificate, err := n.store.GetLocalSSLCert(params.DefaultSSLCertificate)
+		if err == nil {
+			return certificate
+		}
+
+		klog.Warningf("Error loading default certificate %v of the IngressClass, falling back to the default certificate:\n%v", params.DefaultSSLCertificate, err)
+	}
+
 	// read custom default SSL certificate, fall back to generated default certificate
 	if n.cfg.DefaultSSLCertificate != "" {
 		certificate, err := n.store.GetLocalSSLCert(n.cfg.DefaultSSLCertificate)
@@ -1154,7 +1222,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 	pathTypePrefix := networking.PathTypePrefix
 	servers[defServerName] = &ingress.Server{
 		Hostname: defServerName,
-		SSLCert:  n.getDefaultSSLCertificate(),
+		SSLCert:  n.getDefaultSSLCertificate(nil),
 		Locations: []*ingress.Location{
 			{
 				Path:         rootLocation,
@@ -1311,7 +1379,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 			tlsSecretName := extractTLSSecretName(host, ing, n.store.GetLocalSSLCert)
 			if tlsSecretName == "" {
 				klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using default certificate", host)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(nil)
 				continue
 			}
 
@@ -1319,14 +1387,14 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 			cert, err := n.store.GetLocalSSLCert(secrKey)
 			if err != nil {
 				klog.Warningf("Error getting SSL certificate %q: %v. Using default certificate", secrKey, err)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(nil)
 				continue
 			}
 
 			if cert.Certificate == nil {
 				klog.Warningf("SSL certificate %q does not contain a valid SSL certificate for server %q", secrKey, host)
 				klog.Warningf("Using default certificate")
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(nil)
 				continue
 			}
 
@@ -1340,7 +1408,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				if err != nil {
 					klog.Warningf("SSL certificate %q does not contain a Common Name or Subject Alternative Name for server %q: %v", secrKey, host, err)
 					klog.Warningf("Using default certificate")
-					servers[host].SSLCert = n.getDefaultSSLCertificate()
+					servers[host].SSLCert = n.getDefaultSSLCertificate(nil)
 					continue
 				}
 			}
@@ -1414,6 +1482,12 @@ func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress)
 	loc.ModSecurity = anns.ModSecurity
 	loc.Satisfy = anns.Satisfy
 	loc.Mirror = anns.Mirror
+	loc.HeaderModifier = anns.HeaderModifier
+	loc.JWTAuth = anns.JWTAuth
+	loc.OIDC = anns.OIDC
+	loc.ProxyCache = anns.ProxyCache
+	loc.FaultInjection = anns.FaultInjection
+	loc.RetryPolicy = anns.RetryPolicy
 
 	loc.DefaultBackendUpstreamName = defUpstreamName
 }
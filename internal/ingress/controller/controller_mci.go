@@ -16,9 +16,14 @@ import (
 
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/headermodifier"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routematch"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/trafficsplit"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/k8s"
@@ -28,6 +33,13 @@ import (
 // getConfigurationFromMCI returns the configuration matching the multiclusteringress
 func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIngress) (sets.String, []*ingress.Server, *ingress.Configuration) {
 	upstreams, servers := n.getBackendServersFromMCIs(mcis)
+	return n.completeConfigurationFromMCI(mcis, upstreams, servers)
+}
+
+// completeConfigurationFromMCI returns the configuration containing the upstreams
+// and servers built from the multiclusteringresses
+func (n *NGINXController) completeConfigurationFromMCI(mcis []*ingress.MultiClusterIngress,
+	upstreams []*ingress.Backend, servers []*ingress.Server) (sets.String, []*ingress.Server, *ingress.Configuration) {
 	var passUpstreams []*ingress.SSLPassthroughBackend
 
 	hosts := sets.NewString()
@@ -83,8 +95,10 @@ func (n *NGINXController) getConfigurationFromMCI(mcis []*ingress.MultiClusterIn
 		UDPEndpoints:          n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
 		PassthroughBackends:   passUpstreams,
 		BackendConfigChecksum: n.store.GetBackendConfiguration().Checksum,
-		DefaultSSLCertificate: n.getDefaultSSLCertificate(),
+		DefaultSSLCertificate: n.getDefaultSSLCertificate(nil),
 		StreamSnippets:        n.getStreamSnippetsFromMCIs(mcis),
+		OIDCSecrets:           getOIDCSecrets(servers),
+		ACMEChallenges:        n.getACMEChallenges(),
 	}
 }
 
@@ -289,6 +303,17 @@ func (n *NGINXController) getBackendServersFromMCIs(mcis []*ingress.MultiCluster
 		}
 	}
 
+	for _, mci := range mcis {
+		if len(mci.ParsedAnnotations.TrafficSplit.Branches) > 0 {
+			n.mergeTrafficSplitBackends(mci, servers, upstreams)
+		}
+		if len(mci.ParsedAnnotations.RouteMatch.Rules) > 0 {
+			n.mergeRouteMatchBackends(mci, servers, upstreams)
+		}
+	}
+
+	n.mergeMirrorBackends(servers, upstreams)
+
 	aUpstreams := make([]*ingress.Backend, 0, len(upstreams))
 
 	for _, upstream := range upstreams {
@@ -402,6 +427,12 @@ func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIn
 				upstreams[defBackend].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 			}
 
+			upstreams[defBackend].RetryPolicy = newRetryPolicy(anns.RetryPolicy)
+			upstreams[defBackend].HealthCheck = anns.HealthCheck
+			upstreams[defBackend].SlowStartWindow = anns.SlowStartWindow
+			upstreams[defBackend].ZoneAwareRouting = anns.ZoneAwareRouting
+			upstreams[defBackend].CircuitBreaker = anns.CircuitBreaker
+
 			svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(mci.Spec.DefaultBackend.Service.Name))
 
 			// add the service ClusterIP as a single Endpoint instead of individual Endpoints
@@ -474,6 +505,12 @@ func (n *NGINXController) createUpstreamsFromMCIs(mcis []*ingress.MultiClusterIn
 					upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 				}
 
+				upstreams[name].RetryPolicy = newRetryPolicy(anns.RetryPolicy)
+				upstreams[name].HealthCheck = anns.HealthCheck
+				upstreams[name].SlowStartWindow = anns.SlowStartWindow
+				upstreams[name].ZoneAwareRouting = anns.ZoneAwareRouting
+				upstreams[name].CircuitBreaker = anns.CircuitBreaker
+
 				svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svcName))
 
 				// add the service ClusterIP as a single Endpoint instead of individual Endpoints
@@ -556,7 +593,7 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 	pathTypePrefix := networking.PathTypePrefix
 	servers[defServerName] = &ingress.Server{
 		Hostname: defServerName,
-		SSLCert:  n.getDefaultSSLCertificate(),
+		SSLCert:  n.getDefaultSSLCertificate(nil),
 		Locations: []*ingress.Location{
 			{
 				Path:         rootLocation,
@@ -666,6 +703,8 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 			continue
 		}
 
+		params := n.getIngressClassParameters(mci)
+
 		for _, rule := range mci.Spec.Rules {
 			host := rule.Host
 			if host == "" {
@@ -700,6 +739,20 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 				servers[host].SSLPreferServerCiphers = anns.SSLCipher.SSLPreferServerCiphers
 			}
 
+			// the TLS policy of the IngressClass applies when the annotations
+			// do not configure the server
+			if params != nil && host != defServerName {
+				if servers[host].SSLCiphers == "" {
+					servers[host].SSLCiphers = params.SSLCiphers
+				}
+				if servers[host].SSLProtocols == "" {
+					servers[host].SSLProtocols = params.SSLProtocols
+				}
+				if servers[host].HSTS == nil && params.HasHSTS() {
+					servers[host].HSTS = serverHSTS(params, n.store.GetBackendConfiguration())
+				}
+			}
+
 			// only add a certificate if the server does not have one previously configured
 			if servers[host].SSLCert != nil {
 				continue
@@ -713,7 +766,7 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 			tlsSecretName := extractTLSSecretNameFromMCI(host, mci, n.store.GetLocalSSLCert)
 			if tlsSecretName == "" {
 				klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using default certificate", host)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(params)
 				continue
 			}
 
@@ -721,14 +774,14 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 			cert, err := n.store.GetLocalSSLCert(secrKey)
 			if err != nil {
 				klog.Warningf("Error getting SSL certificate %q: %v. Using default certificate", secrKey, err)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(params)
 				continue
 			}
 
 			if cert.Certificate == nil {
 				klog.Warningf("SSL certificate %q does not contain a valid SSL certificate for server %q", secrKey, host)
 				klog.Warningf("Using default certificate")
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getDefaultSSLCertificate(params)
 				continue
 			}
 
@@ -742,17 +795,21 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 				if err != nil {
 					klog.Warningf("SSL certificate %q does not contain a Common Name or Subject Alternative Name for server %q: %v", secrKey, host, err)
 					klog.Warningf("Using default certificate")
-					servers[host].SSLCert = n.getDefaultSSLCertificate()
+					servers[host].SSLCert = n.getDefaultSSLCertificate(params)
 					continue
 				}
 			}
 
+			if cert.Alternate == nil {
+				cert = n.withAlternateSSLCertFromMCI(host, tlsSecretName, mci, cert)
+			}
+
 			servers[host].SSLCert = cert
 
 			now := time.Now()
 			if cert.ExpireTime.Before(now) {
 				klog.Warningf("SSL certificate for server %q expired (%v)", host, cert.ExpireTime)
-			} else if cert.ExpireTime.Before(now.Add(240 * time.Hour)) {
+			} else if n.cfg.SSLExpiryWarningWindow > 0 && cert.ExpireTime.Before(now.Add(n.cfg.SSLExpiryWarningWindow)) {
 				klog.Warningf("SSL certificate for server %q is about to expire (%v)", host, cert.ExpireTime)
 			}
 		}
@@ -786,6 +843,55 @@ func (n *NGINXController) createServersFromMCIs(mcis []*ingress.MultiClusterIngr
 	return servers
 }
 
+// getIngressClassParameters returns the parameters of the IngressClass of a
+// MultiClusterIngress, or nil when the IngressClass has no parameters
+func (n *NGINXController) getIngressClassParameters(mci *ingress.MultiClusterIngress) *ingressclass.Parameters {
+	className := ""
+	if mci.Spec.IngressClassName != nil {
+		className = *mci.Spec.IngressClassName
+	} else if n.cfg.IngressClassConfiguration != nil && n.cfg.IngressClassConfiguration.IngressClassByName {
+		className = mci.GetAnnotations()[ingressclass.IngressKey]
+	}
+
+	if className == "" {
+		return nil
+	}
+
+	params, err := n.store.GetIngressClassParameters(className)
+	if err != nil {
+		klog.Warningf("Error reading parameters of IngressClass %q of MultiClusterIngress %q: %v", className, k8s.MetaNamespaceKey(mci), err)
+		return nil
+	}
+
+	return params
+}
+
+// serverHSTS returns the HSTS configuration of the servers of an IngressClass,
+// using the global configuration for the settings the parameters omit
+func serverHSTS(params *ingressclass.Parameters, cfg ngx_config.Configuration) *ingress.HSTS {
+	hsts := &ingress.HSTS{
+		Enable:            cfg.HSTS,
+		MaxAge:            cfg.HSTSMaxAge,
+		IncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		Preload:           cfg.HSTSPreload,
+	}
+
+	if params.HSTS != nil {
+		hsts.Enable = *params.HSTS
+	}
+	if params.HSTSMaxAge != "" {
+		hsts.MaxAge = params.HSTSMaxAge
+	}
+	if params.HSTSIncludeSubdomains != nil {
+		hsts.IncludeSubdomains = *params.HSTSIncludeSubdomains
+	}
+	if params.HSTSPreload != nil {
+		hsts.Preload = *params.HSTSPreload
+	}
+
+	return hsts
+}
+
 // extractTLSSecretNameFromMCI returns the name of the Secret containing a SSL
 // certificate for the given host name, or an empty string.
 func extractTLSSecretNameFromMCI(host string, mci *ingress.MultiClusterIngress,
@@ -836,6 +942,58 @@ func extractTLSSecretNameFromMCI(host string, mci *ingress.MultiClusterIngress,
 	return ""
 }
 
+// withAlternateSSLCertFromMCI returns the SSL certificate of a host with the
+// certificate of another Secret of the TLS section listing the host as
+// alternate, when it has a different key type. The certificate is copied
+// since the store shares it with the other hosts using the Secret.
+func (n *NGINXController) withAlternateSSLCertFromMCI(host, secretName string, mci *ingress.MultiClusterIngress,
+	cert *ingress.SSLCert) *ingress.SSLCert {
+
+	lowercaseHost := toLowerCaseASCII(host)
+	for _, tls := range mci.Spec.TLS {
+		if tls.SecretName == "" || tls.SecretName == secretName {
+			continue
+		}
+
+		listed := false
+		for _, tlsHost := range tls.Hosts {
+			if toLowerCaseASCII(tlsHost) == lowercaseHost {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			continue
+		}
+
+		secrKey := fmt.Sprintf("%v/%v", mci.Namespace, tls.SecretName)
+		alternate, err := n.store.GetLocalSSLCert(secrKey)
+		if err != nil {
+			klog.Warningf("Error getting alternate SSL certificate %q: %v", secrKey, err)
+			continue
+		}
+
+		if alternate.Certificate == nil || alternate.KeyType == cert.KeyType {
+			continue
+		}
+
+		if err := alternate.Certificate.VerifyHostname(host); err != nil {
+			klog.Warningf("Alternate SSL certificate %q is not valid for server %q: %v", secrKey, host, err)
+			continue
+		}
+
+		alt := *alternate
+		alt.Alternate = nil
+
+		c := *cert
+		c.Alternate = &alt
+		klog.V(3).Infof("Using SSL certificates %q with %v and %v keys for server %q", secrKey, c.KeyType, alt.KeyType, host)
+		return &c
+	}
+
+	return cert
+}
+
 // OK to merge canary multiclusteringresses iff there exists one or more multiclusteringresses to potentially merge into
 func nonCanaryMCIExists(mcis []*ingress.MultiClusterIngress, canaryMCIs []*ingress.MultiClusterIngress) bool {
 	return len(mcis)-len(canaryMCIs) > 0
@@ -970,6 +1128,303 @@ func mergeAlternativeBackendByMCI(mci *ingress.MultiClusterIngress, priUps *ingr
 	return true
 }
 
+// mergeTrafficSplitBackends creates an upstream for each service of the traffic
+// split of a MultiClusterIngress, and a copy of the primary backend of each of
+// its locations holding them as alternative backends, so the split does not
+// apply to the other hosts, objects and paths sharing the backends
+func (n *NGINXController) mergeTrafficSplitBackends(mci *ingress.MultiClusterIngress, servers map[string]*ingress.Server, upstreams map[string]*ingress.Backend) {
+	mciKey := k8s.MetaNamespaceKey(mci)
+	anns := mci.ParsedAnnotations
+
+	if anns.Canary.Enabled {
+		klog.Warningf("MultiClusterIngress %q is a canary, ignoring its traffic split", mciKey)
+		return
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.MultiClusterIngress == nil || k8s.MetaNamespaceKey(loc.MultiClusterIngress) != mciKey {
+				continue
+			}
+
+			priUps := upstreams[loc.Backend]
+			if priUps == nil || priUps.NoServer || priUps.Name == defUpstreamName {
+				continue
+			}
+
+			loc.Backend = n.trafficSplitUpstream(mci, priUps, upstreams)
+		}
+	}
+}
+
+// trafficSplitUpstream returns the name of the copy of a primary backend holding
+// the traffic split of a MultiClusterIngress, created on first use. The upstreams
+// of the services of the split belong to the MultiClusterIngress as well, and the
+// services that are the primary backend itself are left out.
+func (n *NGINXController) trafficSplitUpstream(mci *ingress.MultiClusterIngress, priUps *ingress.Backend, upstreams map[string]*ingress.Backend) string {
+	name := fmt.Sprintf("%v-split-%v", priUps.Name, mci.Name)
+	if _, ok := upstreams[name]; ok {
+		return name
+	}
+
+	split := mci.ParsedAnnotations.TrafficSplit
+
+	var branches []*ingress.Backend
+	for _, branch := range split.Branches {
+		svc := branch.ServiceBackend()
+		svcName := upstreamName(mci.Namespace, svc)
+		if svcName == priUps.Name {
+			continue
+		}
+
+		altName := fmt.Sprintf("%v-branch-%v", svcName, mci.Name)
+		altUps, ok := upstreams[altName]
+		if !ok {
+			altUps = n.createNoServerUpstream(mci, altName, svc)
+			altUps.TrafficShapingPolicy = ingress.TrafficShapingPolicy{
+				Weight:        branch.Weight,
+				WeightTotal:   split.WeightTotal,
+				Header:        branch.Header,
+				HeaderValue:   branch.HeaderValue,
+				HeaderPattern: branch.HeaderPattern,
+				Cookie:        branch.Cookie,
+			}
+			upstreams[altName] = altUps
+		}
+
+		branches = append(branches, altUps)
+	}
+	if len(branches) == 0 {
+		return priUps.Name
+	}
+
+	klog.V(3).Infof("Creating upstream %q with the traffic split of MultiClusterIngress %q", name, k8s.MetaNamespaceKey(mci))
+	ups := priUps.DeepCopy()
+	ups.Name = name
+	for _, altUps := range branches {
+		mergeAlternativeBackendByMCI(mci, ups, altUps)
+	}
+
+	upstreams[name] = ups
+	return name
+}
+
+// mergeRouteMatchBackends creates an upstream for each service of the route
+// matches of a MultiClusterIngress, and a copy of the primary backend of each
+// of its locations holding the matches, so they do not apply to the other
+// hosts, objects and paths sharing the backend. Requests not satisfying any
+// match are served by the endpoints of the primary backend.
+func (n *NGINXController) mergeRouteMatchBackends(mci *ingress.MultiClusterIngress, servers map[string]*ingress.Server, upstreams map[string]*ingress.Backend) {
+	mciKey := k8s.MetaNamespaceKey(mci)
+	anns := mci.ParsedAnnotations
+
+	if anns.Canary.Enabled {
+		klog.Warningf("MultiClusterIngress %q is a canary, ignoring its route matches", mciKey)
+		return
+	}
+
+	var routeMatches []ingress.RouteMatch
+	for _, match := range anns.RouteMatch.Rules {
+		svc := match.ServiceBackend()
+		name := upstreamName(mci.Namespace, svc)
+
+		if _, ok := upstreams[name]; !ok {
+			upstreams[name] = n.createNoServerUpstream(mci, name, svc)
+		}
+
+		routeMatches = append(routeMatches, ingress.RouteMatch{
+			Backend:       name,
+			Methods:       match.Methods,
+			Header:        match.Header,
+			HeaderValue:   match.HeaderValue,
+			HeaderPattern: match.HeaderPattern,
+			QueryParam:    match.QueryParam,
+			QueryValue:    match.QueryValue,
+			QueryPattern:  match.QueryPattern,
+		})
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.MultiClusterIngress == nil || k8s.MetaNamespaceKey(loc.MultiClusterIngress) != mciKey {
+				continue
+			}
+
+			priUps := upstreams[loc.Backend]
+			if priUps == nil || priUps.NoServer || priUps.Name == defUpstreamName {
+				continue
+			}
+
+			loc.Backend = routeMatchUpstream(mci, priUps, routeMatches, upstreams)
+		}
+	}
+}
+
+// routeMatchUpstream returns the name of the copy of a primary backend holding
+// the route matches of a MultiClusterIngress, created on first use. The matches
+// sending the requests to the primary backend itself are left out.
+func routeMatchUpstream(mci *ingress.MultiClusterIngress, priUps *ingress.Backend, routeMatches []ingress.RouteMatch, upstreams map[string]*ingress.Backend) string {
+	name := fmt.Sprintf("%v-routes-%v", priUps.Name, mci.Name)
+	if _, ok := upstreams[name]; ok {
+		return name
+	}
+
+	var matches []ingress.RouteMatch
+	for _, routeMatch := range routeMatches {
+		if routeMatch.Backend != priUps.Name {
+			matches = append(matches, routeMatch)
+		}
+	}
+	if len(matches) == 0 {
+		return priUps.Name
+	}
+
+	klog.V(3).Infof("Creating upstream %q with the route matches of MultiClusterIngress %q", name, k8s.MetaNamespaceKey(mci))
+	ups := priUps.DeepCopy()
+	ups.Name = name
+	ups.RouteMatches = matches
+
+	upstreams[name] = ups
+	return name
+}
+
+// mergeMirrorBackends sets the upstreams receiving the copies of the requests
+// of the locations mirrored to another member cluster or to another backend
+func (n *NGINXController) mergeMirrorBackends(servers map[string]*ingress.Server, upstreams map[string]*ingress.Backend) {
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.Mirror.Upstream != "" || loc.MultiClusterIngress == nil {
+				continue
+			}
+
+			mci := loc.MultiClusterIngress
+			mciKey := k8s.MetaNamespaceKey(mci)
+
+			var name string
+			switch {
+			case loc.Mirror.Cluster != "":
+				priUps := upstreams[loc.Backend]
+				if priUps == nil || priUps.Name == defUpstreamName {
+					klog.Warningf("Location %q of MultiClusterIngress %q has no backend to mirror to member cluster %q", loc.Path, mciKey, loc.Mirror.Cluster)
+					continue
+				}
+				loc.Backend, name = mirrorClusterUpstreams(priUps, loc.Mirror.Cluster, upstreams)
+			case loc.Mirror.Backend != "":
+				svc := loc.Mirror.ServiceBackend()
+				name = upstreamName(mci.Namespace, svc)
+				if name == loc.Backend {
+					klog.Warningf("Location %q of MultiClusterIngress %q mirrors its requests to its own backend, ignoring the mirror", loc.Path, mciKey)
+					continue
+				}
+				if _, ok := upstreams[name]; !ok {
+					upstreams[name] = n.createNoServerUpstream(mci, name, svc)
+				}
+			default:
+				continue
+			}
+
+			loc.Mirror.Upstream = name
+			loc.Mirror.Source = fmt.Sprintf("%v-%v", loc.Mirror.Source, name)
+		}
+	}
+}
+
+// mirrorClusterUpstreams returns the names of the upstreams splitting the
+// endpoints of a backend for the locations mirrored to a member cluster: the
+// endpoints of the other clusters serve the requests, the endpoints of the
+// cluster only receive the mirrored requests. They are created on first use
+// and the backend, shared with the other locations, is left unchanged.
+func mirrorClusterUpstreams(priUps *ingress.Backend, cluster string, upstreams map[string]*ingress.Backend) (string, string) {
+	name := fmt.Sprintf("%v-except-%v", priUps.Name, cluster)
+	mirrorName := fmt.Sprintf("%v-mirror-%v", priUps.Name, cluster)
+	if _, ok := upstreams[mirrorName]; ok {
+		return name, mirrorName
+	}
+
+	klog.V(3).Infof("Creating upstreams %q and %q mirroring upstream %q to member cluster %q", name, mirrorName, priUps.Name, cluster)
+	ups := priUps.DeepCopy()
+	ups.Name = name
+	ups.Endpoints = make([]ingress.Endpoint, 0, len(priUps.Endpoints))
+
+	mirrorUps := newUpstream(mirrorName)
+	mirrorUps.NoServer = true
+	mirrorUps.Port = priUps.Port
+	mirrorUps.Service = priUps.Service
+	mirrorUps.LoadBalancing = priUps.LoadBalancing
+	mirrorUps.UpstreamHashBy = priUps.UpstreamHashBy
+	mirrorUps.CircuitBreaker = priUps.CircuitBreaker
+
+	for _, ep := range priUps.Endpoints {
+		if ep.Cluster == cluster {
+			mirrorUps.Endpoints = append(mirrorUps.Endpoints, ep)
+			continue
+		}
+		ups.Endpoints = append(ups.Endpoints, ep)
+	}
+
+	if len(mirrorUps.Endpoints) == 0 {
+		klog.Warningf("Upstream %q has no endpoint in member cluster %q to mirror the requests to", priUps.Name, cluster)
+	}
+
+	upstreams[name] = ups
+	upstreams[mirrorName] = mirrorUps
+	return name, mirrorName
+}
+
+// createNoServerUpstream creates the upstream of a service selected in Lua by a
+// traffic split, a route match or a mirror, which is not referenced by any server
+func (n *NGINXController) createNoServerUpstream(mci *ingress.MultiClusterIngress, name string, svc *networking.IngressServiceBackend) *ingress.Backend {
+	anns := mci.ParsedAnnotations
+
+	klog.V(3).Infof("Creating upstream %q without server", name)
+	ups := newUpstream(name)
+	ups.NoServer = true
+	_, ups.Port = upstreamServiceNameAndPort(svc)
+
+	ups.UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
+	ups.UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
+	ups.UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+
+	ups.LoadBalancing = anns.LoadBalancing
+	if ups.LoadBalancing == "" {
+		ups.LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
+	}
+
+	ups.RetryPolicy = newRetryPolicy(anns.RetryPolicy)
+	ups.HealthCheck = anns.HealthCheck
+	ups.SlowStartWindow = anns.SlowStartWindow
+	ups.ZoneAwareRouting = anns.ZoneAwareRouting
+	ups.CircuitBreaker = anns.CircuitBreaker
+
+	svcKey := fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svc.Name))
+
+	// add the service ClusterIP as a single Endpoint instead of individual Endpoints
+	if anns.ServiceUpstream {
+		endpoint, err := n.getServiceClusterEndpoint(svcKey, &networking.IngressBackend{Service: svc})
+		if err != nil {
+			klog.Errorf("Failed to determine a suitable ClusterIP Endpoint for Service %q: %v", svcKey, err)
+		} else {
+			ups.Endpoints = []ingress.Endpoint{endpoint}
+		}
+	}
+
+	if len(ups.Endpoints) == 0 {
+		endps, err := n.serviceEndpoints(svcKey, ups.Port.String())
+		if err != nil {
+			klog.Warningf("Error obtaining Endpoints for Service %q: %v", svcKey, err)
+		}
+		ups.Endpoints = endps
+	}
+
+	s, err := n.store.GetService(svcKey)
+	if err != nil {
+		klog.Warningf("Error obtaining Service %q: %v", svcKey, err)
+	}
+	ups.Service = s
+
+	return ups
+}
+
 func (n *NGINXController) getStreamSnippetsFromMCIs(mcis []*ingress.MultiClusterIngress) []string {
 	snippets := make([]string, 0, len(mcis))
 	for _, mci := range mcis {
@@ -1034,6 +1489,12 @@ func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) erro
 		return nil
 	}
 
+	// objects from other shards are validated by the controllers that own them
+	if !n.store.OwnsMultiClusterIngress(mci) {
+		klog.V(2).InfoS("skipping validation of multiclusteringress from another shard", "multiclusteringress", klog.KObj(mci))
+		return nil
+	}
+
 	if n.cfg.DisableCatchAll && mci.Spec.DefaultBackend != nil {
 		return fmt.Errorf("This deployment is trying to create a catch-all multiclusteringress while DisableCatchAll flag is set to true. Remove '.spec.backend' or set DisableCatchAll flag to false. ")
 	}
@@ -1072,6 +1533,18 @@ func (n *NGINXController) CheckMCI(mci *karmadanetwork.MultiClusterIngress) erro
 
 	}
 
+	if _, err := trafficsplit.NewParser(n.store).ParseByMCI(mci); err != nil && !errors.IsMissingAnnotations(err) {
+		return err
+	}
+
+	if _, err := routematch.NewParser(n.store).ParseByMCI(mci); err != nil && !errors.IsMissingAnnotations(err) {
+		return err
+	}
+
+	if _, err := headermodifier.NewParser(n.store).ParseByMCI(mci); err != nil && !errors.IsMissingAnnotations(err) {
+		return err
+	}
+
 	karmada.SetDefaultNGINXPathType(mci)
 
 	allMCIs := n.store.ListMultiClusterIngresses()
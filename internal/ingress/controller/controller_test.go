@@ -27,11 +27,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/eapache/channels"
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
 	karmadafake "github.com/karmada-io/karmada/pkg/generated/clientset/versioned/fake"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -39,16 +41,21 @@ import (
 	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"k8s.io/ingress-nginx/internal/file"
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routematch"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/trafficsplit"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
@@ -58,6 +65,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/net/ssl"
+	"k8s.io/ingress-nginx/internal/task"
 )
 
 type fakeIngressStore struct {
@@ -97,6 +105,10 @@ func (fis fakeIngressStore) ListMultiClusterIngresses() []*ingress.MultiClusterI
 	return nil
 }
 
+func (fis fakeIngressStore) OwnsMultiClusterIngress(mci *karmadanetwork.MultiClusterIngress) bool {
+	return true
+}
+
 func (fis fakeIngressStore) FilterIngresses(ingresses []*ingress.Ingress, filterFunc store.IngressFilterFunc) []*ingress.Ingress {
 	return ingresses
 }
@@ -117,6 +129,10 @@ func (fakeIngressStore) GetDefaultBackend() defaults.Backend {
 	return defaults.Backend{}
 }
 
+func (fakeIngressStore) GetIngressClassParameters(name string) (*ingressclass.Parameters, error) {
+	return nil, nil
+}
+
 func (fakeIngressStore) Run(stopCh chan struct{}) {}
 
 type testNginxTestCommand struct {
@@ -2411,6 +2427,7 @@ func newNGINXController(t *testing.T) *NGINXController {
 			Controller:      "k8s.io/ingress-nginx",
 			AnnotationValue: "nginx",
 		},
+		nil,
 	)
 
 	sslCert := ssl.GetFakeSSLCert()
@@ -2428,6 +2445,32 @@ func newNGINXController(t *testing.T) *NGINXController {
 	}
 }
 
+// syncTestStore lists a fixed set of MultiClusterIngresses
+type syncTestStore struct {
+	store.Storer
+	mcis []*ingress.MultiClusterIngress
+}
+
+func (s syncTestStore) ListMultiClusterIngresses() []*ingress.MultiClusterIngress {
+	return s.mcis
+}
+
+// newSyncTestController returns a controller able to run syncIngress with
+// the MultiClusterIngresses given, running the configuration built from them
+func newSyncTestController(t *testing.T, mcis ...*ingress.MultiClusterIngress) *NGINXController {
+	n := newNGINXController(t)
+	n.store = syncTestStore{Storer: n.store, mcis: mcis}
+	n.syncRateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	n.syncEvents = newSyncEvents()
+	n.metricCollector = metric.DummyCollector{}
+	n.quarantine = newMCIQuarantine()
+	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+
+	_, _, n.runningConfig = n.getConfigurationFromMCI(mcis)
+
+	return n
+}
+
 var oidExtensionSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
 
 func fakeX509Cert(dnsNames []string) *x509.Certificate {
@@ -2479,7 +2522,8 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *v1.Confi
 		&ingressclass.IngressClassConfiguration{
 			Controller:      "k8s.io/ingress-nginx",
 			AnnotationValue: "nginx",
-		})
+		},
+		nil)
 
 	sslCert := ssl.GetFakeSSLCert()
 	config := &Configuration{
@@ -2495,3 +2539,378 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *v1.Confi
 		command: NewNginxCommand(),
 	}
 }
+
+// testMCI returns a MultiClusterIngress routing the requests of a host to
+// the port 80 of a Service
+func testMCI(name, host, service string) *ingress.MultiClusterIngress {
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     "/",
+										PathType: &pathTypePrefix,
+										Backend: networking.IngressBackend{
+											Service: &networking.IngressServiceBackend{
+												Name: service,
+												Port: networking.ServiceBackendPort{
+													Number: 80,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{},
+	}
+}
+
+func TestMergeTrafficSplitBackends(t *testing.T) {
+	nginx := newNGINXController(t)
+
+	mci := testMCI("split", "split.example.com", "app-v1")
+	mci.ParsedAnnotations.TrafficSplit = trafficsplit.Config{
+		WeightTotal: 100,
+		Branches: []trafficsplit.Branch{
+			{Service: "app-v2", Port: intstr.FromInt(80), Weight: 20},
+			{Service: "app-v3", Port: intstr.FromInt(80), Weight: 10, Header: "X-Version", HeaderValue: "v3"},
+		},
+	}
+	// another object splits the traffic of the backend to the same service
+	heavy := testMCI("heavy", "heavy.example.com", "app-v1")
+	heavy.ParsedAnnotations.TrafficSplit = trafficsplit.Config{
+		WeightTotal: 100,
+		Branches: []trafficsplit.Branch{
+			{Service: "app-v2", Port: intstr.FromInt(80), Weight: 50},
+		},
+	}
+	// another object shares the backend without traffic split
+	other := testMCI("other", "other.example.com", "app-v1")
+
+	upstreams, servers := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci, heavy, other})
+
+	backends := make(map[string]*ingress.Backend, len(upstreams))
+	for _, upstream := range upstreams {
+		backends[upstream.Name] = upstream
+	}
+
+	primary, ok := backends["default-app-v1-80"]
+	if !ok {
+		t.Fatalf("expected the primary backend default-app-v1-80 but got %v", backends)
+	}
+	if len(primary.AlternativeBackends) != 0 {
+		t.Errorf("expected the shared primary backend without alternative backends but got %v", primary.AlternativeBackends)
+	}
+
+	split, ok := backends["default-app-v1-80-split-split"]
+	if !ok {
+		t.Fatalf("expected the backend default-app-v1-80-split-split but got %v", backends)
+	}
+
+	expected := []string{"default-app-v2-80-branch-split", "default-app-v3-80-branch-split"}
+	if !reflect.DeepEqual(split.AlternativeBackends, expected) {
+		t.Errorf("expected alternative backends %v but got %v", expected, split.AlternativeBackends)
+	}
+
+	v3, ok := backends["default-app-v3-80-branch-split"]
+	if !ok || !v3.NoServer {
+		t.Fatalf("expected the backend default-app-v3-80-branch-split without server")
+	}
+
+	if v3.TrafficShapingPolicy.Weight != 10 || v3.TrafficShapingPolicy.HeaderValue != "v3" || v3.TrafficShapingPolicy.WeightTotal != 100 {
+		t.Errorf("unexpected traffic shaping policy %+v", v3.TrafficShapingPolicy)
+	}
+
+	expectedWeights := map[string]int{
+		"default-app-v2-80-branch-split": 20,
+		"default-app-v2-80-branch-heavy": 50,
+	}
+	for name, weight := range expectedWeights {
+		ups, ok := backends[name]
+		if !ok || !ups.NoServer {
+			t.Errorf("expected the backend %v without server", name)
+			continue
+		}
+		if ups.TrafficShapingPolicy.Weight != weight {
+			t.Errorf("expected the backend %v to receive the weight %v but got %v", name, weight, ups.TrafficShapingPolicy.Weight)
+		}
+	}
+
+	expectedBackends := map[string]string{
+		"split.example.com": "default-app-v1-80-split-split",
+		"heavy.example.com": "default-app-v1-80-split-heavy",
+		"other.example.com": "default-app-v1-80",
+	}
+	for _, server := range servers {
+		expectedBackend, ok := expectedBackends[server.Hostname]
+		if !ok {
+			continue
+		}
+		delete(expectedBackends, server.Hostname)
+		for _, loc := range server.Locations {
+			if loc.Backend != expectedBackend {
+				t.Errorf("expected location %v of server %v to use backend %v but got %v", loc.Path, server.Hostname, expectedBackend, loc.Backend)
+			}
+		}
+	}
+	if len(expectedBackends) != 0 {
+		t.Errorf("expected the servers %v", expectedBackends)
+	}
+}
+
+func TestMergeRouteMatchBackends(t *testing.T) {
+	nginx := newNGINXController(t)
+
+	mci := testMCI("match", "match.example.com", "app")
+	mci.ParsedAnnotations.RouteMatch = routematch.Config{
+		Rules: []routematch.Rule{
+			{Service: "api-v2", Port: intstr.FromInt(80), Header: "X-Api", HeaderValue: "v2"},
+			{Service: "uploads", Port: intstr.FromInt(80), Methods: []string{"POST"}, QueryParam: "upload"},
+			{Service: "app", Port: intstr.FromInt(80), Methods: []string{"GET"}},
+		},
+	}
+	// another object shares the backend without route matches
+	other := testMCI("other", "other.example.com", "app")
+
+	upstreams, servers := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci, other})
+
+	backends := make(map[string]*ingress.Backend, len(upstreams))
+	for _, upstream := range upstreams {
+		backends[upstream.Name] = upstream
+	}
+
+	primary, ok := backends["default-app-80"]
+	if !ok {
+		t.Fatalf("expected the primary backend default-app-80 but got %v", backends)
+	}
+	if len(primary.RouteMatches) != 0 {
+		t.Errorf("expected the shared primary backend without route matches but got %+v", primary.RouteMatches)
+	}
+
+	routes, ok := backends["default-app-80-routes-match"]
+	if !ok {
+		t.Fatalf("expected the backend default-app-80-routes-match but got %v", backends)
+	}
+
+	expected := []ingress.RouteMatch{
+		{Backend: "default-api-v2-80", Header: "X-Api", HeaderValue: "v2"},
+		{Backend: "default-uploads-80", Methods: []string{"POST"}, QueryParam: "upload"},
+	}
+	if !reflect.DeepEqual(routes.RouteMatches, expected) {
+		t.Errorf("expected route matches %+v but got %+v", expected, routes.RouteMatches)
+	}
+	if !reflect.DeepEqual(routes.Endpoints, primary.Endpoints) {
+		t.Errorf("expected the endpoints %+v of the primary backend but got %+v", primary.Endpoints, routes.Endpoints)
+	}
+
+	for _, name := range []string{"default-api-v2-80", "default-uploads-80"} {
+		if ups, ok := backends[name]; !ok || !ups.NoServer {
+			t.Errorf("expected the backend %v without server", name)
+		}
+	}
+
+	expectedBackends := map[string]string{
+		"match.example.com": "default-app-80-routes-match",
+		"other.example.com": "default-app-80",
+	}
+	for _, server := range servers {
+		expectedBackend, ok := expectedBackends[server.Hostname]
+		if !ok {
+			continue
+		}
+		delete(expectedBackends, server.Hostname)
+		for _, loc := range server.Locations {
+			if loc.Backend != expectedBackend {
+				t.Errorf("expected location %v of server %v to use backend %v but got %v", loc.Path, server.Hostname, expectedBackend, loc.Backend)
+			}
+		}
+	}
+	if len(expectedBackends) != 0 {
+		t.Errorf("expected the servers %v", expectedBackends)
+	}
+}
+
+func TestMergeMirrorBackends(t *testing.T) {
+	nginx := newNGINXController(t)
+
+	mci := testMCI("mirror", "mirror.example.com", "app")
+	mci.ParsedAnnotations.Mirror = mirror.Config{Source: "/_mirror-uid", RequestBody: "on", Backend: "shadow:80", Sample: 10}
+
+	upstreams, servers := nginx.getBackendServersFromMCIs([]*ingress.MultiClusterIngress{mci})
+
+	backends := make(map[string]*ingress.Backend, len(upstreams))
+	for _, upstream := range upstreams {
+		backends[upstream.Name] = upstream
+	}
+
+	if ups, ok := backends["default-shadow-80"]; !ok || !ups.NoServer {
+		t.Fatalf("expected the backend default-shadow-80 without server")
+	}
+
+	found := false
+	for _, server := range servers {
+		if server.Hostname != "mirror.example.com" {
+			continue
+		}
+		for _, loc := range server.Locations {
+			found = true
+			if loc.Mirror.Upstream != "default-shadow-80" {
+				t.Errorf("expected location %v to mirror to default-shadow-80 but got %q", loc.Path, loc.Mirror.Upstream)
+			}
+			if loc.Mirror.Source != "/_mirror-uid-default-shadow-80" {
+				t.Errorf("unexpected mirror source %q", loc.Mirror.Source)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected the locations of the server mirror.example.com")
+	}
+}
+
+func TestMirrorClusterUpstreams(t *testing.T) {
+	primary := &ingress.Backend{
+		Name: "default-app-80",
+		Port: intstr.FromInt(80),
+		Endpoints: []ingress.Endpoint{
+			{Address: "10.0.0.1", Port: "8080", Cluster: "member1"},
+			{Address: "10.1.0.1", Port: "8080", Cluster: "member2"},
+			{Address: "10.0.0.2", Port: "8080", Cluster: "member1"},
+		},
+	}
+	endpoints := append([]ingress.Endpoint{}, primary.Endpoints...)
+	upstreams := map[string]*ingress.Backend{primary.Name: primary}
+
+	name, mirrorName := mirrorClusterUpstreams(primary, "member2", upstreams)
+	if name != "default-app-80-except-member2" || mirrorName != "default-app-80-mirror-member2" {
+		t.Fatalf("unexpected upstreams %q and %q", name, mirrorName)
+	}
+
+	mirrorUps, ok := upstreams[mirrorName]
+	if !ok || !mirrorUps.NoServer {
+		t.Fatalf("expected the backend %v without server", mirrorName)
+	}
+
+	expected := []ingress.Endpoint{{Address: "10.1.0.1", Port: "8080", Cluster: "member2"}}
+	if !reflect.DeepEqual(mirrorUps.Endpoints, expected) {
+		t.Errorf("expected mirror endpoints %+v but got %+v", expected, mirrorUps.Endpoints)
+	}
+
+	ups, ok := upstreams[name]
+	if !ok || ups.NoServer {
+		t.Fatalf("expected the backend %v of the mirrored locations", name)
+	}
+
+	expected = []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080", Cluster: "member1"},
+		{Address: "10.0.0.2", Port: "8080", Cluster: "member1"},
+	}
+	if !reflect.DeepEqual(ups.Endpoints, expected) {
+		t.Errorf("expected endpoints %+v but got %+v", expected, ups.Endpoints)
+	}
+
+	// the backend is shared with the locations not mirrored
+	if !reflect.DeepEqual(primary.Endpoints, endpoints) {
+		t.Errorf("expected the endpoints of the backend to be unchanged but got %+v", primary.Endpoints)
+	}
+
+	// a second location mirroring the same backend reuses the upstreams
+	if again, mirrorAgain := mirrorClusterUpstreams(primary, "member2", upstreams); again != name || mirrorAgain != mirrorName ||
+		upstreams[name] != ups || upstreams[mirrorName] != mirrorUps {
+		t.Errorf("expected the upstreams %v and %v to be reused", name, mirrorName)
+	}
+}
+
+// ingressClassTestStore returns the parameters of a single IngressClass
+type ingressClassTestStore struct {
+	fakeIngressStore
+	class  string
+	params *ingressclass.Parameters
+	certs  map[string]*ingress.SSLCert
+}
+
+func (s ingressClassTestStore) GetIngressClassParameters(name string) (*ingressclass.Parameters, error) {
+	if name != s.class {
+		return nil, fmt.Errorf("IngressClass %v not found", name)
+	}
+	return s.params, nil
+}
+
+func (s ingressClassTestStore) GetLocalSSLCert(name string) (*ingress.SSLCert, error) {
+	if cert, ok := s.certs[name]; ok {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("secret %v not found", name)
+}
+
+func TestCreateServersFromMCIsWithIngressClassParameters(t *testing.T) {
+	enabled := true
+	classCert := &ingress.SSLCert{Certificate: fakeX509Cert([]string{"*.tenant-a.com"}), PemCertKey: "tenant-a"}
+
+	nginx := &NGINXController{
+		cfg: &Configuration{},
+		store: ingressClassTestStore{
+			fakeIngressStore: fakeIngressStore{configuration: ngx_config.Configuration{
+				HSTS:                  false,
+				HSTSMaxAge:            "15724800",
+				HSTSIncludeSubdomains: true,
+			}},
+			class: "tenant-a",
+			params: &ingressclass.Parameters{
+				DefaultSSLCertificate: "tenant-a/default-tls",
+				SSLProtocols:          "TLSv1.2 TLSv1.3",
+				SSLCiphers:            "ECDHE-RSA-AES128-GCM-SHA256",
+				HSTS:                  &enabled,
+			},
+			certs: map[string]*ingress.SSLCert{"tenant-a/default-tls": classCert},
+		},
+	}
+
+	className := "tenant-a"
+	tenant := testMCI("tenant", "app.tenant-a.com", "app")
+	tenant.Spec.IngressClassName = &className
+	tenant.Spec.TLS = []networking.IngressTLS{{Hosts: []string{"app.tenant-a.com"}, SecretName: "missing"}}
+
+	ciphers := testMCI("ciphers", "ciphers.tenant-a.com", "app")
+	ciphers.Spec.IngressClassName = &className
+	ciphers.ParsedAnnotations.SSLCipher.SSLCiphers = "HIGH"
+
+	other := testMCI("other", "app.example.com", "app")
+
+	servers := nginx.createServersFromMCIs([]*ingress.MultiClusterIngress{tenant, ciphers, other},
+		map[string]*ingress.Backend{}, &ingress.Backend{Name: defUpstreamName})
+
+	server := servers["app.tenant-a.com"]
+	if server.SSLCert != classCert {
+		t.Errorf("expected the default certificate of the IngressClass but got %v", server.SSLCert)
+	}
+	if server.SSLProtocols != "TLSv1.2 TLSv1.3" || server.SSLCiphers != "ECDHE-RSA-AES128-GCM-SHA256" {
+		t.Errorf("unexpected TLS policy, protocols %q and ciphers %q", server.SSLProtocols, server.SSLCiphers)
+	}
+	expectedHSTS := &ingress.HSTS{Enable: true, MaxAge: "15724800", IncludeSubdomains: true}
+	if !server.HSTS.Equal(expectedHSTS) {
+		t.Errorf("expected HSTS %+v but got %+v", expectedHSTS, server.HSTS)
+	}
+
+	if server := servers["ciphers.tenant-a.com"]; server.SSLCiphers != "HIGH" {
+		t.Errorf("expected the ciphers of the annotation but got %q", server.SSLCiphers)
+	}
+
+	server = servers["app.example.com"]
+	if server.SSLProtocols != "" || server.SSLCiphers != "" || server.HSTS != nil {
+		t.Errorf("expected no TLS policy for a MultiClusterIngress of another class but got %+v", server)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// drainingEndpoint is an endpoint removed from a backend, kept until the
+// end of the draining period
+type drainingEndpoint struct {
+	endpoint ingress.Endpoint
+	until    time.Time
+}
+
+// endpointDrainer keeps the endpoints removed from the backends in a
+// draining state: the balancers with cookie affinity keep sending them the
+// requests of their sessions, but no new session.
+type endpointDrainer struct {
+	mu sync.Mutex
+	// endpoints contains the endpoints of each backend of the last update
+	endpoints map[string]map[string]ingress.Endpoint
+	// draining contains the draining endpoints of each backend
+	draining map[string]map[string]drainingEndpoint
+}
+
+func newEndpointDrainer() *endpointDrainer {
+	return &endpointDrainer{
+		endpoints: map[string]map[string]ingress.Endpoint{},
+		draining:  map[string]map[string]drainingEndpoint{},
+	}
+}
+
+// update records the endpoints of the backends and returns, for each
+// backend, the endpoints removed less than period ago and not added back
+func (d *endpointDrainer) update(backends []*ingress.Backend, period time.Duration, now time.Time) map[string][]ingress.Endpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	endpoints := map[string]map[string]ingress.Endpoint{}
+	draining := map[string]map[string]drainingEndpoint{}
+	result := map[string][]ingress.Endpoint{}
+
+	for _, backend := range backends {
+		current := map[string]ingress.Endpoint{}
+		for _, ep := range backend.Endpoints {
+			current[net.JoinHostPort(ep.Address, ep.Port)] = ep
+		}
+		endpoints[backend.Name] = current
+
+		if period <= 0 {
+			continue
+		}
+
+		backendDraining := map[string]drainingEndpoint{}
+		for key, de := range d.draining[backend.Name] {
+			if _, ok := current[key]; ok || !now.Before(de.until) {
+				continue
+			}
+			backendDraining[key] = de
+		}
+		for key, ep := range d.endpoints[backend.Name] {
+			if _, ok := current[key]; ok {
+				continue
+			}
+			if _, ok := backendDraining[key]; ok {
+				continue
+			}
+			ep.Draining = true
+			backendDraining[key] = drainingEndpoint{endpoint: ep, until: now.Add(period)}
+		}
+		if len(backendDraining) == 0 {
+			continue
+		}
+
+		draining[backend.Name] = backendDraining
+		keys := make([]string, 0, len(backendDraining))
+		for key := range backendDraining {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result[backend.Name] = append(result[backend.Name], backendDraining[key].endpoint)
+		}
+	}
+
+	d.endpoints = endpoints
+	d.draining = draining
+
+	return result
+}
+
+// expired returns if the draining period of an endpoint is over
+func (d *endpointDrainer) expired(now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, backendDraining := range d.draining {
+		for _, de := range backendDraining {
+			if !now.Before(de.until) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Run checks the draining endpoints every second until the channel is
+// closed, calling onChange when the draining period of an endpoint is over
+func (d *endpointDrainer) Run(stopCh <-chan struct{}, onChange func()) {
+	wait.Until(func() {
+		if d.expired(time.Now()) {
+			onChange()
+		}
+	}, time.Second, stopCh)
+}
+
+// applyEndpointDraining adds the draining endpoints to the backends.
+func (n *NGINXController) applyEndpointDraining(backends []*ingress.Backend) {
+	if n.endpointDrainer == nil {
+		return
+	}
+
+	period := time.Duration(n.store.GetBackendConfiguration().UpstreamDrainingPeriod) * time.Second
+	draining := n.endpointDrainer.update(backends, period, time.Now())
+
+	for i, backend := range backends {
+		endpoints, ok := draining[backend.Name]
+		if !ok {
+			continue
+		}
+
+		all := make([]ingress.Endpoint, 0, len(backend.Endpoints)+len(endpoints))
+		all = append(all, backend.Endpoints...)
+		all = append(all, endpoints...)
+		backends[i] = withEndpoints(backend, all)
+	}
+}
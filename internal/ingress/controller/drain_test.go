@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+func TestEndpointDrainer(t *testing.T) {
+	ep1 := ingress.Endpoint{Address: "10.0.0.1", Port: "8080"}
+	ep2 := ingress.Endpoint{Address: "10.0.0.2", Port: "8080"}
+	draining2 := ep2
+	draining2.Draining = true
+
+	backend := func(endpoints ...ingress.Endpoint) []*ingress.Backend {
+		return []*ingress.Backend{{Name: "default-web-80", Endpoints: endpoints}}
+	}
+
+	d := newEndpointDrainer()
+	now := time.Now()
+	period := 30 * time.Second
+
+	if draining := d.update(backend(ep1, ep2), period, now); len(draining) != 0 {
+		t.Fatalf("expected no draining endpoint but got %+v", draining)
+	}
+
+	now = now.Add(time.Second)
+	draining := d.update(backend(ep1), period, now)
+	expected := map[string][]ingress.Endpoint{"default-web-80": {draining2}}
+	if !reflect.DeepEqual(draining, expected) {
+		t.Fatalf("expected draining endpoints %+v but got %+v", expected, draining)
+	}
+
+	now = now.Add(10 * time.Second)
+	if d.expired(now) {
+		t.Errorf("expected the draining period not to be over")
+	}
+	draining = d.update(backend(ep1), period, now)
+	if !reflect.DeepEqual(draining, expected) {
+		t.Errorf("expected the endpoint to keep draining but got %+v", draining)
+	}
+
+	now = now.Add(20 * time.Second)
+	if !d.expired(now) {
+		t.Errorf("expected the draining period to be over")
+	}
+	if draining = d.update(backend(ep1), period, now); len(draining) != 0 {
+		t.Errorf("expected no draining endpoint after the draining period but got %+v", draining)
+	}
+	if d.expired(now) {
+		t.Errorf("expected the expired endpoints to be forgotten")
+	}
+
+	// an endpoint added back stops draining
+	d.update(backend(ep1, ep2), period, now)
+	d.update(backend(ep1), period, now)
+	if draining = d.update(backend(ep1, ep2), period, now); len(draining) != 0 {
+		t.Errorf("expected no draining endpoint after the endpoint came back but got %+v", draining)
+	}
+
+	// a zero period disables draining
+	if draining = d.update(backend(ep1), 0, now); len(draining) != 0 {
+		t.Errorf("expected no draining endpoint without draining period but got %+v", draining)
+	}
+}
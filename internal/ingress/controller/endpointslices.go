@@ -23,15 +23,22 @@ import (
 	"strconv"
 	"strings"
 
+	workv1alpha1 "github.com/karmada-io/karmada/pkg/apis/work/v1alpha1"
+	"github.com/karmada-io/karmada/pkg/util/names"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/klog/v2"
 )
 
+// maxEndpointWeight bounds the weight of an endpoint, the Lua balancers
+// allocate memory in proportion to the weights
+const maxEndpointWeight = 100
+
 // getEndpointsByEps returns a slice of ingress.Endpoint for a given service/target port combination.
 func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto corev1.Protocol,
 	getServiceEndpointSlices func(string) ([]*discoveryv1.EndpointSlice, error)) []ingress.Endpoint {
@@ -79,6 +86,8 @@ func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto c
 	}
 
 	for _, endpointSlice := range endpointSlices {
+		weight := endpointSliceWeight(endpointSlice)
+		cluster := endpointSliceCluster(endpointSlice)
 		matchedPortNameFound := false
 		for index, epPort := range endpointSlice.Ports {
 			if !reflect.DeepEqual(*epPort.Protocol, proto) {
@@ -114,6 +123,16 @@ func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto c
 						Address: address,
 						Port:    fmt.Sprintf("%v", targetPort),
 						Target:  endpoint.TargetRef,
+						Weight:  weight,
+						Cluster: cluster,
+					}
+					if endpoint.Zone != nil {
+						upServer.Zone = *endpoint.Zone
+					}
+					if endpoint.Hints != nil {
+						for _, forZone := range endpoint.Hints.ForZones {
+							upServer.ForZones = append(upServer.ForZones, forZone.Name)
+						}
 					}
 					upsServers = append(upsServers, upServer)
 					processedUpstreamServers[epStr] = struct{}{}
@@ -125,3 +144,37 @@ func getEndpointsByEps(svc *corev1.Service, svcPort *corev1.ServicePort, proto c
 	klog.V(3).Infof("Endpoints found for Service %q: %+v", svcKey, upsServers)
 	return upsServers
 }
+
+// endpointSliceWeight returns the weight of the endpoints of an EndpointSlice
+// set with the endpoint-weight annotation, or zero when it is not set or
+// invalid
+func endpointSliceWeight(endpointSlice *discoveryv1.EndpointSlice) int {
+	val, ok := endpointSlice.Annotations[parser.GetAnnotationWithPrefix("endpoint-weight")]
+	if !ok {
+		return 0
+	}
+
+	weight, err := strconv.Atoi(val)
+	if err != nil || weight < 1 || weight > maxEndpointWeight {
+		klog.Warningf("Invalid endpoint weight %q of EndpointSlice %q, expected a number between 1 and %v", val, k8s.MetaNamespaceKey(endpointSlice), maxEndpointWeight)
+		return 0
+	}
+
+	return weight
+}
+
+// endpointSliceCluster returns the member cluster reporting an EndpointSlice
+// collected by Karmada, or an empty string when it is not collected
+func endpointSliceCluster(endpointSlice *discoveryv1.EndpointSlice) string {
+	executionSpace, ok := endpointSlice.Labels[workv1alpha1.WorkNamespaceLabel]
+	if !ok {
+		return ""
+	}
+
+	cluster, err := names.GetClusterName(executionSpace)
+	if err != nil {
+		return ""
+	}
+
+	return cluster
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	workv1alpha1 "github.com/karmada-io/karmada/pkg/apis/work/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+func newTestEndpointSlice(name string, annotations map[string]string, addresses ...string) *discoveryv1.EndpointSlice {
+	protocol := corev1.ProtocolTCP
+	port := int32(8080)
+	portName := ""
+
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   metav1.NamespaceDefault,
+			Annotations: annotations,
+		},
+		Ports: []discoveryv1.EndpointPort{{Name: &portName, Port: &port, Protocol: &protocol}},
+	}
+	for _, address := range addresses {
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{address}})
+	}
+
+	return endpointSlice
+}
+
+func TestGetEndpointsByEpsWeight(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: metav1.NamespaceDefault},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}
+
+	endpointSlices := []*discoveryv1.EndpointSlice{
+		newTestEndpointSlice("app-large", map[string]string{"nginx.ingress.kubernetes.io/endpoint-weight": "4"}, "10.0.0.1"),
+		newTestEndpointSlice("app-small", nil, "10.0.1.1"),
+		newTestEndpointSlice("app-invalid", map[string]string{"nginx.ingress.kubernetes.io/endpoint-weight": "1000"}, "10.0.2.1"),
+	}
+
+	endpoints := getEndpointsByEps(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP,
+		func(string) ([]*discoveryv1.EndpointSlice, error) {
+			return endpointSlices, nil
+		})
+
+	expected := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080", Weight: 4},
+		{Address: "10.0.1.1", Port: "8080"},
+		{Address: "10.0.2.1", Port: "8080"},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected endpoints %+v but got %+v", expected, endpoints)
+	}
+}
+
+func TestGetEndpointsByEpsTopology(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: metav1.NamespaceDefault},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}
+
+	zoneA, zoneB := "zone-a", "zone-b"
+	endpointSlice := newTestEndpointSlice("app", nil, "10.0.0.1", "10.0.1.1")
+	endpointSlice.Endpoints[0].Zone = &zoneA
+	endpointSlice.Endpoints[0].Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: zoneA}}}
+	endpointSlice.Endpoints[1].Zone = &zoneB
+
+	endpoints := getEndpointsByEps(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP,
+		func(string) ([]*discoveryv1.EndpointSlice, error) {
+			return []*discoveryv1.EndpointSlice{endpointSlice}, nil
+		})
+
+	expected := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080", Zone: zoneA, ForZones: []string{zoneA}},
+		{Address: "10.0.1.1", Port: "8080", Zone: zoneB},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected endpoints %+v but got %+v", expected, endpoints)
+	}
+}
+
+func TestGetEndpointsByEpsCluster(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: metav1.NamespaceDefault},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}
+
+	member1 := newTestEndpointSlice("app-member1", nil, "10.0.0.1")
+	member1.Labels = map[string]string{workv1alpha1.WorkNamespaceLabel: "karmada-es-member1"}
+	local := newTestEndpointSlice("app-local", nil, "10.0.1.1")
+
+	endpoints := getEndpointsByEps(svc, &svc.Spec.Ports[0], corev1.ProtocolTCP,
+		func(string) ([]*discoveryv1.EndpointSlice, error) {
+			return []*discoveryv1.EndpointSlice{member1, local}, nil
+		})
+
+	expected := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080", Cluster: "member1"},
+		{Address: "10.0.1.1", Port: "8080"},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Errorf("expected endpoints %+v but got %+v", expected, endpoints)
+	}
+}
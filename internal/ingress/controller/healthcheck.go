@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+)
+
+const (
+	endpointHealthy   = "healthy"
+	endpointUnhealthy = "unhealthy"
+
+	// maxConcurrentHealthChecks is the maximum number of endpoints probed at the same time
+	maxConcurrentHealthChecks = 32
+	// maxHealthCheckBodySize is the maximum size of a response read before
+	// closing the connection
+	maxHealthCheckBodySize = 4096
+)
+
+// healthTarget is an endpoint of a backend probed by the health checker
+type healthTarget struct {
+	backend  string
+	endpoint string
+	config   healthcheck.Config
+
+	healthy bool
+	// successes and failures are the number of consecutive successful and failed probes
+	successes int
+	failures  int
+	nextProbe time.Time
+}
+
+// healthChecker probes the endpoints of the backends with an active health
+// check and keeps the health of each of them. The endpoints are healthy until
+// they fail the number of consecutive probes of the unhealthy threshold.
+type healthChecker struct {
+	client *http.Client
+
+	metricCollector metric.Collector
+
+	mu sync.Mutex
+	// targets contains the probed endpoints indexed by backend and endpoint
+	targets map[string]*healthTarget
+}
+
+func newHealthChecker(mc metric.Collector) *healthChecker {
+	return &healthChecker{
+		client: &http.Client{
+			Transport: &http.Transport{
+				// each probe opens a new connection to test the network path
+				DisableKeepAlives: true,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		metricCollector: mc,
+		targets:         map[string]*healthTarget{},
+	}
+}
+
+func healthTargetKey(backend, endpoint string) string {
+	return fmt.Sprintf("%v/%v", backend, endpoint)
+}
+
+// update replaces the probed endpoints with the endpoints of the backends
+// with a health check. The state of the endpoints already probed is kept
+// unless the health check changed.
+func (h *healthChecker) update(backends []*ingress.Backend) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	keep := map[string]bool{}
+
+	for _, backend := range backends {
+		if backend.HealthCheck.Path == "" {
+			continue
+		}
+
+		for _, ep := range backend.Endpoints {
+			endpoint := net.JoinHostPort(ep.Address, ep.Port)
+			key := healthTargetKey(backend.Name, endpoint)
+			keep[key] = true
+
+			if target, ok := h.targets[key]; ok && target.config == backend.HealthCheck {
+				continue
+			}
+
+			h.targets[key] = &healthTarget{
+				backend:   backend.Name,
+				endpoint:  endpoint,
+				config:    backend.HealthCheck,
+				healthy:   true,
+				nextProbe: now,
+			}
+			h.metricCollector.SetUpstreamHealth(backend.Name, endpoint, true)
+		}
+	}
+
+	for key, target := range h.targets {
+		if !keep[key] {
+			delete(h.targets, key)
+			h.metricCollector.RemoveUpstreamHealth(target.backend, target.endpoint)
+		}
+	}
+}
+
+// health returns the health of an endpoint of a backend, or an empty
+// string when the endpoint is not probed
+func (h *healthChecker) health(backend string, ep ingress.Endpoint) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	target, ok := h.targets[healthTargetKey(backend, net.JoinHostPort(ep.Address, ep.Port))]
+	if !ok {
+		return ""
+	}
+	if target.healthy {
+		return endpointHealthy
+	}
+
+	return endpointUnhealthy
+}
+
+// probeDue probes the endpoints whose interval elapsed and returns if the
+// health of any of them changed
+func (h *healthChecker) probeDue(now time.Time) bool {
+	h.mu.Lock()
+	due := []*healthTarget{}
+	for _, target := range h.targets {
+		if !now.Before(target.nextProbe) {
+			target.nextProbe = now.Add(time.Duration(target.config.Interval) * time.Millisecond)
+			due = append(due, target)
+		}
+	}
+	h.mu.Unlock()
+
+	results := make([]bool, len(due))
+	sem := make(chan struct{}, maxConcurrentHealthChecks)
+	var wg sync.WaitGroup
+	for i, target := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, target *healthTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := h.probe(target.endpoint, target.config)
+			if err != nil {
+				klog.V(3).InfoS("Health check failed", "backend", target.backend, "endpoint", target.endpoint, "err", err)
+			}
+			results[i] = err == nil
+		}(i, target)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := false
+	for i, target := range due {
+		// the endpoint was removed or its health check changed during the probe
+		if h.targets[healthTargetKey(target.backend, target.endpoint)] != target {
+			continue
+		}
+
+		if h.record(target, results[i]) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// record counts the result of a probe and returns if the endpoint changed its health
+func (h *healthChecker) record(target *healthTarget, success bool) bool {
+	if success {
+		target.successes++
+		target.failures = 0
+	} else {
+		target.failures++
+		target.successes = 0
+	}
+
+	changed := false
+	switch {
+	case target.healthy && target.failures >= target.config.UnhealthyThreshold:
+		klog.InfoS("Endpoint is unhealthy", "backend", target.backend, "endpoint", target.endpoint)
+		target.healthy = false
+		changed = true
+	case !target.healthy && target.successes >= target.config.HealthyThreshold:
+		klog.InfoS("Endpoint is healthy", "backend", target.backend, "endpoint", target.endpoint)
+		target.healthy = true
+		changed = true
+	}
+
+	h.metricCollector.SetUpstreamHealth(target.backend, target.endpoint, target.healthy)
+	return changed
+}
+
+// probe sends the request of the health check to an endpoint
+func (h *healthChecker) probe(endpoint string, config healthcheck.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%v%v", endpoint, config.Path), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ingress-nginx-health-check")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// reading the beginning of the body waits for the end of small responses
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHealthCheckBodySize))
+
+	if !config.Matches(resp.StatusCode) {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Run probes the endpoints every second until the channel is closed,
+// calling onChange when the health of an endpoint changes
+func (h *healthChecker) Run(stopCh <-chan struct{}, onChange func()) {
+	wait.Until(func() {
+		if h.probeDue(time.Now()) {
+			onChange()
+		}
+	}, time.Second, stopCh)
+}
+
+// applyHealthChecks registers the endpoints of the backends with a health
+// check and sets their health.
+func (n *NGINXController) applyHealthChecks(backends []*ingress.Backend) {
+	if n.healthChecker == nil {
+		return
+	}
+
+	n.healthChecker.update(backends)
+
+	for i, backend := range backends {
+		if backend.HealthCheck.Path == "" {
+			continue
+		}
+
+		endpoints := make([]ingress.Endpoint, len(backend.Endpoints))
+		for j, ep := range backend.Endpoints {
+			ep.Health = n.healthChecker.health(backend.Name, ep)
+			endpoints[j] = ep
+		}
+		backends[i] = withEndpoints(backend, endpoints)
+	}
+}
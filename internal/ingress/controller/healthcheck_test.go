@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+)
+
+func TestHealthChecker(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	address, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	endpoint := ingress.Endpoint{Address: address, Port: port}
+
+	backend := &ingress.Backend{
+		Name:      "default-web-80",
+		Endpoints: []ingress.Endpoint{endpoint},
+		HealthCheck: healthcheck.Config{
+			Path:               "/healthz",
+			Interval:           1000,
+			Timeout:            1000,
+			HealthyThreshold:   2,
+			UnhealthyThreshold: 2,
+			ExpectedStatus:     "200-399",
+		},
+	}
+
+	h := newHealthChecker(metric.DummyCollector{})
+	h.update([]*ingress.Backend{backend, {Name: "default-other-80", Endpoints: []ingress.Endpoint{endpoint}}})
+
+	if health := h.health(backend.Name, endpoint); health != endpointHealthy {
+		t.Errorf("expected a new endpoint to be healthy but returned %q", health)
+	}
+	if health := h.health("default-other-80", endpoint); health != "" {
+		t.Errorf("expected no health for a backend without health check but returned %q", health)
+	}
+
+	now := time.Now()
+	probe := func() bool {
+		now = now.Add(time.Second)
+		return h.probeDue(now)
+	}
+
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+
+	if probe() {
+		t.Errorf("expected no change before the unhealthy threshold")
+	}
+	if !probe() {
+		t.Errorf("expected a change after the unhealthy threshold")
+	}
+	if health := h.health(backend.Name, endpoint); health != endpointUnhealthy {
+		t.Errorf("expected the endpoint to be unhealthy but returned %q", health)
+	}
+
+	if h.probeDue(now) {
+		t.Errorf("expected no probe before the interval")
+	}
+
+	mu.Lock()
+	status = http.StatusOK
+	mu.Unlock()
+
+	if probe() {
+		t.Errorf("expected no change before the healthy threshold")
+	}
+	if !probe() {
+		t.Errorf("expected a change after the healthy threshold")
+	}
+	if health := h.health(backend.Name, endpoint); health != endpointHealthy {
+		t.Errorf("expected the endpoint to be healthy but returned %q", health)
+	}
+
+	h.update([]*ingress.Backend{})
+	if health := h.health(backend.Name, endpoint); health != "" {
+		t.Errorf("expected no health for a removed endpoint but returned %q", health)
+	}
+}
+
+func TestHealthCheckerUnreachableEndpoint(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	address, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	endpoint := ingress.Endpoint{Address: address, Port: port}
+	backend := &ingress.Backend{
+		Name:      "default-web-80",
+		Endpoints: []ingress.Endpoint{endpoint},
+		HealthCheck: healthcheck.Config{
+			Path:               "/healthz",
+			Interval:           1000,
+			Timeout:            1000,
+			HealthyThreshold:   1,
+			UnhealthyThreshold: 1,
+			ExpectedStatus:     "200",
+		},
+	}
+
+	h := newHealthChecker(metric.DummyCollector{})
+	h.update([]*ingress.Backend{backend})
+
+	if !h.probeDue(time.Now()) {
+		t.Errorf("expected a change when the endpoint is unreachable")
+	}
+	if health := h.health(backend.Name, endpoint); health != endpointUnhealthy {
+		t.Errorf("expected the endpoint to be unhealthy but returned %q", health)
+	}
+}
+
+func TestApplyHealthChecks(t *testing.T) {
+	healthy := ingress.Endpoint{Address: "10.0.0.1", Port: "8080"}
+	unhealthy := ingress.Endpoint{Address: "10.0.0.2", Port: "8080"}
+
+	backend := &ingress.Backend{
+		Name:        "default-web-80",
+		Endpoints:   []ingress.Endpoint{healthy, unhealthy},
+		HealthCheck: healthcheck.Config{Path: "/healthz", Interval: 1000, UnhealthyThreshold: 1},
+	}
+	running := []*ingress.Backend{backend}
+	backends := []*ingress.Backend{backend}
+
+	n := &NGINXController{healthChecker: newHealthChecker(metric.DummyCollector{})}
+	n.healthChecker.update(backends)
+
+	target := n.healthChecker.targets[healthTargetKey(backend.Name, "10.0.0.2:8080")]
+	n.healthChecker.record(target, false)
+
+	n.applyHealthChecks(backends)
+
+	if backends[0] == backend {
+		t.Fatalf("expected a copy of the backend")
+	}
+	if backend.Endpoints[1].Health != "" {
+		t.Errorf("expected the shared backend to be unchanged")
+	}
+	if health := backends[0].Endpoints[0].Health; health != endpointHealthy {
+		t.Errorf("expected the first endpoint to be healthy but returned %q", health)
+	}
+	if health := backends[0].Endpoints[1].Health; health != endpointUnhealthy {
+		t.Errorf("expected the second endpoint to be unhealthy but returned %q", health)
+	}
+	if backends[0].Equal(running[0]) {
+		t.Errorf("expected the health change to be detected as a backend change")
+	}
+}
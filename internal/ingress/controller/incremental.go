@@ -0,0 +1,580 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/karmada-io/karmada/pkg/util/names"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+const (
+	// configBuildFull is a build of the configuration from every MultiClusterIngress
+	configBuildFull = "full"
+	// configBuildIncremental is a build that only updates the objects affected by a change
+	configBuildIncremental = "incremental"
+)
+
+// configChanges describes the objects changed since the last configuration build
+type configChanges struct {
+	// full indicates a change that can affect any part of the configuration
+	full bool
+	// mcis contains the keys of the changed MultiClusterIngresses
+	mcis sets.String
+	// services contains the keys of the Services whose endpoints changed
+	services sets.String
+}
+
+func newConfigChanges() configChanges {
+	return configChanges{
+		mcis:     sets.NewString(),
+		services: sets.NewString(),
+	}
+}
+
+func (c *configChanges) add(evt store.Event) {
+	if len(evt.MultiClusterIngresses) == 0 && len(evt.Services) == 0 {
+		c.full = true
+		return
+	}
+
+	c.mcis.Insert(evt.MultiClusterIngresses...)
+	c.services.Insert(evt.Services...)
+}
+
+// serviceBackend describes how a backend obtains the endpoints of a Service
+type serviceBackend struct {
+	service string
+	port    string
+	// clusterIP indicates the backend uses the ClusterIP of the Service
+	clusterIP bool
+}
+
+// configCache contains the backends and servers of the last configuration built
+// from the MultiClusterIngresses, before the locations are updated, and the
+// indexes required to rebuild only the parts affected by a change.
+type configCache struct {
+	// built is the time of the last full build
+	built time.Time
+
+	backends []*ingress.Backend
+	servers  map[string]*ingress.Server
+
+	// mciHosts contains the hostnames of the servers configured by each MultiClusterIngress
+	mciHosts map[string]sets.String
+	// backendOwners contains the MultiClusterIngresses that reference each backend
+	backendOwners map[string]sets.String
+	// backendServices contains the Service used by each backend
+	backendServices map[string]serviceBackend
+	// defaultBackendServices contains the Services used as custom default backends
+	defaultBackendServices sets.String
+	// unpatchable contains the MultiClusterIngresses that can only change with a full build
+	unpatchable sets.String
+}
+
+func newConfigCache(mcis []*ingress.MultiClusterIngress, backends []*ingress.Backend, servers []*ingress.Server) *configCache {
+	c := &configCache{
+		built:                  time.Now(),
+		backends:               backends,
+		servers:                make(map[string]*ingress.Server, len(servers)),
+		mciHosts:               make(map[string]sets.String, len(mcis)),
+		backendOwners:          make(map[string]sets.String),
+		backendServices:        make(map[string]serviceBackend),
+		defaultBackendServices: sets.NewString(),
+		unpatchable:            sets.NewString(),
+	}
+
+	for _, server := range servers {
+		c.servers[server.Hostname] = server
+
+		for _, loc := range server.Locations {
+			if loc.DefaultBackend != nil {
+				c.defaultBackendServices.Insert(k8s.MetaNamespaceKey(loc.DefaultBackend))
+			}
+		}
+	}
+
+	for _, mci := range mcis {
+		c.index(mci)
+	}
+
+	return c
+}
+
+// index adds the hostnames and backends of a MultiClusterIngress, following
+// the same precedence used by createUpstreamsFromMCIs
+func (c *configCache) index(mci *ingress.MultiClusterIngress) {
+	key := k8s.MetaNamespaceKey(mci)
+	c.mciHosts[key] = mciHostnames(mci)
+
+	if err := patchable(mci); err != nil {
+		c.unpatchable.Insert(key)
+	} else {
+		c.unpatchable.Delete(key)
+	}
+
+	addOwner := func(name string) {
+		if _, ok := c.backendOwners[name]; !ok {
+			c.backendOwners[name] = sets.NewString()
+		}
+		c.backendOwners[name].Insert(key)
+	}
+
+	clusterIP := mci.ParsedAnnotations != nil && mci.ParsedAnnotations.ServiceUpstream
+
+	if mci.Spec.DefaultBackend != nil && mci.Spec.DefaultBackend.Service != nil {
+		name := upstreamName(mci.Namespace, mci.Spec.DefaultBackend.Service)
+		_, port := upstreamServiceNameAndPort(mci.Spec.DefaultBackend.Service)
+		addOwner(name)
+		c.backendServices[name] = serviceBackend{
+			service:   fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(mci.Spec.DefaultBackend.Service.Name)),
+			port:      port.String(),
+			clusterIP: clusterIP,
+		}
+	}
+
+	addBackend := func(svc *networking.IngressServiceBackend) {
+		name := upstreamName(mci.Namespace, svc)
+		addOwner(name)
+
+		if _, ok := c.backendServices[name]; ok {
+			return
+		}
+
+		svcName, port := upstreamServiceNameAndPort(svc)
+		c.backendServices[name] = serviceBackend{
+			service:   fmt.Sprintf("%v/%v", mci.Namespace, names.GenerateDerivedServiceName(svcName)),
+			port:      port.String(),
+			clusterIP: clusterIP,
+		}
+	}
+
+	for _, rule := range mci.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			addBackend(path.Backend.Service)
+		}
+	}
+
+	// the upstreams without server of the annotations also use a Service
+	for _, svc := range annotationServices(mci) {
+		addBackend(svc)
+	}
+}
+
+// annotationServices returns the Services of the upstreams without server
+// created for the annotations of a MultiClusterIngress
+func annotationServices(mci *ingress.MultiClusterIngress) []*networking.IngressServiceBackend {
+	anns := mci.ParsedAnnotations
+	if anns == nil {
+		return nil
+	}
+
+	var services []*networking.IngressServiceBackend
+	for _, branch := range anns.TrafficSplit.Branches {
+		services = append(services, branch.ServiceBackend())
+	}
+	for _, rule := range anns.RouteMatch.Rules {
+		services = append(services, rule.ServiceBackend())
+	}
+	if anns.Mirror.Backend != "" {
+		if svc := anns.Mirror.ServiceBackend(); svc != nil {
+			services = append(services, svc)
+		}
+	}
+
+	return services
+}
+
+// clone returns a copy of the cache that can be updated without
+// modifying the backends and servers of the current one
+func (c *configCache) clone() *configCache {
+	nc := &configCache{
+		built:                  c.built,
+		backends:               make([]*ingress.Backend, len(c.backends)),
+		servers:                make(map[string]*ingress.Server, len(c.servers)),
+		mciHosts:               make(map[string]sets.String, len(c.mciHosts)),
+		backendOwners:          make(map[string]sets.String, len(c.backendOwners)),
+		backendServices:        make(map[string]serviceBackend, len(c.backendServices)),
+		defaultBackendServices: c.defaultBackendServices,
+		unpatchable:            sets.NewString(c.unpatchable.UnsortedList()...),
+	}
+
+	copy(nc.backends, c.backends)
+	for k, v := range c.servers {
+		nc.servers[k] = v
+	}
+	for k, v := range c.mciHosts {
+		nc.mciHosts[k] = v
+	}
+	for k, v := range c.backendOwners {
+		nc.backendOwners[k] = sets.NewString(v.UnsortedList()...)
+	}
+	for k, v := range c.backendServices {
+		nc.backendServices[k] = v
+	}
+
+	return nc
+}
+
+// configuration returns the backends and a copy of the servers, so
+// updating the locations does not modify the cached servers
+func (c *configCache) configuration() ([]*ingress.Backend, []*ingress.Server) {
+	backends := make([]*ingress.Backend, len(c.backends))
+	copy(backends, c.backends)
+
+	servers := make([]*ingress.Server, 0, len(c.servers))
+	for _, server := range c.servers {
+		s := *server
+		s.Locations = make([]*ingress.Location, 0, len(server.Locations))
+		for _, loc := range server.Locations {
+			l := *loc
+			s.Locations = append(s.Locations, &l)
+		}
+		servers = append(servers, &s)
+	}
+
+	sort.SliceStable(servers, func(i, j int) bool {
+		return servers[i].Hostname < servers[j].Hostname
+	})
+
+	return backends, servers
+}
+
+// mciHostnames returns the hostnames of the servers configured by a MultiClusterIngress
+func mciHostnames(mci *ingress.MultiClusterIngress) sets.String {
+	hosts := sets.NewString()
+
+	if mci.Spec.DefaultBackend != nil {
+		hosts.Insert(defServerName)
+	}
+
+	for _, rule := range mci.Spec.Rules {
+		host := rule.Host
+		if host == "" {
+			host = defServerName
+		}
+		hosts.Insert(host)
+	}
+
+	return hosts
+}
+
+// patchable returns an error if the configuration of a MultiClusterIngress
+// depends on other servers or backends, so it requires a full build
+func patchable(mci *ingress.MultiClusterIngress) error {
+	key := k8s.MetaNamespaceKey(mci)
+	anns := mci.ParsedAnnotations
+
+	switch {
+	case anns == nil:
+		return fmt.Errorf("multiclusteringress %q has no parsed annotations", key)
+	case anns.Canary.Enabled:
+		return fmt.Errorf("multiclusteringress %q is a canary", key)
+	case anns.SSLPassthrough:
+		return fmt.Errorf("multiclusteringress %q uses SSL passthrough", key)
+	case len(anns.Aliases) > 0:
+		return fmt.Errorf("multiclusteringress %q defines server aliases", key)
+	case anns.DefaultBackend != nil:
+		return fmt.Errorf("multiclusteringress %q defines a custom default backend", key)
+	case len(anns.TrafficSplit.Branches) > 0:
+		return fmt.Errorf("multiclusteringress %q defines a traffic split", key)
+	case len(anns.RouteMatch.Rules) > 0:
+		return fmt.Errorf("multiclusteringress %q defines route matches", key)
+	case anns.Mirror.Cluster != "" || anns.Mirror.Backend != "":
+		return fmt.Errorf("multiclusteringress %q mirrors requests to another backend", key)
+	}
+
+	return nil
+}
+
+// buildConfiguration returns the configuration matching the MultiClusterIngresses.
+// When the incremental build is enabled, only the servers and backends affected
+// by the changes are rebuilt, falling back to a full build when required.
+func (n *NGINXController) buildConfiguration(mcis []*ingress.MultiClusterIngress, changes configChanges) (sets.String, []*ingress.Server, *ingress.Configuration) {
+	if !n.cfg.EnableIncrementalConfig {
+		return n.getConfigurationFromMCI(mcis)
+	}
+
+	start := time.Now()
+	mode := configBuildIncremental
+
+	cache, ok := n.patchConfiguration(mcis, changes)
+	if !ok {
+		mode = configBuildFull
+		upstreams, servers := n.getBackendServersFromMCIs(mcis)
+		cache = newConfigCache(mcis, upstreams, servers)
+	}
+
+	n.configCache = cache
+
+	upstreams, servers := cache.configuration()
+	hosts, servers, pcfg := n.completeConfigurationFromMCI(mcis, upstreams, servers)
+
+	klog.V(3).InfoS("Configuration built", "mode", mode, "duration", time.Since(start))
+	n.metricCollector.ObserveConfigBuild(mode, time.Since(start).Seconds())
+
+	return hosts, servers, pcfg
+}
+
+// patchConfiguration returns a copy of the cached configuration updated with the changes,
+// or false when the changes require a full build
+func (n *NGINXController) patchConfiguration(mcis []*ingress.MultiClusterIngress, changes configChanges) (*configCache, bool) {
+	if n.configCache == nil || changes.full {
+		return nil, false
+	}
+
+	if changes.mcis.Len() == 0 && changes.services.Len() == 0 {
+		return nil, false
+	}
+
+	if n.cfg.FullConfigRebuildInterval > 0 && time.Since(n.configCache.built) > n.cfg.FullConfigRebuildInterval {
+		klog.V(3).InfoS("Periodic full build of the configuration")
+		return nil, false
+	}
+
+	c := n.configCache.clone()
+
+	if changes.mcis.Len() > 0 {
+		if err := n.patchMCIs(c, mcis, changes.mcis); err != nil {
+			klog.V(3).InfoS("Incremental build not possible", "reason", err)
+			return nil, false
+		}
+	}
+
+	if changes.services.Len() > 0 {
+		if err := n.patchServices(c, changes.services); err != nil {
+			klog.V(3).InfoS("Incremental build not possible", "reason", err)
+			return nil, false
+		}
+	}
+
+	return c, true
+}
+
+// patchMCIs rebuilds the servers of the hostnames configured by the changed
+// MultiClusterIngresses, and the backends they reference.
+func (n *NGINXController) patchMCIs(c *configCache, mcis []*ingress.MultiClusterIngress, changed sets.String) error {
+	current := make(map[string]*ingress.MultiClusterIngress, len(mcis))
+	for _, mci := range mcis {
+		current[k8s.MetaNamespaceKey(mci)] = mci
+	}
+
+	affected := sets.NewString()
+	for key := range changed {
+		if hosts, ok := c.mciHosts[key]; ok {
+			affected = affected.Union(hosts)
+		}
+		if mci, ok := current[key]; ok {
+			affected = affected.Union(mciHostnames(mci))
+		}
+	}
+
+	// servers are shared by every MultiClusterIngress with the same hostname
+	closure := sets.NewString()
+	var rebuild []*ingress.MultiClusterIngress
+	for {
+		grown := false
+		for _, mci := range mcis {
+			key := k8s.MetaNamespaceKey(mci)
+			if closure.Has(key) {
+				continue
+			}
+
+			hosts := mciHostnames(mci)
+			if !changed.Has(key) && !hosts.HasAny(affected.UnsortedList()...) {
+				continue
+			}
+
+			closure.Insert(key)
+			affected = affected.Union(hosts)
+			grown = true
+		}
+
+		if !grown {
+			break
+		}
+	}
+
+	if affected.Has(defServerName) {
+		return fmt.Errorf("the catch-all server is affected")
+	}
+
+	for _, server := range c.servers {
+		if affected.Has(server.Hostname) {
+			continue
+		}
+		if affected.HasAny(server.Aliases...) {
+			return fmt.Errorf("server %q uses an affected hostname as alias", server.Hostname)
+		}
+	}
+
+	// deleted objects are part of the change but not of the rebuild
+	removed := closure.Union(changed)
+
+	if keys := removed.Intersection(c.unpatchable); keys.Len() > 0 {
+		return fmt.Errorf("multiclusteringresses %v require a full build", keys.List())
+	}
+
+	for _, mci := range mcis {
+		if !closure.Has(k8s.MetaNamespaceKey(mci)) {
+			continue
+		}
+
+		if err := patchable(mci); err != nil {
+			return err
+		}
+
+		rebuild = append(rebuild, mci)
+	}
+
+	upstreams, servers := n.getBackendServersFromMCIs(rebuild)
+
+	rebuilt := sets.NewString()
+	for _, upstream := range upstreams {
+		if upstream.Name == defUpstreamName {
+			continue
+		}
+
+		if owners, ok := c.backendOwners[upstream.Name]; ok && owners.Difference(removed).Len() > 0 {
+			return fmt.Errorf("backend %q is shared with other multiclusteringresses", upstream.Name)
+		}
+
+		rebuilt.Insert(upstream.Name)
+	}
+
+	// remove the references of the rebuilt objects from the indexes
+	obsolete := sets.NewString()
+	for name, owners := range c.backendOwners {
+		if !owners.HasAny(removed.UnsortedList()...) {
+			continue
+		}
+
+		owners.Delete(removed.UnsortedList()...)
+		if owners.Len() == 0 {
+			obsolete.Insert(name)
+			delete(c.backendOwners, name)
+			delete(c.backendServices, name)
+		}
+	}
+
+	for key := range removed {
+		delete(c.mciHosts, key)
+		c.unpatchable.Delete(key)
+	}
+
+	for _, mci := range rebuild {
+		c.index(mci)
+	}
+
+	backends := make([]*ingress.Backend, 0, len(c.backends)+len(upstreams))
+	for _, backend := range c.backends {
+		if obsolete.Has(backend.Name) || rebuilt.Has(backend.Name) {
+			continue
+		}
+		backends = append(backends, backend)
+	}
+
+	for _, upstream := range upstreams {
+		if rebuilt.Has(upstream.Name) {
+			backends = append(backends, upstream)
+		}
+	}
+
+	sort.SliceStable(backends, func(a, b int) bool {
+		return backends[a].Name < backends[b].Name
+	})
+	c.backends = backends
+
+	for host := range affected {
+		delete(c.servers, host)
+	}
+
+	for _, server := range servers {
+		if server.Hostname == defServerName {
+			continue
+		}
+		c.servers[server.Hostname] = server
+	}
+
+	return nil
+}
+
+// patchServices updates the endpoints of the backends that use the changed Services
+func (n *NGINXController) patchServices(c *configCache, services sets.String) error {
+	for svcKey := range services {
+		if svcKey == n.cfg.DefaultService {
+			return fmt.Errorf("service %q is the default backend", svcKey)
+		}
+
+		if c.defaultBackendServices.Has(svcKey) {
+			return fmt.Errorf("service %q is used as a custom default backend", svcKey)
+		}
+	}
+
+	// the backends of the services can be copied into the upstreams of the
+	// annotations, like the ones of the traffic splits or of the locations
+	// mirrored to a member cluster, which are only created by a full build
+	for name, sb := range c.backendServices {
+		if sb.clusterIP || !services.Has(sb.service) {
+			continue
+		}
+
+		if owners := c.backendOwners[name].Intersection(c.unpatchable); owners.Len() > 0 {
+			return fmt.Errorf("backend %q is used by multiclusteringresses %v requiring a full build", name, owners.List())
+		}
+	}
+
+	for i, backend := range c.backends {
+		sb, ok := c.backendServices[backend.Name]
+		if !ok || sb.clusterIP || !services.Has(sb.service) {
+			continue
+		}
+
+		endps, err := n.serviceEndpoints(sb.service, sb.port)
+		if err != nil {
+			return fmt.Errorf("obtaining endpoints for service %q: %w", sb.service, err)
+		}
+
+		// locations switch to the custom default backend without endpoints
+		if (len(backend.Endpoints) == 0) != (len(endps) == 0) {
+			return fmt.Errorf("backend %q changed the availability of endpoints", backend.Name)
+		}
+
+		nb := backend.DeepCopy()
+		nb.Endpoints = endps
+		c.backends[i] = nb
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/routematch"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/trafficsplit"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+)
+
+func newIncrementalMCI(name, host, service string) *ingress.MultiClusterIngress {
+	pathType := networking.PathTypePrefix
+
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     "/",
+										PathType: &pathType,
+										Backend: networking.IngressBackend{
+											Service: &networking.IngressServiceBackend{
+												Name: service,
+												Port: networking.ServiceBackendPort{Number: 80},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{},
+	}
+}
+
+func TestBuildConfigurationIncremental(t *testing.T) {
+	canaryMCI := newIncrementalMCI("canary", "b.example.com", "canary")
+	canaryMCI.ParsedAnnotations.Canary = canary.Config{Enabled: true}
+
+	testCases := map[string]struct {
+		before      []*ingress.MultiClusterIngress
+		after       []*ingress.MultiClusterIngress
+		changed     []string
+		incremental bool
+	}{
+		"new object with a distinct hostname": {
+			before:      []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a")},
+			after:       []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b")},
+			changed:     []string{"default/b"},
+			incremental: true,
+		},
+		"updated object with a distinct hostname": {
+			before:      []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b")},
+			after:       []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "c.example.com", "c")},
+			changed:     []string{"default/b"},
+			incremental: true,
+		},
+		"deleted object": {
+			before:      []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b")},
+			after:       []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a")},
+			changed:     []string{"default/b"},
+			incremental: true,
+		},
+		"catch-all object": {
+			before:  []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a")},
+			after:   []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "", "b")},
+			changed: []string{"default/b"},
+		},
+		"canary object": {
+			before:  []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b")},
+			after:   []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b"), canaryMCI},
+			changed: []string{"default/canary"},
+		},
+		"backend shared with an unchanged object": {
+			before:  []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "b")},
+			after:   []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a"), newIncrementalMCI("b", "b.example.com", "a")},
+			changed: []string{"default/b"},
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.cfg.EnableIncrementalConfig = true
+			nginx.cfg.FullConfigRebuildInterval = time.Hour
+
+			nginx.buildConfiguration(tc.before, configChanges{full: true})
+			first := nginx.configCache
+
+			changes := newConfigChanges()
+			changes.mcis = sets.NewString(tc.changed...)
+
+			_, _, pcfg := nginx.buildConfiguration(tc.after, changes)
+			if incremental := nginx.configCache.built == first.built; incremental != tc.incremental {
+				t.Errorf("expected an incremental build %v but got %v", tc.incremental, incremental)
+			}
+
+			_, _, expected := nginx.getConfigurationFromMCI(tc.after)
+			if !pcfg.Equal(expected) {
+				t.Errorf("the configuration built does not match a full build")
+			}
+		})
+	}
+}
+
+func TestBuildConfigurationPeriodicFullBuild(t *testing.T) {
+	nginx := newNGINXController(t)
+	nginx.metricCollector = metric.DummyCollector{}
+	nginx.cfg.EnableIncrementalConfig = true
+	nginx.cfg.FullConfigRebuildInterval = time.Nanosecond
+
+	mcis := []*ingress.MultiClusterIngress{newIncrementalMCI("a", "a.example.com", "a")}
+	nginx.buildConfiguration(mcis, configChanges{full: true})
+	first := nginx.configCache
+
+	time.Sleep(time.Millisecond)
+
+	changes := newConfigChanges()
+	changes.mcis.Insert("default/a")
+	nginx.buildConfiguration(mcis, changes)
+
+	if nginx.configCache.built == first.built {
+		t.Errorf("expected a full build after the rebuild interval")
+	}
+}
+
+// serviceTestStore returns a Service with the port 80 for any key
+type serviceTestStore struct {
+	store.Storer
+}
+
+func (serviceTestStore) GetService(key string) (*corev1.Service, error) {
+	namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeClusterIP,
+			ClusterIP: "10.96.0.10",
+			Ports:     []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}, nil
+}
+
+func (serviceTestStore) GetServiceEndpointSlices(key string) ([]*discoveryv1.EndpointSlice, error) {
+	return nil, nil
+}
+
+func TestBuildConfigurationServiceChange(t *testing.T) {
+	mirrorMCI := newIncrementalMCI("a", "a.example.com", "a")
+	mirrorMCI.ParsedAnnotations.Mirror = mirror.Config{Source: "/_mirror-uid", Cluster: "member2"}
+
+	splitMCI := newIncrementalMCI("a", "a.example.com", "b")
+	splitMCI.ParsedAnnotations.TrafficSplit = trafficsplit.Config{
+		Branches:    []trafficsplit.Branch{{Service: "a", Port: intstr.FromInt(80), Weight: 10}},
+		WeightTotal: 100,
+	}
+
+	matchMCI := newIncrementalMCI("a", "a.example.com", "b")
+	matchMCI.ParsedAnnotations.RouteMatch = routematch.Config{
+		Rules: []routematch.Rule{{Service: "a", Port: intstr.FromInt(80), Header: "X-Canary", HeaderValue: "always"}},
+	}
+
+	mirrorBackendMCI := newIncrementalMCI("a", "a.example.com", "b")
+	mirrorBackendMCI.ParsedAnnotations.Mirror = mirror.Config{Source: "/_mirror-uid", Backend: "a:80"}
+
+	testCases := map[string]struct {
+		mci         *ingress.MultiClusterIngress
+		incremental bool
+	}{
+		"backend of a path": {
+			mci:         newIncrementalMCI("a", "a.example.com", "a"),
+			incremental: true,
+		},
+		"backend mirrored to a member cluster": {
+			mci: mirrorMCI,
+		},
+		"branch of a traffic split": {
+			mci: splitMCI,
+		},
+		"backend of a route match": {
+			mci: matchMCI,
+		},
+		"backend receiving mirrored requests": {
+			mci: mirrorBackendMCI,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			nginx := newNGINXController(t)
+			nginx.store = serviceTestStore{Storer: nginx.store}
+			nginx.metricCollector = metric.DummyCollector{}
+			nginx.cfg.EnableIncrementalConfig = true
+			nginx.cfg.FullConfigRebuildInterval = time.Hour
+
+			mcis := []*ingress.MultiClusterIngress{tc.mci}
+			nginx.buildConfiguration(mcis, configChanges{full: true})
+			first := nginx.configCache
+
+			changes := newConfigChanges()
+			changes.services.Insert("default/derived-a")
+
+			nginx.buildConfiguration(mcis, changes)
+			if incremental := nginx.configCache.built == first.built; incremental != tc.incremental {
+				t.Errorf("expected an incremental build %v but got %v", tc.incremental, incremental)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingressclass
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+)
+
+const (
+	// ParametersKind is the kind of the object referenced by the parameters
+	// of an IngressClass
+	ParametersKind = "ConfigMap"
+
+	defaultSSLCertificateKey = "default-ssl-certificate"
+	sslMinVersionKey         = "ssl-min-version"
+	sslCiphersKey            = "ssl-ciphers"
+	hstsKey                  = "hsts"
+	hstsMaxAgeKey            = "hsts-max-age"
+	hstsIncludeSubdomainsKey = "hsts-include-subdomains"
+	hstsPreloadKey           = "hsts-preload"
+)
+
+// sslVersions are the TLS versions supported by NGINX, in ascending order
+var sslVersions = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+// Parameters defines the TLS baseline of the servers of the
+// MultiClusterIngresses of an IngressClass. Fields left empty keep the
+// values of the global configuration.
+type Parameters struct {
+	// DefaultSSLCertificate is the Secret (namespace/name) served to the
+	// hosts of the class without a valid certificate
+	DefaultSSLCertificate string
+	// SSLProtocols contains the protocols enabled from the minimum TLS version
+	SSLProtocols string
+	// SSLCiphers contains the ciphers enabled
+	SSLCiphers string
+
+	HSTS                  *bool
+	HSTSMaxAge            string
+	HSTSIncludeSubdomains *bool
+	HSTSPreload           *bool
+}
+
+// ParametersConfigMap returns the key (namespace/name) of the ConfigMap
+// referenced by the parameters of an IngressClass, or an empty string when
+// the IngressClass has no parameters
+func ParametersConfigMap(ic *networking.IngressClass) (string, error) {
+	params := ic.Spec.Parameters
+	if params == nil {
+		return "", nil
+	}
+
+	if params.Kind != ParametersKind || (params.APIGroup != nil && *params.APIGroup != "") {
+		return "", fmt.Errorf("unsupported parameters kind %v of IngressClass %v, only %v is supported", params.Kind, ic.Name, ParametersKind)
+	}
+
+	if params.Scope == nil || *params.Scope != networking.IngressClassParametersReferenceScopeNamespace ||
+		params.Namespace == nil || *params.Namespace == "" {
+		return "", fmt.Errorf("parameters of IngressClass %v must reference a namespaced %v", ic.Name, ParametersKind)
+	}
+
+	return fmt.Sprintf("%v/%v", *params.Namespace, params.Name), nil
+}
+
+// ParseParameters parses the data of the ConfigMap referenced by an IngressClass
+func ParseParameters(data map[string]string) (*Parameters, error) {
+	params := &Parameters{}
+
+	if val, ok := data[defaultSSLCertificateKey]; ok && val != "" {
+		if len(strings.Split(val, "/")) != 2 {
+			return nil, fmt.Errorf("invalid format (namespace/name) found in %v: '%v'", defaultSSLCertificateKey, val)
+		}
+		params.DefaultSSLCertificate = val
+	}
+
+	if val, ok := data[sslMinVersionKey]; ok && val != "" {
+		protocols := []string{}
+		for i, version := range sslVersions {
+			if version == val {
+				protocols = sslVersions[i:]
+				break
+			}
+		}
+		if len(protocols) == 0 {
+			return nil, fmt.Errorf("invalid %v '%v', expected one of %v", sslMinVersionKey, val, strings.Join(sslVersions, ", "))
+		}
+		params.SSLProtocols = strings.Join(protocols, " ")
+	}
+
+	if val, ok := data[sslCiphersKey]; ok && val != "" {
+		if strings.ContainsAny(val, ";{}'\"") {
+			return nil, fmt.Errorf("invalid %v '%v'", sslCiphersKey, val)
+		}
+		params.SSLCiphers = val
+	}
+
+	var err error
+	if params.HSTS, err = parseBool(data, hstsKey); err != nil {
+		return nil, err
+	}
+	if params.HSTSIncludeSubdomains, err = parseBool(data, hstsIncludeSubdomainsKey); err != nil {
+		return nil, err
+	}
+	if params.HSTSPreload, err = parseBool(data, hstsPreloadKey); err != nil {
+		return nil, err
+	}
+
+	if val, ok := data[hstsMaxAgeKey]; ok && val != "" {
+		if maxAge, err := strconv.Atoi(val); err != nil || maxAge < 0 {
+			return nil, fmt.Errorf("invalid %v '%v', expected a number of seconds", hstsMaxAgeKey, val)
+		}
+		params.HSTSMaxAge = val
+	}
+
+	return params, nil
+}
+
+// HasHSTS returns if the parameters override one of the HSTS settings
+func (p *Parameters) HasHSTS() bool {
+	return p.HSTS != nil || p.HSTSMaxAge != "" || p.HSTSIncludeSubdomains != nil || p.HSTSPreload != nil
+}
+
+func parseBool(data map[string]string, key string) (*bool, error) {
+	val, ok := data[key]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v '%v': %v", key, val, err)
+	}
+
+	return &b, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingressclass
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParametersConfigMap(t *testing.T) {
+	namespace := "ingress-nginx"
+	scopeNamespace := networking.IngressClassParametersReferenceScopeNamespace
+	scopeCluster := networking.IngressClassParametersReferenceScopeCluster
+	apiGroup := "k8s.example.com"
+
+	testCases := map[string]struct {
+		params   *networking.IngressClassParametersReference
+		expected string
+		err      bool
+	}{
+		"no parameters": {},
+		"namespaced configmap": {
+			params:   &networking.IngressClassParametersReference{Kind: "ConfigMap", Name: "tenant-a", Scope: &scopeNamespace, Namespace: &namespace},
+			expected: "ingress-nginx/tenant-a",
+		},
+		"cluster scope": {
+			params: &networking.IngressClassParametersReference{Kind: "ConfigMap", Name: "tenant-a", Scope: &scopeCluster},
+			err:    true,
+		},
+		"missing namespace": {
+			params: &networking.IngressClassParametersReference{Kind: "ConfigMap", Name: "tenant-a", Scope: &scopeNamespace},
+			err:    true,
+		},
+		"custom resource": {
+			params: &networking.IngressClassParametersReference{APIGroup: &apiGroup, Kind: "Parameters", Name: "tenant-a", Scope: &scopeNamespace, Namespace: &namespace},
+			err:    true,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			ic := &networking.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"},
+				Spec:       networking.IngressClassSpec{Controller: DefaultControllerName, Parameters: tc.params},
+			}
+
+			key, err := ParametersConfigMap(ic)
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.err, err)
+			}
+			if key != tc.expected {
+				t.Errorf("expected key %q but got %q", tc.expected, key)
+			}
+		})
+	}
+}
+
+func TestParseParameters(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	testCases := map[string]struct {
+		data     map[string]string
+		expected *Parameters
+		err      bool
+	}{
+		"empty": {
+			data:     map[string]string{},
+			expected: &Parameters{},
+		},
+		"all settings": {
+			data: map[string]string{
+				"default-ssl-certificate": "tenant-a/default-tls",
+				"ssl-min-version":         "TLSv1.2",
+				"ssl-ciphers":             "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256",
+				"hsts":                    "true",
+				"hsts-max-age":            "63072000",
+				"hsts-include-subdomains": "false",
+				"hsts-preload":            "true",
+			},
+			expected: &Parameters{
+				DefaultSSLCertificate: "tenant-a/default-tls",
+				SSLProtocols:          "TLSv1.2 TLSv1.3",
+				SSLCiphers:            "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256",
+				HSTS:                  &enabled,
+				HSTSMaxAge:            "63072000",
+				HSTSIncludeSubdomains: &disabled,
+				HSTSPreload:           &enabled,
+			},
+		},
+		"invalid default certificate": {
+			data: map[string]string{"default-ssl-certificate": "default-tls"},
+			err:  true,
+		},
+		"invalid minimum version": {
+			data: map[string]string{"ssl-min-version": "SSLv3"},
+			err:  true,
+		},
+		"ciphers escaping the directive": {
+			data: map[string]string{"ssl-ciphers": "HIGH; return 200"},
+			err:  true,
+		},
+		"invalid hsts": {
+			data: map[string]string{"hsts": "yes please"},
+			err:  true,
+		},
+		"invalid hsts max age": {
+			data: map[string]string{"hsts-max-age": "-1"},
+			err:  true,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			params, err := ParseParameters(tc.data)
+			if tc.err != (err != nil) {
+				t.Fatalf("expected error %v but got %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(params, tc.expected) {
+				t.Errorf("expected parameters %+v but got %+v", tc.expected, params)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/jwtauth"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+const (
+	// maxJWKSSize is the maximum size of a key set downloaded from a URL
+	maxJWKSSize = 1 << 20
+)
+
+// jwksFetcher downloads the key sets referenced by the jwt-auth-jwks-url
+// annotations and keeps them in memory, refreshing them periodically. The
+// downloads run in the background so they never block the synchronization.
+type jwksFetcher struct {
+	client *http.Client
+	// onChange is called when a key set is downloaded or changes
+	onChange func()
+
+	mu sync.Mutex
+	// keySets contains the key sets indexed by URL
+	keySets map[string]string
+	// urls contains the URLs referenced by the configuration
+	urls sets.String
+	// pending contains the URLs being downloaded for the first time
+	pending sets.String
+}
+
+func newJWKSFetcher(onChange func()) *jwksFetcher {
+	return &jwksFetcher{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		onChange: onChange,
+		keySets:  map[string]string{},
+		urls:     sets.NewString(),
+		pending:  sets.NewString(),
+	}
+}
+
+// Get returns the key set of a URL and if it is available. The first time,
+// the key set is downloaded in the background and onChange is called once
+// it is available.
+func (f *jwksFetcher) Get(url string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if content, ok := f.keySets[url]; ok {
+		return content, true
+	}
+
+	if !f.pending.Has(url) {
+		f.pending.Insert(url)
+		go f.download(url)
+	}
+
+	return "", false
+}
+
+func (f *jwksFetcher) download(url string) {
+	content, err := f.fetch(url)
+
+	f.mu.Lock()
+	f.pending.Delete(url)
+	if err == nil {
+		f.keySets[url] = content
+	}
+	f.mu.Unlock()
+
+	if err != nil {
+		// requests to the locations are rejected until the key set is available
+		klog.Errorf("Error downloading the key set %v: %v", url, err)
+		return
+	}
+
+	if f.onChange != nil {
+		f.onChange()
+	}
+}
+
+// retain records the URLs referenced by the configuration and removes the
+// key sets of the URLs no longer referenced
+func (f *jwksFetcher) retain(urls sets.String) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.urls = urls
+	for url := range f.keySets {
+		if !urls.Has(url) {
+			delete(f.keySets, url)
+		}
+	}
+}
+
+// refresh downloads again the key sets referenced, including the ones whose
+// first download failed, and returns if any of them changed. A key set that
+// cannot be downloaded keeps its previous value.
+func (f *jwksFetcher) refresh() bool {
+	f.mu.Lock()
+	urls := f.urls.List()
+	f.mu.Unlock()
+
+	changed := false
+	for _, url := range urls {
+		content, err := f.fetch(url)
+		if err != nil {
+			klog.Warningf("Error refreshing the key set %v, keeping the previous keys: %v", url, err)
+			continue
+		}
+
+		f.mu.Lock()
+		if previous, ok := f.keySets[url]; f.urls.Has(url) && (!ok || previous != content) {
+			klog.InfoS("Key set changed", "url", url)
+			f.keySets[url] = content
+			changed = true
+		}
+		f.mu.Unlock()
+	}
+
+	return changed
+}
+
+// Run refreshes the key sets every interval until the channel is closed,
+// calling onChange when one of them changes
+func (f *jwksFetcher) Run(interval time.Duration, stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if f.refresh() && f.onChange != nil {
+			f.onChange()
+		}
+	}, interval, stopCh)
+}
+
+func (f *jwksFetcher) fetch(url string) (string, error) {
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxJWKSSize {
+		return "", fmt.Errorf("the key set exceeds %v bytes", maxJWKSSize)
+	}
+
+	if err := jwtauth.ValidateJWKS(data); err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// getJWKS returns the key sets used by the locations of the servers, indexed
+// by the key used in Lua
+func (n *NGINXController) getJWKS(servers []*ingress.Server) map[string]string {
+	jwks := map[string]string{}
+	urls := sets.NewString()
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			cfg := location.JWTAuth
+			if cfg.JWKSKey == "" {
+				continue
+			}
+			if _, ok := jwks[cfg.JWKSKey]; ok {
+				continue
+			}
+
+			if cfg.JWKSURL == "" {
+				jwks[cfg.JWKSKey] = cfg.JWKS
+				continue
+			}
+
+			if n.jwksFetcher == nil {
+				continue
+			}
+
+			urls.Insert(cfg.JWKSURL)
+			// the key set is added by a later synchronization when it is not
+			// available yet
+			if content, ok := n.jwksFetcher.Get(cfg.JWKSURL); ok {
+				jwks[cfg.JWKSKey] = content
+			}
+		}
+	}
+
+	if n.jwksFetcher != nil {
+		n.jwksFetcher.retain(urls)
+	}
+
+	return jwks
+}
+
+// configureJWKS POSTs the key sets to the internal HTTP endpoint handled by Lua
+func configureJWKS(jwks map[string]string) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/jwks", "application/json", jwks)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
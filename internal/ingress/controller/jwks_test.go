@@ -0,0 +1,294 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/jwtauth"
+)
+
+// newTestJWKS returns a key set with the public key of an ECDSA key generated locally
+func newTestJWKS(t *testing.T, kid string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	return fmt.Sprintf(`{"keys":[{"kty":"EC","kid":%q,"crv":"P-256","x":%q,"y":%q}]}`,
+		kid, enc(key.X.FillBytes(make([]byte, 32))), enc(key.Y.FillBytes(make([]byte, 32))))
+}
+
+func TestJWKSFetcher(t *testing.T) {
+	var mu sync.Mutex
+	current := newTestJWKS(t, "first")
+	status := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+		fmt.Fprint(w, current)
+	}))
+	defer server.Close()
+
+	changed := make(chan struct{}, 1)
+	f := newJWKSFetcher(func() { changed <- struct{}{} })
+
+	if _, ok := f.Get(server.URL); ok {
+		t.Fatalf("expected the key set to be downloaded in the background")
+	}
+	f.retain(sets.NewString(server.URL))
+	waitForChange(t, changed)
+
+	jwks, ok := f.Get(server.URL)
+	if !ok {
+		t.Fatalf("expected the key set to be available after the download")
+	}
+	if jwks != current {
+		t.Errorf("expected %v but returned %v", current, jwks)
+	}
+
+	if f.refresh() {
+		t.Errorf("expected no change when the key set is the same")
+	}
+
+	mu.Lock()
+	first := current
+	status = http.StatusInternalServerError
+	mu.Unlock()
+
+	if f.refresh() {
+		t.Errorf("expected no change when the key set cannot be downloaded")
+	}
+	if jwks, _ := f.Get(server.URL); jwks != first {
+		t.Errorf("expected the previous key set to be kept but returned %v", jwks)
+	}
+
+	mu.Lock()
+	current = newTestJWKS(t, "second")
+	status = http.StatusOK
+	mu.Unlock()
+
+	if !f.refresh() {
+		t.Errorf("expected a change after rotating the keys")
+	}
+	if jwks, _ := f.Get(server.URL); jwks != current {
+		t.Errorf("expected %v but returned %v", current, jwks)
+	}
+
+	f.retain(sets.NewString())
+	if len(f.keySets) != 0 {
+		t.Errorf("expected the unused key set to be removed but returned %v", f.keySets)
+	}
+
+	mu.Lock()
+	current = `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`
+	mu.Unlock()
+
+	f.retain(sets.NewString(server.URL))
+	if f.refresh() {
+		t.Errorf("expected no change downloading an invalid key set")
+	}
+	if _, ok := f.Get(server.URL); ok {
+		t.Errorf("expected an invalid key set not to be available")
+	}
+}
+
+// waitForChange waits for the fetcher to report a key set downloaded
+func waitForChange(t *testing.T, changed <-chan struct{}) {
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the key set to be downloaded")
+	}
+}
+
+func TestGetJWKS(t *testing.T) {
+	urlJWKS := newTestJWKS(t, "url")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, urlJWKS)
+	}))
+	defer server.Close()
+
+	secretJWKS := newTestJWKS(t, "secret")
+
+	servers := []*ingress.Server{
+		{
+			Hostname: "foo.bar",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{
+					Path: "/secret",
+					JWTAuth: jwtauth.Config{
+						JWKSSecret: "default/jwks",
+						JWKSKey:    "secret:default/jwks",
+						JWKS:       secretJWKS,
+					},
+				},
+				{
+					Path: "/url",
+					JWTAuth: jwtauth.Config{
+						JWKSURL: server.URL,
+						JWKSKey: "url:" + server.URL,
+					},
+				},
+				{
+					Path: "/unavailable",
+					JWTAuth: jwtauth.Config{
+						JWKSURL: server.URL + "/missing\x7f",
+						JWKSKey: "url:" + server.URL + "/missing\x7f",
+					},
+				},
+			},
+		},
+	}
+
+	changed := make(chan struct{}, 1)
+	n := &NGINXController{jwksFetcher: newJWKSFetcher(func() { changed <- struct{}{} })}
+
+	expected := map[string]string{"secret:default/jwks": secretJWKS}
+	if jwks := n.getJWKS(servers); !reflect.DeepEqual(jwks, expected) {
+		t.Errorf("expected the key sets downloaded in the background to be missing but returned %v", jwks)
+	}
+	waitForChange(t, changed)
+
+	expected["url:"+server.URL] = urlJWKS
+	if jwks := n.getJWKS(servers); !reflect.DeepEqual(jwks, expected) {
+		t.Errorf("expected %v but returned %v", expected, jwks)
+	}
+
+	servers[0].Locations = servers[0].Locations[:2]
+	n.getJWKS(servers)
+	if len(n.jwksFetcher.keySets) != 0 {
+		t.Errorf("expected the key sets no longer referenced to be removed but returned %v", n.jwksFetcher.keySets)
+	}
+}
+
+// newJWKSTestMCI returns a MultiClusterIngress validating the tokens of its
+// requests with the key set of the configuration given
+func newJWKSTestMCI(config jwtauth.Config) *ingress.MultiClusterIngress {
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "jwks",
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: "jwks.example.com",
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     "/",
+										PathType: &pathTypePrefix,
+										Backend: networking.IngressBackend{
+											Service: &networking.IngressServiceBackend{
+												Name: "app",
+												Port: networking.ServiceBackendPort{
+													Number: 80,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{JWTAuth: config},
+	}
+}
+
+func TestSyncIngressConfiguresRotatedKeySets(t *testing.T) {
+	const url = "https://idp.example.com/jwks"
+	first, second := newTestJWKS(t, "first"), newTestJWKS(t, "second")
+
+	testCases := map[string]struct {
+		config jwtauth.Config
+		rotate func(*NGINXController, *ingress.MultiClusterIngress)
+	}{
+		"key set from a Secret": {
+			config: jwtauth.Config{
+				JWKSSecret: "default/jwks",
+				JWKSKey:    "secret:default/jwks",
+				JWKS:       first,
+			},
+			rotate: func(n *NGINXController, mci *ingress.MultiClusterIngress) {
+				mci.ParsedAnnotations.JWTAuth.JWKS = second
+			},
+		},
+		"key set from a URL": {
+			config: jwtauth.Config{
+				JWKSURL: url,
+				JWKSKey: "url:" + url,
+			},
+			rotate: func(n *NGINXController, mci *ingress.MultiClusterIngress) {
+				n.jwksFetcher.keySets[url] = second
+			},
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			server := newLuaConfigurationServer(t)
+			defer server.Close()
+
+			mci := newJWKSTestMCI(tc.config)
+
+			n := newSyncTestController(t, mci)
+			n.jwksFetcher = newJWKSFetcher(nil)
+			n.jwksFetcher.keySets[url] = first
+			n.runningConfig.JWKS = map[string]string{tc.config.JWKSKey: first}
+
+			tc.rotate(n, mci)
+			if err := n.syncIngress(nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if jwks := server.Requests()["/configuration/jwks"]; !strings.Contains(jwks, "second") {
+				t.Errorf("expected the rotated key set to be configured but got %v", jwks)
+			}
+			if n.runningConfig.JWKS[tc.config.JWKSKey] != second {
+				t.Errorf("expected the running configuration to contain the rotated key set")
+			}
+		})
+	}
+}
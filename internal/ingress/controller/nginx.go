@@ -41,6 +41,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/flowcontrol"
@@ -66,8 +67,16 @@ import (
 const (
 	tempNginxPattern = "nginx-cfg"
 	emptyUID         = "-1"
+
+	// configurationChecksumPath returns the checksum of the configuration
+	// served by the NGINX workers
+	configurationChecksumPath = "/configuration-checksum"
 )
 
+// workersReadyTimeout is the time to wait for the NGINX workers to
+// serve a new configuration after a reload
+var workersReadyTimeout = 10 * time.Second
+
 // NewNGINXController creates a new NGINX Ingress controller.
 func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXController {
 	eventBroadcaster := record.NewBroadcaster()
@@ -106,6 +115,23 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		metricCollector: mc,
 
 		command: NewNginxCommand(),
+
+		syncEvents: newSyncEvents(),
+
+		quarantine: newMCIQuarantine(),
+
+		healthChecker: newHealthChecker(mc),
+
+		endpointDrainer: newEndpointDrainer(),
+	}
+
+	n.jwksFetcher = newJWKSFetcher(func() {
+		n.syncEvents.Add("jwks-change")
+		n.syncQueue.EnqueueTask(task.GetDummyObject("jwks-change"))
+	})
+
+	if config.ReloadHistorySize > 0 {
+		n.reloadHistory = newReloadHistory(config.ReloadHistorySize)
 	}
 
 	if n.cfg.ValidationWebhook != "" {
@@ -135,10 +161,16 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		n.updateCh,
 		config.DisableCatchAll,
 		config.DeepInspector,
-		config.IngressClassConfiguration)
+		config.IngressClassConfiguration,
+		config.Shard)
 
 	n.syncQueue = task.NewTaskQueue(n.syncIngress)
 
+	if config.ACMEDirectoryURL != "" {
+		n.acmeManager = newACMEManager(config.KarmadaKubeClient, n.store, config.ACMEDirectoryURL,
+			config.ACMEEmail, config.ACMENamespace, config.ACMERenewBefore)
+	}
+
 	if config.UpdateStatus {
 		n.syncStatus = status.NewStatusSyncer(status.Config{
 			Client:                 config.Client,
@@ -163,6 +195,7 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 
 		n.t = template
 		klog.InfoS("New NGINX configuration template loaded")
+		n.syncEvents.Add("template-change")
 		n.syncQueue.EnqueueTask(task.GetDummyObject("template-change"))
 	}
 
@@ -199,6 +232,7 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 	for _, f := range filesToWatch {
 		_, err = watch.NewFileWatcher(f, func() {
 			klog.InfoS("File changed detected. Reloading NGINX", "path", f)
+			n.syncEvents.Add(fmt.Sprintf("file-change %v", f))
 			n.syncQueue.EnqueueTask(task.GetDummyObject("file-change"))
 		})
 		if err != nil {
@@ -253,6 +287,34 @@ type NGINXController struct {
 	validationWebhookServer *http.Server
 
 	command NginxExecTester
+
+	// reloadHistory contains the latest reloads of NGINX
+	reloadHistory *reloadHistory
+
+	// syncEvents contains the events received since the last synchronization
+	syncEvents *syncEvents
+
+	// quarantine contains the MultiClusterIngresses excluded after a failed reload
+	quarantine *mciQuarantine
+
+	// configCache contains the last configuration built, used by incremental builds
+	configCache *configCache
+
+	// jwksFetcher downloads the key sets used to validate JSON Web Tokens
+	jwksFetcher *jwksFetcher
+
+	// healthChecker probes the endpoints of the backends with an active health check
+	healthChecker *healthChecker
+
+	// endpointDrainer keeps the endpoints removed from the backends while they drain
+	endpointDrainer *endpointDrainer
+
+	// acmeManager orders certificates for the MultiClusterIngresses without Secret
+	acmeManager *acmeManager
+
+	// certificateWarnings contains the last time an event was emitted for each
+	// certificate warning still present
+	certificateWarnings map[string]time.Time
 }
 
 // Start starts a new NGINX master process running in the foreground.
@@ -268,6 +330,11 @@ func (n *NGINXController) Start() {
 	// Should revisit this in a future
 	electionID := n.cfg.ElectionID
 
+	// each shard updates the status of the objects it owns
+	if n.cfg.Shard != nil {
+		electionID = fmt.Sprintf("%v-%v", electionID, n.cfg.Shard.Name())
+	}
+
 	setupLeaderElection(&leaderElectionConfig{
 		Client:     n.cfg.Client,
 		ElectionID: electionID,
@@ -304,8 +371,40 @@ func (n *NGINXController) Start() {
 
 	go n.syncQueue.Run(time.Second, n.stopCh)
 	// force initial sync
+	n.syncEvents.Add("initial-sync")
 	n.syncQueue.EnqueueTask(task.GetDummyObject("initial-sync"))
 
+	if n.cfg.JWKSRefreshInterval > 0 {
+		go n.jwksFetcher.Run(n.cfg.JWKSRefreshInterval, n.stopCh)
+	}
+
+	go n.healthChecker.Run(n.stopCh, func() {
+		n.syncEvents.Add("health-change")
+		n.syncQueue.EnqueueTask(task.GetDummyObject("health-change"))
+	})
+
+	go n.endpointDrainer.Run(n.stopCh, func() {
+		n.syncEvents.Add("draining-change")
+		n.syncQueue.EnqueueTask(task.GetDummyObject("draining-change"))
+	})
+
+	if n.acmeManager != nil {
+		go n.acmeManager.WatchChallenges(n.stopCh, func() {
+			n.syncEvents.Add("acme-challenges-change")
+			n.syncQueue.EnqueueTask(task.GetDummyObject("acme-challenges-change"))
+		})
+
+		// a single controller orders the certificates, whatever the member cluster
+		setupLeaderElection(&leaderElectionConfig{
+			Client:     n.cfg.KarmadaKubeClient,
+			Namespace:  n.cfg.ACMENamespace,
+			ElectionID: fmt.Sprintf("%v-acme", n.cfg.ElectionID),
+			OnStartedLeading: func(stopCh chan struct{}) {
+				go n.acmeManager.Run(stopCh)
+			},
+		})
+	}
+
 	// In case of error the temporal configuration file will
 	// be available up to five minutes after the error
 	go func() {
@@ -346,6 +445,7 @@ func (n *NGINXController) Start() {
 
 			if evt, ok := event.(store.Event); ok {
 				klog.V(3).InfoS("Event received", "type", evt.Type, "object", evt.Obj)
+				n.syncEvents.AddEvent(evt)
 				if evt.Type == store.ConfigurationEvent {
 					// TODO: is this necessary? Consider removing this special case
 					n.syncQueue.EnqueueTask(task.GetDummyObject("configmap-change"))
@@ -577,7 +677,7 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 
 	cfg.SSLDHParam = sslDHParam
 
-	cfg.DefaultSSLCertificate = n.getDefaultSSLCertificate()
+	cfg.DefaultSSLCertificate = n.getDefaultSSLCertificate(nil)
 
 	tc := ngx_config.TemplateConfig{
 		ProxySetHeaders:          setHeaders,
@@ -605,6 +705,7 @@ func (n NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressC
 		StatusPort:               nginx.StatusPort,
 		StreamPort:               nginx.StreamPort,
 		StreamSnippets:           append(ingressCfg.StreamSnippets, cfg.StreamSnippet),
+		EnableACME:               n.acmeManager != nil,
 	}
 
 	tc.Cfg.Checksum = ingressCfg.ConfigurationChecksum
@@ -647,55 +748,121 @@ Error: %v
 // OnUpdate is called by the synchronization loop whenever configuration
 // changes were detected. The received backend Configuration is merged with the
 // configuration ConfigMap before generating the final configuration file.
-// Returns nil in case the backend was successfully reloaded.
-func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
+// Returns the differences with the previous configuration file and nil in case
+// the backend was successfully reloaded.
+func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) (string, error) {
 	cfg := n.store.GetBackendConfiguration()
 	cfg.Resolver = n.resolver
 
 	content, err := n.generateTemplate(cfg, ingressCfg)
 	if err != nil {
-		return err
+		return "", invalidConfigurationError{err}
 	}
 
 	err = createOpentracingCfg(cfg)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	err = n.testTemplate(content)
 	if err != nil {
-		return err
+		return "", invalidConfigurationError{err}
 	}
 
-	if klog.V(2).Enabled() {
-		src, _ := os.ReadFile(cfgPath)
-		if !bytes.Equal(src, content) {
-			tmpfile, err := os.CreateTemp("", "new-nginx-cfg")
-			if err != nil {
-				return err
-			}
-			defer tmpfile.Close()
-			err = os.WriteFile(tmpfile.Name(), content, file.ReadWriteByUser)
-			if err != nil {
-				return err
-			}
+	var diff string
+	if n.reloadHistory != nil || klog.V(2).Enabled() {
+		diff, err = diffConfiguration(cfgPath, content)
+		if err != nil {
+			return "", err
+		}
 
-			diffOutput, err := exec.Command("diff", "-I", "'# Configuration.*'", "-u", cfgPath, tmpfile.Name()).CombinedOutput()
-			if err != nil {
-				if exitError, ok := err.(*exec.ExitError); ok {
-					ws := exitError.Sys().(syscall.WaitStatus)
-					if ws.ExitStatus() == 2 {
-						klog.Warningf("Failed to executing diff command: %v", err)
-					}
-				}
-			}
+		if diff != "" {
+			klog.V(2).InfoS("NGINX configuration change", "diff", diff)
+		}
+	}
 
-			klog.InfoS("NGINX configuration change", "diff", string(diffOutput))
+	err = backupConfiguration()
+	if err != nil {
+		return diff, err
+	}
 
-			// we do not defer the deletion of temp files in order
-			// to keep them around for inspection in case of error
-			os.Remove(tmpfile.Name())
+	err = os.WriteFile(cfgPath, content, file.ReadWriteByUser)
+	if err != nil {
+		return diff, err
+	}
+
+	err = n.reload(ingressCfg.ConfigurationChecksum)
+	if err != nil {
+		if rollbackErr := n.rollbackConfiguration(); rollbackErr != nil {
+			klog.ErrorS(rollbackErr, "Unexpected error restoring the last valid NGINX configuration")
+		} else {
+			klog.InfoS("Restored the last valid NGINX configuration")
 		}
+
+		return diff, err
+	}
+
+	err = os.WriteFile(lastGoodCfgPath, content, file.ReadWriteByUser)
+	if err != nil {
+		klog.Warningf("Unexpected error saving a copy of the NGINX configuration: %v", err)
+	}
+
+	return diff, nil
+}
+
+// reload signals NGINX to load the configuration located in cfgPath and waits
+// until the workers serve the configuration with the given checksum.
+func (n *NGINXController) reload(checksum string) error {
+	o, err := n.command.ExecCommand("-s", "reload").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\n%v", err, string(o))
+	}
+
+	if checksum == "" {
+		return nil
+	}
+
+	// NGINX only logs an error and keeps the old workers running when the new
+	// workers cannot be started (e.g. the listen sockets cannot be bound)
+	err = wait.PollImmediate(250*time.Millisecond, workersReadyTimeout, func() (bool, error) {
+		statusCode, body, err := nginx.NewGetStatusRequest(configurationChecksumPath)
+		if err != nil || statusCode != http.StatusOK {
+			return false, nil
+		}
+
+		return strings.TrimSpace(string(body)) == checksum, nil
+	})
+	if err != nil {
+		return fmt.Errorf("NGINX workers did not start with the configuration %v after %v", checksum, workersReadyTimeout)
+	}
+
+	return nil
+}
+
+// backupConfiguration keeps a copy of the configuration NGINX is running
+// before it is replaced for the first time.
+func backupConfiguration() error {
+	_, err := os.Stat(lastGoodCfgPath)
+	if err == nil || !os.IsNotExist(err) {
+		return err
+	}
+
+	current, err := os.ReadFile(cfgPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return os.WriteFile(lastGoodCfgPath, current, file.ReadWriteByUser)
+}
+
+// rollbackConfiguration restores the last configuration accepted by NGINX
+func (n *NGINXController) rollbackConfiguration() error {
+	content, err := os.ReadFile(lastGoodCfgPath)
+	if err != nil {
+		return fmt.Errorf("reading the last valid configuration: %w", err)
 	}
 
 	err = os.WriteFile(cfgPath, content, file.ReadWriteByUser)
@@ -711,6 +878,41 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 	return nil
 }
 
+// diffConfiguration returns the unified diff between the configuration file
+// located in path and the new content, or an empty string if there are no changes.
+func diffConfiguration(path string, content []byte) (string, error) {
+	src, _ := os.ReadFile(path)
+	if bytes.Equal(src, content) {
+		return "", nil
+	}
+
+	tmpfile, err := os.CreateTemp("", "new-nginx-cfg")
+	if err != nil {
+		return "", err
+	}
+	defer tmpfile.Close()
+	err = os.WriteFile(tmpfile.Name(), content, file.ReadWriteByUser)
+	if err != nil {
+		return "", err
+	}
+
+	diffOutput, err := exec.Command("diff", "-I", "'# Configuration.*'", "-u", path, tmpfile.Name()).CombinedOutput()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			ws := exitError.Sys().(syscall.WaitStatus)
+			if ws.ExitStatus() == 2 {
+				klog.Warningf("Failed to executing diff command: %v", err)
+			}
+		}
+	}
+
+	// we do not defer the deletion of temp files in order
+	// to keep them around for inspection in case of error
+	os.Remove(tmpfile.Name())
+
+	return string(diffOutput), nil
+}
+
 // nginxHashBucketSize computes the correct NGINX hash_bucket_size for a hash
 // with the given longest key.
 func nginxHashBucketSize(longestString int) int {
@@ -837,6 +1039,15 @@ func (n *NGINXController) IsDynamicConfigurationEnough(pcfg *ingress.Configurati
 	clearCertificates(&copyOfRunningConfig)
 	clearCertificates(&copyOfPcfg)
 
+	copyOfRunningConfig.JWKS = nil
+	copyOfPcfg.JWKS = nil
+
+	copyOfRunningConfig.OIDCSecrets = nil
+	copyOfPcfg.OIDCSecrets = nil
+
+	copyOfRunningConfig.ACMEChallenges = nil
+	copyOfPcfg.ACMEChallenges = nil
+
 	return copyOfRunningConfig.Equal(&copyOfPcfg)
 }
 
@@ -867,6 +1078,30 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 		}
 	}
 
+	jwksChanged := !reflect.DeepEqual(n.runningConfig.JWKS, pcfg.JWKS)
+	if jwksChanged {
+		err := configureJWKS(pcfg.JWKS)
+		if err != nil {
+			return err
+		}
+	}
+
+	oidcChanged := !reflect.DeepEqual(n.runningConfig.OIDCSecrets, pcfg.OIDCSecrets)
+	if oidcChanged {
+		err := configureOIDC(pcfg.OIDCSecrets)
+		if err != nil {
+			return err
+		}
+	}
+
+	acmeChallengesChanged := !reflect.DeepEqual(n.runningConfig.ACMEChallenges, pcfg.ACMEChallenges)
+	if acmeChallengesChanged {
+		err := configureACMEChallenges(pcfg.ACMEChallenges)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -944,13 +1179,20 @@ func configureBackends(rawBackends []*ingress.Backend) error {
 			NoServer:             backend.NoServer,
 			TrafficShapingPolicy: backend.TrafficShapingPolicy,
 			AlternativeBackends:  backend.AlternativeBackends,
+			RouteMatches:         backend.RouteMatches,
+			RetryPolicy:          backend.RetryPolicy,
+			SlowStartWindow:      backend.SlowStartWindow,
+			CircuitBreaker:       backend.CircuitBreaker,
 		}
 
 		var endpoints []ingress.Endpoint
 		for _, endpoint := range backend.Endpoints {
 			endpoints = append(endpoints, ingress.Endpoint{
-				Address: endpoint.Address,
-				Port:    endpoint.Port,
+				Address:  endpoint.Address,
+				Port:     endpoint.Port,
+				Health:   endpoint.Health,
+				Weight:   endpoint.Weight,
+				Draining: endpoint.Draining,
 			})
 		}
 
@@ -973,6 +1215,8 @@ func configureBackends(rawBackends []*ingress.Backend) error {
 type sslConfiguration struct {
 	Certificates map[string]string `json:"certificates"`
 	Servers      map[string]string `json:"servers"`
+	// Alternates contains the certificates of the servers with a second key type
+	Alternates map[string]string `json:"alternates"`
 }
 
 // configureCertificates JSON encodes certificates and POSTs it to an internal HTTP endpoint
@@ -981,10 +1225,12 @@ func configureCertificates(rawServers []*ingress.Server) error {
 	configuration := &sslConfiguration{
 		Certificates: map[string]string{},
 		Servers:      map[string]string{},
+		Alternates:   map[string]string{},
 	}
 
 	configure := func(hostname string, sslCert *ingress.SSLCert) {
 		uid := emptyUID
+		alternateUID := emptyUID
 
 		if sslCert != nil {
 			uid = sslCert.UID
@@ -992,9 +1238,18 @@ func configureCertificates(rawServers []*ingress.Server) error {
 			if _, ok := configuration.Certificates[uid]; !ok {
 				configuration.Certificates[uid] = sslCert.PemCertKey
 			}
+
+			if sslCert.Alternate != nil {
+				alternateUID = sslCert.Alternate.UID
+
+				if _, ok := configuration.Certificates[alternateUID]; !ok {
+					configuration.Certificates[alternateUID] = sslCert.Alternate.PemCertKey
+				}
+			}
 		}
 
 		configuration.Servers[hostname] = uid
+		configuration.Alternates[hostname] = alternateUID
 	}
 
 	for _, rawServer := range rawServers {
@@ -1002,9 +1257,9 @@ func configureCertificates(rawServers []*ingress.Server) error {
 
 		for _, alias := range rawServer.Aliases {
 			if rawServer.SSLCert != nil && ssl.IsValidHostname(alias, rawServer.SSLCert.CN) {
-				configuration.Servers[alias] = rawServer.SSLCert.UID
+				configure(alias, rawServer.SSLCert)
 			} else {
-				configuration.Servers[alias] = emptyUID
+				configure(alias, nil)
 			}
 		}
 	}
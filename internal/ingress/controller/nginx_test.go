@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/circuitbreaker"
 	"k8s.io/ingress-nginx/internal/nginx"
 )
 
@@ -196,13 +198,25 @@ func TestConfigureDynamically(t *testing.T) {
 						if !strings.Contains(body, "service") {
 							t.Errorf("service reference should be present in JSON content: %v", body)
 						}
+
+						if !strings.Contains(body, `"weight":4`) {
+							t.Errorf("endpoint weight should be present in JSON content: %v", body)
+						}
+
+						if !strings.Contains(body, `"draining":true`) {
+							t.Errorf("draining endpoint should be present in JSON content: %v", body)
+						}
+
+						if !strings.Contains(body, `"circuitBreaker":{"maxRequests":100,"maxPending":10,"status":503}`) {
+							t.Errorf("circuit breaker should be present in JSON content: %v", body)
+						}
 					}
 				case "/configuration/general":
 					{
 					}
 				case "/configuration/servers":
 					{
-						if !strings.Contains(body, `{"certificates":{},"servers":{"myapp.fake":"-1"}}`) {
+						if !strings.Contains(body, `{"certificates":{},"servers":{"myapp.fake":"-1"},"alternates":{"myapp.fake":"-1"}}`) {
 							t.Errorf("should be present in JSON content: %v", body)
 						}
 					}
@@ -218,8 +232,9 @@ func TestConfigureDynamically(t *testing.T) {
 	target := &apiv1.ObjectReference{}
 
 	backends := []*ingress.Backend{{
-		Name:    "fakenamespace-myapp-80",
-		Service: &apiv1.Service{},
+		Name:           "fakenamespace-myapp-80",
+		Service:        &apiv1.Service{},
+		CircuitBreaker: circuitbreaker.Config{MaxRequests: 100, MaxPending: 10, Status: 503},
 		Endpoints: []ingress.Endpoint{
 			{
 				Address: "10.0.0.1",
@@ -230,6 +245,13 @@ func TestConfigureDynamically(t *testing.T) {
 				Address: "10.0.0.2",
 				Port:    "8080",
 				Target:  target,
+				Weight:  4,
+			},
+			{
+				Address:  "10.0.0.3",
+				Port:     "8080",
+				Target:   target,
+				Draining: true,
 			},
 		},
 	}}
@@ -323,6 +345,17 @@ func TestConfigureCertificates(t *testing.T) {
 				UID:        "c89a5111-b2e9-4af8-be19-c2a4a924c256",
 			},
 		},
+		{
+			Hostname: "myapp.dual",
+			SSLCert: &ingress.SSLCert{
+				PemCertKey: "fake-rsa-cert",
+				UID:        "0d8b8a0f-0a0c-4b1e-9a4c-1b7d2d4a3c11",
+				Alternate: &ingress.SSLCert{
+					PemCertKey: "fake-ecdsa-cert",
+					UID:        "0d8b8a0f-0a0c-4b1e-9a4c-1b7d2d4a3c11-alt",
+				},
+			},
+		},
 		{
 			Hostname: "myapp.nossl",
 		},
@@ -362,6 +395,17 @@ func TestConfigureCertificates(t *testing.T) {
 							t.Errorf("Expected server %s to have UID of %s but got %s", server.Hostname, server.SSLCert.UID, conf.Servers[server.Hostname])
 						}
 					}
+
+					alternateUID := emptyUID
+					if server.SSLCert != nil && server.SSLCert.Alternate != nil {
+						alternateUID = server.SSLCert.Alternate.UID
+						if conf.Certificates[alternateUID] != server.SSLCert.Alternate.PemCertKey {
+							t.Errorf("Expected the alternate certificate of server %s to be posted", server.Hostname)
+						}
+					}
+					if conf.Alternates[server.Hostname] != alternateUID {
+						t.Errorf("Expected server %s to have alternate UID of %s but got %s", server.Hostname, alternateUID, conf.Alternates[server.Hostname])
+					}
 				}
 			}),
 		},
@@ -547,3 +591,52 @@ func tryListen(network, address string) (l net.Listener, err error) {
 	err = wait.ExponentialBackoff(backoff, condFunc)
 	return
 }
+
+// luaConfigurationServer records the configurations POSTed to Lua
+type luaConfigurationServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]string
+}
+
+func newLuaConfigurationServer(t *testing.T) *luaConfigurationServer {
+	listener, err := tryListen("tcp", fmt.Sprintf(":%v", nginx.StatusPort))
+	if err != nil {
+		t.Fatalf("creating tcp listener: %s", err)
+	}
+
+	s := &luaConfigurationServer{requests: map[string]string{}}
+	s.Server = &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("unexpected error reading the request: %v", err)
+				}
+
+				s.mu.Lock()
+				s.requests[r.URL.Path] = string(b)
+				s.mu.Unlock()
+
+				w.WriteHeader(http.StatusCreated)
+			}),
+		},
+	}
+	s.Start()
+
+	return s
+}
+
+// Requests returns the bodies of the requests received, indexed by path
+func (s *luaConfigurationServer) Requests() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := map[string]string{}
+	for path, body := range s.requests {
+		requests[path] = body
+	}
+	return requests
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+// getOIDCSecrets returns the secrets of the OpenID providers used by the
+// locations of the servers, indexed by the key of the provider
+func getOIDCSecrets(servers []*ingress.Server) map[string]oidc.Secrets {
+	secrets := map[string]oidc.Secrets{}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.OIDC.Key == "" {
+				continue
+			}
+			secrets[location.OIDC.Key] = location.OIDC.Secrets
+		}
+	}
+
+	return secrets
+}
+
+// configureOIDC POSTs the secrets of the OpenID providers to the internal
+// HTTP endpoint handled by Lua
+func configureOIDC(secrets map[string]oidc.Secrets) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/oidc", "application/json", secrets)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
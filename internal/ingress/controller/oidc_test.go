@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+)
+
+func TestGetOIDCSecrets(t *testing.T) {
+	team := oidc.Secrets{ClientSecret: "team-secret", CookieSecret: "0123456789abcdef"}
+	admin := oidc.Secrets{ClientSecret: "admin-secret", CookieSecret: "fedcba9876543210"}
+
+	servers := []*ingress.Server{
+		{
+			Hostname: "foo.bar",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{Path: "/team", OIDC: oidc.Config{Key: "team", Secrets: team}},
+			},
+		},
+		{
+			Hostname: "admin.foo.bar",
+			Locations: []*ingress.Location{
+				{Path: "/", OIDC: oidc.Config{Key: "admin", Secrets: admin}},
+				{Path: "/team", OIDC: oidc.Config{Key: "team", Secrets: team}},
+			},
+		},
+	}
+
+	expected := map[string]oidc.Secrets{
+		"team":  team,
+		"admin": admin,
+	}
+	if secrets := getOIDCSecrets(servers); !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("expected %v but returned %v", expected, secrets)
+	}
+}
+
+// newOIDCTestMCI returns a MultiClusterIngress logging in the users of its
+// requests with the provider of the configuration given
+func newOIDCTestMCI(config oidc.Config) *ingress.MultiClusterIngress {
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "oidc",
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: networking.IngressSpec{
+				Rules: []networking.IngressRule{
+					{
+						Host: "oidc.example.com",
+						IngressRuleValue: networking.IngressRuleValue{
+							HTTP: &networking.HTTPIngressRuleValue{
+								Paths: []networking.HTTPIngressPath{
+									{
+										Path:     "/",
+										PathType: &pathTypePrefix,
+										Backend: networking.IngressBackend{
+											Service: &networking.IngressServiceBackend{
+												Name: "app",
+												Port: networking.ServiceBackendPort{
+													Number: 80,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ParsedAnnotations: &annotations.Ingress{OIDC: config},
+	}
+}
+
+func TestSyncIngressConfiguresRotatedOIDCSecrets(t *testing.T) {
+	server := newLuaConfigurationServer(t)
+	defer server.Close()
+
+	mci := newOIDCTestMCI(oidc.Config{
+		Key:     "team",
+		Secrets: oidc.Secrets{ClientSecret: "first-secret", CookieSecret: "0123456789abcdef"},
+	})
+
+	n := newSyncTestController(t, mci)
+
+	mci.ParsedAnnotations.OIDC.Secrets.ClientSecret = "second-secret"
+	if err := n.syncIngress(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if secrets := server.Requests()["/configuration/oidc"]; !strings.Contains(secrets, "second-secret") {
+		t.Errorf("expected the rotated secrets to be configured but got %v", secrets)
+	}
+	if n.runningConfig.OIDCSecrets["team"].ClientSecret != "second-secret" {
+		t.Errorf("expected the running configuration to contain the rotated secrets")
+	}
+}
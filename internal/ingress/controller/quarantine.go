@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// mciQuarantine tracks the MultiClusterIngresses excluded from the configuration
+// because they were part of a change NGINX could not load. An object leaves the
+// quarantine as soon as it is updated or removed.
+type mciQuarantine struct {
+	mu sync.Mutex
+
+	// quarantined contains the resource version of each quarantined object
+	quarantined map[string]string
+
+	// applied contains the resource version of each object included
+	// in the last configuration NGINX accepted
+	applied map[string]string
+}
+
+func newMCIQuarantine() *mciQuarantine {
+	return &mciQuarantine{
+		quarantined: make(map[string]string),
+	}
+}
+
+// Filter returns the MultiClusterIngresses that are not quarantined and
+// the keys of the objects that left the quarantine
+func (q *mciQuarantine) Filter(mcis []*ingress.MultiClusterIngress) ([]*ingress.MultiClusterIngress, []string) {
+	if q == nil {
+		return mcis, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.quarantined) == 0 {
+		return mcis, nil
+	}
+
+	present := make(map[string]bool, len(mcis))
+	filtered := make([]*ingress.MultiClusterIngress, 0, len(mcis))
+	var released []string
+
+	for _, mci := range mcis {
+		key := k8s.MetaNamespaceKey(mci)
+		present[key] = true
+
+		version, ok := q.quarantined[key]
+		if !ok {
+			filtered = append(filtered, mci)
+			continue
+		}
+
+		if version == mci.ResourceVersion {
+			continue
+		}
+
+		delete(q.quarantined, key)
+		released = append(released, key)
+		filtered = append(filtered, mci)
+	}
+
+	for key := range q.quarantined {
+		if !present[key] {
+			delete(q.quarantined, key)
+			released = append(released, key)
+		}
+	}
+
+	return filtered, released
+}
+
+// Applied records the MultiClusterIngresses included in a configuration NGINX accepted
+func (q *mciQuarantine) Applied(mcis []*ingress.MultiClusterIngress) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.applied = make(map[string]string, len(mcis))
+	for _, mci := range mcis {
+		q.applied[k8s.MetaNamespaceKey(mci)] = mci.ResourceVersion
+	}
+}
+
+// Quarantine excludes the MultiClusterIngresses created or updated since the
+// last configuration NGINX accepted and returns them. Nothing is quarantined
+// before NGINX accepts a configuration, because every object would be suspect.
+func (q *mciQuarantine) Quarantine(mcis []*ingress.MultiClusterIngress) []*ingress.MultiClusterIngress {
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.applied == nil {
+		return nil
+	}
+
+	var offending []*ingress.MultiClusterIngress
+	for _, mci := range mcis {
+		key := k8s.MetaNamespaceKey(mci)
+		if version, ok := q.applied[key]; ok && version == mci.ResourceVersion {
+			continue
+		}
+
+		q.quarantined[key] = mci.ResourceVersion
+		offending = append(offending, mci)
+	}
+
+	return offending
+}
+
+// Exclude quarantines the given MultiClusterIngresses
+func (q *mciQuarantine) Exclude(mcis []*ingress.MultiClusterIngress) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, mci := range mcis {
+		q.quarantined[k8s.MetaNamespaceKey(mci)] = mci.ResourceVersion
+	}
+}
+
+// quarantineMCIs excludes from the next configurations the MultiClusterIngresses
+// that changed since the last configuration NGINX accepted
+func (n *NGINXController) quarantineMCIs(mcis []*ingress.MultiClusterIngress, reloadErr error) {
+	for _, mci := range n.quarantine.Quarantine(mcis) {
+		klog.Warningf("MultiClusterIngress %q excluded from the NGINX configuration until it changes", k8s.MetaNamespaceKey(mci))
+		n.metricCollector.SetMCIQuarantined(mci.Namespace, mci.Name, true)
+		n.recorder.Eventf(&mci.MultiClusterIngress, apiv1.EventTypeWarning, "Quarantined",
+			"Excluded from the NGINX configuration until the object changes: %v", reloadErr)
+	}
+}
+
+// releaseMCIs removes the quarantined MultiClusterIngresses that changed or no longer exist
+func (n *NGINXController) releaseMCIs(mcis []*ingress.MultiClusterIngress) []*ingress.MultiClusterIngress {
+	mcis, released := n.quarantine.Filter(mcis)
+	for _, key := range released {
+		klog.InfoS("MultiClusterIngress released from quarantine", "multiclusteringress", key)
+		namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+		n.metricCollector.SetMCIQuarantined(namespace, name, false)
+	}
+
+	return mcis
+}
+
+// invalidConfigurationError indicates the rendered configuration was rejected
+// before replacing the one NGINX is running
+type invalidConfigurationError struct {
+	err error
+}
+
+func (e invalidConfigurationError) Error() string {
+	return e.err.Error()
+}
+
+func (e invalidConfigurationError) Unwrap() error {
+	return e.err
+}
+
+// isInvalidConfiguration checks if an error is an invalidConfigurationError
+func isInvalidConfiguration(err error) bool {
+	var e invalidConfigurationError
+	return errors.As(err, &e)
+}
+
+// invalidMCI is a MultiClusterIngress that renders an invalid configuration
+type invalidMCI struct {
+	mci *ingress.MultiClusterIngress
+	err error
+}
+
+// excludeInvalidMCIs bisects the list of MultiClusterIngresses to find the
+// objects that render an invalid configuration and excludes them until
+// they change, so the rest of the configuration can still be applied
+func (n *NGINXController) excludeInvalidMCIs(mcis []*ingress.MultiClusterIngress) {
+	if err := n.testMCIs(nil); err != nil {
+		klog.Warningf("The NGINX configuration is invalid even without MultiClusterIngresses, skipping the search of invalid objects: %v", err)
+		return
+	}
+
+	invalid := n.findInvalidMCIs(mcis)
+	if len(invalid) == 0 {
+		klog.Warningf("Unable to identify the MultiClusterIngresses that generate an invalid NGINX configuration")
+		return
+	}
+
+	for _, i := range invalid {
+		klog.Warningf("MultiClusterIngress %q generates an invalid NGINX configuration and is excluded until it changes", k8s.MetaNamespaceKey(i.mci))
+		n.quarantine.Exclude([]*ingress.MultiClusterIngress{i.mci})
+		n.metricCollector.SetMCIQuarantined(i.mci.Namespace, i.mci.Name, true)
+		n.recorder.Eventf(&i.mci.MultiClusterIngress, apiv1.EventTypeWarning, "InvalidConfiguration",
+			"Excluded from the NGINX configuration until the object changes: %v", i.err)
+	}
+}
+
+// findInvalidMCIs returns the MultiClusterIngresses that render an invalid
+// configuration. Objects only invalid when combined with others are not found.
+func (n *NGINXController) findInvalidMCIs(mcis []*ingress.MultiClusterIngress) []invalidMCI {
+	if len(mcis) == 0 {
+		return nil
+	}
+
+	err := n.testMCIs(mcis)
+	if err == nil {
+		return nil
+	}
+
+	if len(mcis) == 1 {
+		return []invalidMCI{{mci: mcis[0], err: err}}
+	}
+
+	middle := len(mcis) / 2
+	return append(n.findInvalidMCIs(mcis[:middle]), n.findInvalidMCIs(mcis[middle:])...)
+}
+
+// testMCIs renders and tests the configuration generated by a list of MultiClusterIngresses
+func (n *NGINXController) testMCIs(mcis []*ingress.MultiClusterIngress) error {
+	cfg := n.store.GetBackendConfiguration()
+	cfg.Resolver = n.resolver
+
+	_, _, pcfg := n.getConfigurationFromMCI(mcis)
+
+	content, err := n.generateTemplate(cfg, *pcfg)
+	if err != nil {
+		return err
+	}
+
+	return n.testTemplate(content)
+}
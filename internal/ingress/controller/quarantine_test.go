@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+)
+
+func newQuarantineMCI(name, version string) *ingress.MultiClusterIngress {
+	return &ingress.MultiClusterIngress{
+		MultiClusterIngress: karmadanetwork.MultiClusterIngress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       metav1.NamespaceDefault,
+				ResourceVersion: version,
+			},
+		},
+	}
+}
+
+func TestMCIQuarantine(t *testing.T) {
+	q := newMCIQuarantine()
+
+	stable := newQuarantineMCI("stable", "1")
+	broken := newQuarantineMCI("broken", "1")
+
+	if offending := q.Quarantine([]*ingress.MultiClusterIngress{stable, broken}); len(offending) != 0 {
+		t.Fatalf("expected no quarantine before a configuration is accepted but got %v", len(offending))
+	}
+
+	q.Applied([]*ingress.MultiClusterIngress{stable})
+
+	offending := q.Quarantine([]*ingress.MultiClusterIngress{stable, broken})
+	if len(offending) != 1 || offending[0] != broken {
+		t.Fatalf("expected only the changed object to be quarantined but got %v", offending)
+	}
+
+	filtered, released := q.Filter([]*ingress.MultiClusterIngress{stable, broken})
+	if !reflect.DeepEqual(filtered, []*ingress.MultiClusterIngress{stable}) || len(released) != 0 {
+		t.Errorf("expected the quarantined object to be excluded but got %v (released %v)", filtered, released)
+	}
+
+	fixed := newQuarantineMCI("broken", "2")
+	filtered, released = q.Filter([]*ingress.MultiClusterIngress{stable, fixed})
+	if len(filtered) != 2 || !reflect.DeepEqual(released, []string{"default/broken"}) {
+		t.Errorf("expected the updated object to be released but got %v (released %v)", filtered, released)
+	}
+
+	q.Quarantine([]*ingress.MultiClusterIngress{stable, fixed})
+	_, released = q.Filter([]*ingress.MultiClusterIngress{stable})
+	if !reflect.DeepEqual(released, []string{"default/broken"}) {
+		t.Errorf("expected the removed object to be released but got %v", released)
+	}
+}
+
+// hostnameTester rejects any configuration that contains one of the hostnames
+type hostnameTester struct {
+	invalid []string
+}
+
+func (hostnameTester) ExecCommand(args ...string) *exec.Cmd {
+	return nil
+}
+
+func (ht hostnameTester) Test(cfg string) ([]byte, error) {
+	content, err := os.ReadFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hostname := range ht.invalid {
+		if strings.Contains(string(content), hostname) {
+			return []byte(fmt.Sprintf("invalid server %v", hostname)), fmt.Errorf("exit status 1")
+		}
+	}
+
+	return nil, nil
+}
+
+func TestFindInvalidMCIs(t *testing.T) {
+	newMCI := func(name string) *ingress.MultiClusterIngress {
+		mci := newQuarantineMCI(name, "1")
+		mci.Spec = networking.IngressSpec{
+			Rules: []networking.IngressRule{
+				{Host: fmt.Sprintf("%v.example.com", name)},
+			},
+		}
+		mci.ParsedAnnotations = &annotations.Ingress{}
+		return mci
+	}
+
+	var mcis []*ingress.MultiClusterIngress
+	for i := 0; i < 7; i++ {
+		mcis = append(mcis, newMCI(fmt.Sprintf("tenant-%v", i)))
+	}
+
+	n := newNGINXController(t)
+	n.t = fakeTemplate{}
+	n.command = hostnameTester{invalid: []string{"tenant-2.example.com", "tenant-5.example.com"}}
+
+	invalid := n.findInvalidMCIs(mcis)
+
+	var names []string
+	for _, i := range invalid {
+		names = append(names, i.mci.Name)
+		if i.err == nil {
+			t.Errorf("expected an error for %v", i.mci.Name)
+		}
+	}
+
+	if !reflect.DeepEqual(names, []string{"tenant-2", "tenant-5"}) {
+		t.Errorf("expected tenant-2 and tenant-5 to be invalid but got %v", names)
+	}
+
+	if invalid := n.findInvalidMCIs([]*ingress.MultiClusterIngress{mcis[0], mcis[1]}); len(invalid) != 0 {
+		t.Errorf("expected no invalid objects but got %v", len(invalid))
+	}
+}
+
+func TestFindInvalidMCIsKeepsKeySets(t *testing.T) {
+	mci := newQuarantineMCI("tenant", "1")
+	mci.Spec = networking.IngressSpec{
+		Rules: []networking.IngressRule{{Host: "tenant.example.com"}},
+	}
+	mci.ParsedAnnotations = &annotations.Ingress{}
+
+	n := newNGINXController(t)
+	n.t = fakeTemplate{}
+	n.command = hostnameTester{invalid: []string{"tenant.example.com"}}
+	n.jwksFetcher = newJWKSFetcher(nil)
+	n.jwksFetcher.keySets["https://idp.example.com/jwks"] = `{"keys":[]}`
+
+	if invalid := n.findInvalidMCIs([]*ingress.MultiClusterIngress{mci}); len(invalid) != 1 {
+		t.Fatalf("expected the object to be invalid but got %v", len(invalid))
+	}
+
+	expected := map[string]string{"https://idp.example.com/jwks": `{"keys":[]}`}
+	if !reflect.DeepEqual(n.jwksFetcher.keySets, expected) {
+		t.Errorf("expected the key sets to be kept but got %v", n.jwksFetcher.keySets)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
+
+const (
+	// ReloadSuccess is the outcome of a reload that NGINX accepted
+	ReloadSuccess = "success"
+	// ReloadFailure is the outcome of a reload that could not be applied
+	ReloadFailure = "failure"
+
+	// ReloadHistoryPath defines the path used to expose the reload history
+	ReloadHistoryPath = "/reloads"
+)
+
+// ReloadRecord describes a single attempt to reload NGINX with a new configuration
+type ReloadRecord struct {
+	// Timestamp is the moment the reload started
+	Timestamp time.Time `json:"timestamp"`
+	// Checksum is the ConfigurationChecksum of the configuration being applied
+	Checksum string `json:"checksum"`
+	// Events contains the keys of the objects that triggered the synchronization
+	Events []string `json:"events,omitempty"`
+	// Duration is the time in seconds required to render, test and reload the configuration
+	Duration float64 `json:"durationSeconds"`
+	// Outcome of the reload, success or failure
+	Outcome string `json:"outcome"`
+	// Error contains the reason of a failed reload
+	Error string `json:"error,omitempty"`
+	// Diff is the unified diff between the previous and the new nginx.conf
+	Diff string `json:"diff,omitempty"`
+}
+
+// newReloadRecord returns the record of a reload that started at start and
+// finished now with the given diff and error
+func newReloadRecord(start time.Time, checksum string, events []string, diff string, err error) ReloadRecord {
+	r := ReloadRecord{
+		Timestamp: start,
+		Checksum:  checksum,
+		Events:    events,
+		Duration:  time.Since(start).Seconds(),
+		Outcome:   ReloadSuccess,
+		Diff:      diff,
+	}
+
+	if err != nil {
+		r.Outcome = ReloadFailure
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// reloadHistory keeps a bounded list of the latest reloads in memory
+type reloadHistory struct {
+	mu      sync.RWMutex
+	size    int
+	records []ReloadRecord
+}
+
+// newReloadHistory creates a reload history that keeps at most size records
+func newReloadHistory(size int) *reloadHistory {
+	return &reloadHistory{
+		size:    size,
+		records: make([]ReloadRecord, 0, size),
+	}
+}
+
+// Add appends a record, discarding the oldest one when the history is full
+func (h *reloadHistory) Add(r ReloadRecord) {
+	if h == nil || h.size <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.records) == h.size {
+		copy(h.records, h.records[1:])
+		h.records = h.records[:h.size-1]
+	}
+
+	h.records = append(h.records, r)
+}
+
+// List returns a copy of the records, from the oldest to the newest
+func (h *reloadHistory) List() []ReloadRecord {
+	if h == nil {
+		return []ReloadRecord{}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	records := make([]ReloadRecord, len(h.records))
+	copy(records, h.records)
+	return records
+}
+
+// ServeHTTP returns the reload history in JSON format
+func (h *reloadHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.List()); err != nil {
+		klog.ErrorS(err, "Unexpected error encoding reload history")
+	}
+}
+
+// syncEvents accumulates the keys of the objects that changed since the last
+// synchronization, so every reload can be traced back to its cause.
+type syncEvents struct {
+	mu      sync.Mutex
+	keys    sets.String
+	changes configChanges
+}
+
+func newSyncEvents() *syncEvents {
+	return &syncEvents{
+		keys:    sets.NewString(),
+		changes: newConfigChanges(),
+	}
+}
+
+// Add registers a new event key that can affect any part of the configuration
+func (e *syncEvents) Add(key string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.keys.Insert(key)
+	e.changes.full = true
+}
+
+// AddEvent registers an event received from the store and the objects it changed
+func (e *syncEvents) AddEvent(evt store.Event) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.keys.Insert(eventKey(evt))
+	e.changes.add(evt)
+}
+
+// Drain returns the sorted list of keys and the changed objects, and resets both
+func (e *syncEvents) Drain() ([]string, configChanges) {
+	if e == nil {
+		return nil, configChanges{full: true}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := e.keys.List()
+	changes := e.changes
+	e.keys = sets.NewString()
+	e.changes = newConfigChanges()
+	return keys, changes
+}
+
+// eventKey returns a human readable key for an event received from the store
+// with the format <type> <kind> <namespace>/<name>
+func eventKey(evt store.Event) string {
+	obj := evt.Obj
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	kind := "Unknown"
+	if obj != nil {
+		kind = reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	}
+
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(evt.Obj)
+	if err != nil {
+		return fmt.Sprintf("%v %v", evt.Type, kind)
+	}
+
+	return fmt.Sprintf("%v %v %v", evt.Type, kind, key)
+}
+
+// ReloadHistory returns the handler that exposes the history of reloads
+func (n *NGINXController) ReloadHistory() http.Handler {
+	return n.reloadHistory
+}
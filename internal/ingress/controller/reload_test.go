@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
+
+func TestReloadHistory(t *testing.T) {
+	h := newReloadHistory(3)
+
+	for i := 0; i < 5; i++ {
+		h.Add(newReloadRecord(time.Now(), fmt.Sprintf("%v", i), nil, "", nil))
+	}
+
+	records := h.List()
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records but got %v", len(records))
+	}
+
+	for i, r := range records {
+		expected := fmt.Sprintf("%v", i+2)
+		if r.Checksum != expected {
+			t.Errorf("expected checksum %v at position %v but got %v", expected, i, r.Checksum)
+		}
+	}
+
+	var disabled *reloadHistory
+	disabled.Add(newReloadRecord(time.Now(), "0", nil, "", nil))
+	if len(disabled.List()) != 0 {
+		t.Errorf("expected an empty list from a disabled history")
+	}
+}
+
+func TestNewReloadRecord(t *testing.T) {
+	r := newReloadRecord(time.Now(), "1", []string{"CREATE"}, "diff", fmt.Errorf("invalid configuration"))
+	if r.Outcome != ReloadFailure {
+		t.Errorf("expected outcome %v but got %v", ReloadFailure, r.Outcome)
+	}
+	if r.Error != "invalid configuration" {
+		t.Errorf("unexpected error %v", r.Error)
+	}
+
+	r = newReloadRecord(time.Now(), "1", nil, "", nil)
+	if r.Outcome != ReloadSuccess {
+		t.Errorf("expected outcome %v but got %v", ReloadSuccess, r.Outcome)
+	}
+}
+
+func TestReloadHistoryServeHTTP(t *testing.T) {
+	h := newReloadHistory(2)
+	h.Add(newReloadRecord(time.Now(), "1", []string{"UPDATE MultiClusterIngress default/demo"}, "-a\n+b", nil))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReloadHistoryPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code 200 but got %v", w.Code)
+	}
+
+	var records []ReloadRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+		t.Fatalf("unexpected error decoding the response: %v", err)
+	}
+	if len(records) != 1 || records[0].Diff != "-a\n+b" {
+		t.Errorf("unexpected response %v", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ReloadHistoryPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status code 405 but got %v", w.Code)
+	}
+}
+
+func TestSyncEvents(t *testing.T) {
+	e := newSyncEvents()
+	e.Add("b")
+	e.Add("a")
+	e.Add("b")
+
+	keys, changes := e.Drain()
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	if !changes.full {
+		t.Errorf("expected a full change for events without objects")
+	}
+
+	if keys, _ := e.Drain(); len(keys) != 0 {
+		t.Errorf("expected no keys after drain but got %v", keys)
+	}
+
+	e.AddEvent(store.Event{Type: store.UpdateEvent, Services: []string{"default/demo"}})
+	_, changes = e.Drain()
+	if changes.full || !changes.services.Has("default/demo") {
+		t.Errorf("expected only the service default/demo to change but got %+v", changes)
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	mci := &karmadanetwork.MultiClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: "default",
+		},
+	}
+
+	testCases := map[string]struct {
+		event    store.Event
+		expected string
+	}{
+		"update": {
+			store.Event{Type: store.UpdateEvent, Obj: mci},
+			"UPDATE MultiClusterIngress default/demo",
+		},
+		"tombstone": {
+			store.Event{Type: store.DeleteEvent, Obj: cache.DeletedFinalStateUnknown{Key: "default/demo", Obj: mci}},
+			"DELETE MultiClusterIngress default/demo",
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			if key := eventKey(tc.event); key != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, key)
+			}
+		})
+	}
+}
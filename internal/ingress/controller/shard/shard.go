@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ByHost assigns MultiClusterIngresses to shards using a consistent hash of the first host
+	ByHost = "host"
+	// ByNamespace assigns the MultiClusterIngresses of the namespaces matching a label selector
+	ByNamespace = "namespace"
+	// ByLabel assigns the MultiClusterIngresses matching a label selector
+	ByLabel = "label"
+)
+
+// Configuration defines the subset of MultiClusterIngresses processed by a
+// controller when several replica sets share the same IngressClass.
+// A nil Configuration owns every object.
+type Configuration struct {
+	// By defines how objects are assigned to shards: host, namespace or label
+	By string
+	// Count is the number of shards when objects are assigned by host
+	Count int
+	// Index is the shard, from zero to Count-1, owned by the controller when
+	// objects are assigned by host
+	Index int
+	// Selector matches the namespace or the labels of the objects owned by the
+	// controller when objects are assigned by namespace or label
+	Selector labels.Selector
+}
+
+// NamespaceLabels returns the labels of a namespace, or false if the namespace does not exist
+type NamespaceLabels func(namespace string) (labels.Set, bool)
+
+// Validate checks the configuration is consistent
+func (c *Configuration) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.By {
+	case ByHost:
+		if c.Count < 1 {
+			return fmt.Errorf("the number of shards must be greater than zero")
+		}
+		if c.Index < 0 || c.Index >= c.Count {
+			return fmt.Errorf("the shard index must be between 0 and %v", c.Count-1)
+		}
+	case ByNamespace, ByLabel:
+		if c.Selector == nil || c.Selector.Empty() {
+			return fmt.Errorf("sharding by %v requires a label selector", c.By)
+		}
+	default:
+		return fmt.Errorf("invalid sharding mode %q (expected %v, %v or %v)", c.By, ByHost, ByNamespace, ByLabel)
+	}
+
+	return nil
+}
+
+// Name returns a name for the shard, unique among the shards of a deployment
+func (c *Configuration) Name() string {
+	if c == nil {
+		return ""
+	}
+
+	if c.By == ByHost {
+		return fmt.Sprintf("shard-%v", c.Index)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(c.By + ":" + c.Selector.String()))
+	return fmt.Sprintf("shard-%x", h.Sum32())
+}
+
+// Owns returns if the MultiClusterIngress is processed by the controller
+func (c *Configuration) Owns(mci *karmadanetwork.MultiClusterIngress, nsLabels NamespaceLabels) bool {
+	if c == nil {
+		return true
+	}
+
+	switch c.By {
+	case ByHost:
+		return Index(Host(mci), c.Count) == c.Index
+	case ByNamespace:
+		set, ok := nsLabels(mci.Namespace)
+		return ok && c.Selector.Matches(set)
+	case ByLabel:
+		return c.Selector.Matches(labels.Set(mci.Labels))
+	}
+
+	return false
+}
+
+// Host returns the hostname used to assign a MultiClusterIngress to a shard,
+// which is the first host defined in the rules. Objects without hostnames
+// return an empty string, so every catch-all object belongs to the same shard.
+func Host(mci *karmadanetwork.MultiClusterIngress) string {
+	for _, rule := range mci.Spec.Rules {
+		if rule.Host != "" {
+			return rule.Host
+		}
+	}
+
+	return ""
+}
+
+// Index returns the shard of a key using jump consistent hashing, so
+// changing the number of shards only moves the minimum number of keys.
+// https://arxiv.org/abs/1406.2294
+func Index(key string, count int) int {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	k := h.Sum64()
+
+	var b, j int64 = -1, 0
+	for j < int64(count) {
+		b = j
+		k = k*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((k>>33)+1)))
+	}
+
+	return int(b)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func newMCI(namespace, host string, lbls map[string]string) *karmadanetwork.MultiClusterIngress {
+	return &karmadanetwork.MultiClusterIngress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "demo",
+			Namespace: namespace,
+			Labels:    lbls,
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{{Host: host}},
+		},
+	}
+}
+
+func TestIndex(t *testing.T) {
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("host-%v.example.com", i)
+
+		before := Index(key, 4)
+		if before < 0 || before >= 4 {
+			t.Fatalf("invalid shard %v for key %v", before, key)
+		}
+		if before != Index(key, 4) {
+			t.Fatalf("expected a stable shard for key %v", key)
+		}
+
+		if after := Index(key, 5); after != before {
+			if after != 4 {
+				t.Errorf("expected key %v to move only to the new shard but got %v", key, after)
+			}
+			moved++
+		}
+	}
+
+	// adding a fifth shard should move about a fifth of the keys
+	if moved < 100 || moved > 300 {
+		t.Errorf("expected about 200 keys to move but got %v", moved)
+	}
+}
+
+func TestOwns(t *testing.T) {
+	nsLabels := func(namespace string) (labels.Set, bool) {
+		if namespace == "team-a" {
+			return labels.Set{"team": "a"}, true
+		}
+		return nil, false
+	}
+
+	host := "demo.example.com"
+	shard := Index(host, 3)
+
+	testCases := map[string]struct {
+		config   *Configuration
+		mci      *karmadanetwork.MultiClusterIngress
+		expected bool
+	}{
+		"disabled": {
+			nil, newMCI("default", host, nil), true,
+		},
+		"host in shard": {
+			&Configuration{By: ByHost, Count: 3, Index: shard}, newMCI("default", host, nil), true,
+		},
+		"host in another shard": {
+			&Configuration{By: ByHost, Count: 3, Index: (shard + 1) % 3}, newMCI("default", host, nil), false,
+		},
+		"namespace matching": {
+			&Configuration{By: ByNamespace, Selector: labels.SelectorFromSet(labels.Set{"team": "a"})}, newMCI("team-a", host, nil), true,
+		},
+		"unknown namespace": {
+			&Configuration{By: ByNamespace, Selector: labels.SelectorFromSet(labels.Set{"team": "a"})}, newMCI("team-b", host, nil), false,
+		},
+		"label matching": {
+			&Configuration{By: ByLabel, Selector: labels.SelectorFromSet(labels.Set{"shard": "edge"})}, newMCI("default", host, map[string]string{"shard": "edge"}), true,
+		},
+		"label not matching": {
+			&Configuration{By: ByLabel, Selector: labels.SelectorFromSet(labels.Set{"shard": "edge"})}, newMCI("default", host, nil), false,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			if owns := tc.config.Owns(tc.mci, nsLabels); owns != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, owns)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []*Configuration{
+		{By: "random"},
+		{By: ByHost, Count: 0},
+		{By: ByHost, Count: 2, Index: 2},
+		{By: ByLabel},
+		{By: ByNamespace, Selector: labels.Everything()},
+	}
+
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected an error validating %+v", c)
+		}
+	}
+
+	valid := &Configuration{By: ByHost, Count: 2, Index: 1}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -36,6 +36,8 @@ import (
 type leaderElectionConfig struct {
 	Client clientset.Interface
 
+	// Namespace of the lock, the namespace of the controller pod when empty
+	Namespace  string
 	ElectionID string
 
 	OnStartedLeading func(chan struct{})
@@ -93,8 +95,13 @@ func setupLeaderElection(config *leaderElectionConfig) {
 		Host:      hostname,
 	})
 
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = k8s.IngressPodDetails.Namespace
+	}
+
 	lock := resourcelock.ConfigMapLock{
-		ConfigMapMeta: metav1.ObjectMeta{Namespace: k8s.IngressPodDetails.Namespace, Name: config.ElectionID},
+		ConfigMapMeta: metav1.ObjectMeta{Namespace: namespace, Name: config.ElectionID},
 		Client:        config.Client.CoreV1(),
 		LockConfig: resourcelock.ResourceLockConfig{
 			Identity:      k8s.IngressPodDetails.Name,
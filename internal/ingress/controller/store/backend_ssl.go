@@ -31,6 +31,14 @@ import (
 	"k8s.io/ingress-nginx/internal/net/ssl"
 )
 
+const (
+	// alternateTLSCertKey and alternateTLSPrivateKeyKey are the keys of the
+	// Secret containing a second keypair with a different key type, like an
+	// ECDSA keypair next to a RSA one
+	alternateTLSCertKey       = "tls-alt.crt"
+	alternateTLSPrivateKeyKey = "tls-alt.key"
+)
+
 // syncSecret synchronizes the content of a TLS Secret (certificate(s), secret
 // key) with the filesystem. The resulting files can be used by NGINX.
 func (s *k8sStore) syncSecret(key string) {
@@ -103,6 +111,23 @@ func (s *k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error
 			return nil, fmt.Errorf("unexpected error creating SSL Cert: %v", err)
 		}
 
+		altCert, okAltCert := secret.Data[alternateTLSCertKey]
+		altKey, okAltKey := secret.Data[alternateTLSPrivateKeyKey]
+		if okAltCert || okAltKey {
+			alternate, err := ssl.CreateSSLCert(altCert, altKey, fmt.Sprintf("%v-alt", secret.UID))
+			if err != nil {
+				return nil, fmt.Errorf("unexpected error creating alternate SSL Cert: %v", err)
+			}
+
+			err = ssl.SetAlternateSSLCert(sslCert, alternate)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected error configuring alternate SSL Cert: %v", err)
+			}
+
+			alternate.Name = secret.Name
+			alternate.Namespace = secret.Namespace
+		}
+
 		if len(ca) > 0 {
 			caCert, err := ssl.CheckCACert(ca)
 			if err != nil {
@@ -133,6 +158,9 @@ func (s *k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error
 		}
 
 		msg := fmt.Sprintf("Configuring Secret %q for TLS encryption (CN: %v)", secretName, sslCert.CN)
+		if sslCert.Alternate != nil {
+			msg += fmt.Sprintf(" with %v and %v keys", sslCert.KeyType, sslCert.Alternate.KeyType)
+		}
 		if ca != nil {
 			msg += " and authentication"
 		}
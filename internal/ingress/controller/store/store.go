@@ -55,6 +55,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
@@ -96,6 +97,9 @@ type Storer interface {
 	// ListMultiClusterIngresses returns a list of all MultiClusterIngresses in the store.S
 	ListMultiClusterIngresses() []*ingress.MultiClusterIngress
 
+	// OwnsMultiClusterIngress returns if the MultiClusterIngress belongs to the shard of the controller
+	OwnsMultiClusterIngress(mci *karmadanetwork.MultiClusterIngress) bool
+
 	// GetLocalSSLCert returns the local copy of a SSLCert
 	GetLocalSSLCert(name string) (*ingress.SSLCert, error)
 
@@ -110,6 +114,10 @@ type Storer interface {
 	// GetDefaultBackend returns the default backend configuration
 	GetDefaultBackend() defaults.Backend
 
+	// GetIngressClassParameters returns the parameters of an IngressClass, or
+	// nil when the IngressClass does not reference parameters
+	GetIngressClassParameters(name string) (*ingressclass.Parameters, error)
+
 	// Run initiates the synchronization of the controllers
 	Run(stopCh chan struct{})
 }
@@ -132,6 +140,12 @@ const (
 type Event struct {
 	Type EventType
 	Obj  interface{}
+
+	// MultiClusterIngresses contains the keys of the MultiClusterIngresses
+	// changed by the event. Empty when the event can affect any object.
+	MultiClusterIngresses []string
+	// Services contains the keys of the Services whose endpoints changed
+	Services []string
 }
 
 // Informer defines the required SharedIndexInformers that interact with the API server.
@@ -265,6 +279,9 @@ type k8sStore struct {
 	backendConfigMu *sync.RWMutex
 
 	defaultSSLCertificate string
+
+	// shard defines the MultiClusterIngresses processed by the controller
+	shard *shard.Configuration
 }
 
 // New creates a new object store to be used in the ingress controller
@@ -279,7 +296,8 @@ func New(
 	updateCh *channels.RingChannel,
 	disableCatchAll bool,
 	deepInspector bool,
-	icConfig *ingressclass.IngressClassConfiguration) Storer {
+	icConfig *ingressclass.IngressClassConfiguration,
+	shardConfig *shard.Configuration) Storer {
 
 	store := &k8sStore{
 		informers:             &Informer{},
@@ -292,6 +310,7 @@ func New(
 		secretIngressMap:      NewObjectRefMap(),
 		secretMCIMap:          NewObjectRefMap(),
 		defaultSSLCertificate: defaultSSLCertificate,
+		shard:                 shardConfig,
 	}
 
 	eventBroadcaster := record.NewBroadcaster()
@@ -388,7 +407,7 @@ func New(
 	store.listers.Service.Store = store.informers.Service.GetStore()
 
 	// avoid caching namespaces at cluster scope when watching single namespace
-	if namespaceSelector != nil && !namespaceSelector.Empty() {
+	if (namespaceSelector != nil && !namespaceSelector.Empty()) || (shardConfig != nil && shardConfig.By == shard.ByNamespace) {
 		// cache informers factory for namespaces
 		infFactoryNamespaces := informers.NewSharedInformerFactoryWithOptions(karmadaKubeClient, resyncPeriod,
 			informers.WithTweakListOptions(labelsTweakListOptionsFunc),
@@ -574,6 +593,11 @@ func New(
 			return
 		}
 
+		// the shard of the object can change after it was added to the store
+		if _, err := store.getMultiClusterIngress(k8s.MetaNamespaceKey(mci)); err != nil && shardConfig != nil {
+			return
+		}
+
 		_, err := store.GetIngressClassByMCI(mci, icConfig)
 		if err != nil {
 			klog.InfoS("Ignoring multiclusteringress because of error while validating ingress class", "multiclusteringress", klog.KObj(mci), "error", err)
@@ -591,8 +615,9 @@ func New(
 		store.secretMCIMap.Delete(key)
 
 		updateCh.In() <- Event{
-			Type: DeleteEvent,
-			Obj:  obj,
+			Type:                  DeleteEvent,
+			Obj:                   obj,
+			MultiClusterIngresses: []string{key},
 		}
 	}
 
@@ -604,6 +629,11 @@ func New(
 				return
 			}
 
+			if !store.OwnsMultiClusterIngress(mci) {
+				klog.V(3).InfoS("Ignoring multiclusteringress from another shard", "multiclusteringress", klog.KObj(mci))
+				return
+			}
+
 			ingressClass, err := store.GetIngressClassByMCI(mci, icConfig)
 			if err != nil {
 				klog.InfoS("Ignoring multiclusteringress because of error while validating ingressClass", "multiclusteringress", klog.KObj(mci), "error", err)
@@ -624,8 +654,9 @@ func New(
 			store.syncSecretsByMCI(mci)
 
 			updateCh.In() <- Event{
-				Type: CreateEvent,
-				Obj:  obj,
+				Type:                  CreateEvent,
+				Obj:                   obj,
+				MultiClusterIngresses: []string{k8s.MetaNamespaceKey(mci)},
 			}
 		},
 		DeleteFunc: mciDeleteHandler,
@@ -637,6 +668,14 @@ func New(
 				return
 			}
 
+			if !store.OwnsMultiClusterIngress(curMCI) {
+				if store.OwnsMultiClusterIngress(oldMCI) {
+					klog.InfoS("removing multiclusteringress moved to another shard", "multiclusteringress", klog.KObj(curMCI))
+					mciDeleteHandler(old)
+				}
+				return
+			}
+
 			var errOld, errCur error
 			var ingressClassCur string
 			if !icConfig.IgnoreIngressClass {
@@ -672,8 +711,44 @@ func New(
 			store.syncSecretsByMCI(curMCI)
 
 			updateCh.In() <- Event{
-				Type: UpdateEvent,
-				Obj:  cur,
+				Type:                  UpdateEvent,
+				Obj:                   cur,
+				MultiClusterIngresses: []string{k8s.MetaNamespaceKey(curMCI)},
+			}
+		},
+	}
+
+	// MultiClusterIngresses move between shards when the labels of their namespace change
+	nsEventHandler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldNs := old.(*corev1.Namespace)
+			curNs := cur.(*corev1.Namespace)
+			if reflect.DeepEqual(oldNs.Labels, curNs.Labels) {
+				return
+			}
+
+			objs, err := store.informers.MultiClusterIngress.GetIndexer().ByIndex(cache.NamespaceIndex, curNs.Name)
+			if err != nil {
+				klog.ErrorS(err, "Error listing multiclusteringresses", "namespace", curNs.Name)
+				return
+			}
+
+			for _, obj := range objs {
+				mci, ok := toMultiClusterIngress(obj)
+				if !ok {
+					continue
+				}
+
+				_, err := store.getMultiClusterIngress(k8s.MetaNamespaceKey(mci))
+				stored := err == nil
+				owned := store.OwnsMultiClusterIngress(mci)
+
+				if owned && !stored {
+					mciEventHandler.AddFunc(obj)
+				} else if !owned && stored {
+					klog.InfoS("removing multiclusteringress moved to another shard", "multiclusteringress", klog.KObj(mci))
+					mciDeleteHandler(obj)
+				}
 			}
 		},
 	}
@@ -695,6 +770,7 @@ func New(
 				klog.InfoS("error adding ingressclass to store", "ingressclass", klog.KObj(ingressclass), "error", err)
 				return
 			}
+			store.syncIngressClassDefaultSSLCertificate(ingressclass.Name)
 
 			updateCh.In() <- Event{
 				Type: CreateEvent,
@@ -724,14 +800,13 @@ func New(
 				klog.InfoS("ignoring ingressclass as the spec.controller is not the same of this ingress", "ingressclass", klog.KObj(cic))
 				return
 			}
-			// TODO: In a future we might be interested in parse parameters and use as
-			// current IngressClass for this case, crossing with configmap
 			if !reflect.DeepEqual(cic.Spec.Parameters, oic.Spec.Parameters) {
 				err := store.listers.IngressClass.Update(cic)
 				if err != nil {
 					klog.InfoS("error updating ingressclass in store", "ingressclass", klog.KObj(cic), "error", err)
 					return
 				}
+				store.syncIngressClassDefaultSSLCertificate(cic.Name)
 				updateCh.In() <- Event{
 					Type: UpdateEvent,
 					Obj:  cur,
@@ -745,8 +820,8 @@ func New(
 			sec := obj.(*corev1.Secret)
 			key := k8s.MetaNamespaceKey(sec)
 
-			if store.defaultSSLCertificate == key {
-				store.syncSecret(store.defaultSSLCertificate)
+			if store.defaultSSLCertificate == key || store.isIngressClassDefaultSSLCertificate(key) {
+				store.syncSecret(key)
 			}
 
 			// find references in ingresses and update local ssl certs
@@ -780,8 +855,9 @@ func New(
 					store.syncSecretsByMCI(mci)
 				}
 				updateCh.In() <- Event{
-					Type: CreateEvent,
-					Obj:  obj,
+					Type:                  CreateEvent,
+					Obj:                   obj,
+					MultiClusterIngresses: store.secretChangedMCIs(key, mcis),
 				}
 			}
 		},
@@ -794,8 +870,8 @@ func New(
 					return
 				}
 
-				if store.defaultSSLCertificate == key {
-					store.syncSecret(store.defaultSSLCertificate)
+				if store.defaultSSLCertificate == key || store.isIngressClassDefaultSSLCertificate(key) {
+					store.syncSecret(key)
 				}
 
 				// find references in ingresses and update local ssl certs
@@ -829,8 +905,9 @@ func New(
 						store.syncMultiClusterIngress(mci)
 					}
 					updateCh.In() <- Event{
-						Type: UpdateEvent,
-						Obj:  cur,
+						Type:                  UpdateEvent,
+						Obj:                   cur,
+						MultiClusterIngresses: store.secretChangedMCIs(key, mcis),
 					}
 				}
 			}
@@ -889,8 +966,9 @@ func New(
 				}
 
 				updateCh.In() <- Event{
-					Type: DeleteEvent,
-					Obj:  obj,
+					Type:                  DeleteEvent,
+					Obj:                   obj,
+					MultiClusterIngresses: store.secretChangedMCIs(key, mcis),
 				}
 			}
 		},
@@ -899,14 +977,16 @@ func New(
 	epEventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			updateCh.In() <- Event{
-				Type: CreateEvent,
-				Obj:  obj,
+				Type:     CreateEvent,
+				Obj:      obj,
+				Services: endpointsServiceKeys(obj),
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			updateCh.In() <- Event{
-				Type: DeleteEvent,
-				Obj:  obj,
+				Type:     DeleteEvent,
+				Obj:      obj,
+				Services: endpointsServiceKeys(obj),
 			}
 		},
 		UpdateFunc: func(old, cur interface{}) {
@@ -914,8 +994,9 @@ func New(
 			cep := cur.(*corev1.Endpoints)
 			if !reflect.DeepEqual(cep.Subsets, oep.Subsets) {
 				updateCh.In() <- Event{
-					Type: UpdateEvent,
-					Obj:  cur,
+					Type:     UpdateEvent,
+					Obj:      cur,
+					Services: endpointsServiceKeys(cur),
 				}
 			}
 		},
@@ -924,25 +1005,29 @@ func New(
 	epsEventHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			updateCh.In() <- Event{
-				Type: CreateEvent,
-				Obj:  obj,
+				Type:     CreateEvent,
+				Obj:      obj,
+				Services: endpointsServiceKeys(obj),
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			oldEps := oldObj.(*discoveryv1.EndpointSlice)
 			newEps := newObj.(*discoveryv1.EndpointSlice)
 			if !reflect.DeepEqual(oldEps.Endpoints, newEps.Endpoints) ||
-				!reflect.DeepEqual(oldEps.Ports, newEps.Ports) {
+				!reflect.DeepEqual(oldEps.Ports, newEps.Ports) ||
+				!reflect.DeepEqual(oldEps.Annotations, newEps.Annotations) {
 				updateCh.In() <- Event{
-					Type: UpdateEvent,
-					Obj:  newObj,
+					Type:     UpdateEvent,
+					Obj:      newObj,
+					Services: endpointsServiceKeys(newObj),
 				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			updateCh.In() <- Event{
-				Type: DeleteEvent,
-				Obj:  obj,
+				Type:     DeleteEvent,
+				Obj:      obj,
+				Services: endpointsServiceKeys(obj),
 			}
 		},
 	}
@@ -963,6 +1048,15 @@ func New(
 			}
 		}
 
+		// updates to the parameters of an IngressClass change the TLS
+		// settings of its servers
+		if classes := store.ingressClassesWithParameters(key); len(classes) > 0 {
+			triggerUpdate = true
+			for _, name := range classes {
+				store.syncIngressClassDefaultSSLCertificate(name)
+			}
+		}
+
 		ings := store.listers.IngressWithAnnotation.List()
 		for _, ingKey := range ings {
 			key := k8s.MetaNamespaceKey(ingKey)
@@ -1062,6 +1156,9 @@ func New(
 
 	store.informers.Ingress.AddEventHandler(ingEventHandler)
 	store.informers.MultiClusterIngress.AddEventHandler(mciEventHandler)
+	if shardConfig != nil && shardConfig.By == shard.ByNamespace {
+		store.informers.Namespace.AddEventHandler(nsEventHandler)
+	}
 	if !icConfig.IgnoreIngressClass {
 		store.informers.IngressClass.AddEventHandler(ingressClassEventHandler)
 	}
@@ -1173,6 +1270,8 @@ func (s *k8sStore) updateSecretIngressMap(ing *networkingv1.Ingress) {
 		"auth-tls-secret",
 		"proxy-ssl-secret",
 		"secure-verify-ca-secret",
+		"jwt-auth-jwks-secret",
+		"oidc-secret",
 	}
 	for _, ann := range secretAnnotations {
 		secrKey, err := objectRefAnnotationNsKey(ann, ing)
@@ -1265,6 +1364,86 @@ func (s *k8sStore) GetIngressClass(ing *networkingv1.Ingress, icConfig *ingressc
 	return "", fmt.Errorf("ingress does not contain a valid IngressClass")
 }
 
+// GetIngressClassParameters returns the parameters of an IngressClass, or
+// nil when the IngressClass does not reference parameters
+func (s *k8sStore) GetIngressClassParameters(name string) (*ingressclass.Parameters, error) {
+	if s.listers.IngressClass.Store == nil {
+		return nil, nil
+	}
+
+	ic, err := s.listers.IngressClass.ByKey(name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ingressclass.ParametersConfigMap(ic)
+	if err != nil || key == "" {
+		return nil, err
+	}
+
+	cfgMap, err := s.GetConfigMap(key)
+	if err != nil {
+		return nil, fmt.Errorf("parameters of IngressClass %v: %w", name, err)
+	}
+
+	params, err := ingressclass.ParseParameters(cfgMap.Data)
+	if err != nil {
+		return nil, fmt.Errorf("parameters of IngressClass %v: %w", name, err)
+	}
+
+	return params, nil
+}
+
+// ingressClassesWithParameters returns the names of the IngressClasses
+// referencing the ConfigMap matching key as parameters
+func (s *k8sStore) ingressClassesWithParameters(key string) []string {
+	if s.listers.IngressClass.Store == nil {
+		return nil
+	}
+
+	var classes []string
+	for _, obj := range s.listers.IngressClass.List() {
+		ic := obj.(*networkingv1.IngressClass)
+		if cmKey, err := ingressclass.ParametersConfigMap(ic); err == nil && cmKey == key {
+			classes = append(classes, ic.Name)
+		}
+	}
+
+	return classes
+}
+
+// isIngressClassDefaultSSLCertificate returns if the Secret matching key is
+// the default certificate of one of the IngressClasses
+func (s *k8sStore) isIngressClassDefaultSSLCertificate(key string) bool {
+	if s.listers.IngressClass.Store == nil {
+		return false
+	}
+
+	for _, obj := range s.listers.IngressClass.List() {
+		ic := obj.(*networkingv1.IngressClass)
+		params, err := s.GetIngressClassParameters(ic.Name)
+		if err == nil && params != nil && params.DefaultSSLCertificate == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// syncIngressClassDefaultSSLCertificate adds the default certificate of an
+// IngressClass to the local store
+func (s *k8sStore) syncIngressClassDefaultSSLCertificate(name string) {
+	params, err := s.GetIngressClassParameters(name)
+	if err != nil {
+		klog.Warningf("Error reading parameters of IngressClass %v: %v", name, err)
+		return
+	}
+
+	if params != nil && params.DefaultSSLCertificate != "" {
+		s.syncSecret(params.DefaultSSLCertificate)
+	}
+}
+
 // getIngress returns the Ingress matching key.
 func (s *k8sStore) getIngress(key string) (*networkingv1.Ingress, error) {
 	ing, err := s.listers.IngressWithAnnotation.ByKey(key)
@@ -1423,3 +1602,24 @@ func toIngress(obj interface{}) (*networkingv1.Ingress, bool) {
 
 	return nil, false
 }
+
+// endpointsServiceKeys returns the key of the Service that owns
+// an Endpoints or EndpointSlice object
+func endpointsServiceKeys(obj interface{}) []string {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	switch eps := obj.(type) {
+	case *corev1.Endpoints:
+		return []string{k8s.MetaNamespaceKey(eps)}
+	case *discoveryv1.EndpointSlice:
+		svcName, ok := eps.Labels[discoveryv1.LabelServiceName]
+		if !ok || svcName == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%v/%v", eps.Namespace, svcName)}
+	}
+
+	return nil
+}
@@ -21,6 +21,8 @@ import (
 	"sort"
 
 	karmadanetwork "github.com/karmada-io/karmada/pkg/apis/networking/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -156,6 +158,8 @@ func (s *k8sStore) updateSecretMCIMap(mci *karmadanetwork.MultiClusterIngress) {
 		"auth-tls-secret",
 		"proxy-ssl-secret",
 		"secure-verify-ca-secret",
+		"jwt-auth-jwks-secret",
+		"oidc-secret",
 	}
 	for _, ann := range secretAnnotations {
 		secrKey, err := objectRefAnnotationNsKeyFromMCI(ann, mci)
@@ -218,3 +222,37 @@ func objectRefAnnotationNsKeyFromMCI(ann string, mci *karmadanetwork.MultiCluste
 	}
 	return annValue, nil
 }
+
+// secretChangedMCIs returns the keys of the MultiClusterIngresses affected by a change
+// in a Secret. The default SSL certificate affects every server, so no key is returned.
+func (s *k8sStore) secretChangedMCIs(secrKey string, mcis []string) []string {
+	if secrKey == s.defaultSSLCertificate {
+		return nil
+	}
+
+	return mcis
+}
+
+// OwnsMultiClusterIngress returns if the MultiClusterIngress belongs to the shard of the controller
+func (s *k8sStore) OwnsMultiClusterIngress(mci *karmadanetwork.MultiClusterIngress) bool {
+	return s.shard.Owns(mci, s.namespaceLabels)
+}
+
+// namespaceLabels returns the labels of a namespace from the local store
+func (s *k8sStore) namespaceLabels(namespace string) (labels.Set, bool) {
+	if s.listers.Namespace.Store == nil {
+		return nil, false
+	}
+
+	item, ok, err := s.listers.Namespace.GetByKey(namespace)
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	ns, ok := item.(*corev1.Namespace)
+	if !ok {
+		return nil, false
+	}
+
+	return labels.Set(ns.Labels), true
+}
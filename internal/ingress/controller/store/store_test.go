@@ -144,7 +144,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -227,7 +228,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 		ic := createIngressClass(clientSet, t, "not-k8s.io/not-ingress-nginx")
@@ -333,7 +335,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 		validSpec := commonIngressSpec
@@ -451,7 +454,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			ingressClassconfig)
+			ingressClassconfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -583,7 +587,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			ingressClassconfig)
+			ingressClassconfig,
+			nil)
 
 		storer.Run(stopCh)
 		validSpec := commonIngressSpec
@@ -685,7 +690,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -781,7 +787,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 		invalidSpec := commonIngressSpec
@@ -869,7 +876,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -967,7 +975,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -1093,7 +1102,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -1216,7 +1226,8 @@ func TestStore(t *testing.T) {
 			updateCh,
 			false,
 			true,
-			DefaultClassConfig)
+			DefaultClassConfig,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -31,6 +31,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
@@ -63,7 +64,9 @@ const (
 	globalAuthCacheKey            = "global-auth-cache-key"
 	globalAuthCacheDuration       = "global-auth-cache-duration"
 	luaSharedDictsKey             = "lua-shared-dicts"
+	proxyCacheZonesKey            = "proxy-cache-zones"
 	plugins                       = "plugins"
+	loadBalance                   = "load-balance"
 )
 
 var (
@@ -76,10 +79,17 @@ var (
 		"balancer_ewma_last_touched_at": 10240,
 		"balancer_ewma_locks":           1024,
 		"certificate_servers":           5120,
+		"certificate_alternates":        5120, // keep this same as certificate_servers
 		"ocsp_response_cache":           5120, // keep this same as certificate_servers
 		"global_throttle_cache":         10240,
+		"circuit_breaker":               1024,
 	}
 	defaultGlobalAuthRedirectParam = "rd"
+	cacheSizeRegex                 = regexp.MustCompile(`^\d+[kKmMgG]?$`)
+	cacheZoneNameRegex             = regexp.MustCompile(`^[a-z\d_]+$`)
+	defaultProxyCacheZones         = map[string]config.ProxyCacheZone{
+		"default": {KeysZoneSize: "10m", MaxSize: "1g"},
+	}
 )
 
 const (
@@ -144,6 +154,29 @@ func ReadConfig(src map[string]string) config.Configuration {
 		}
 	}
 
+	proxyCacheZones := make(map[string]config.ProxyCacheZone)
+
+	// parse cache zones with the format name:keys_zone_size:max_size
+	if val, ok := conf[proxyCacheZonesKey]; ok {
+		delete(conf, proxyCacheZonesKey)
+		for _, v := range splitAndTrimSpace(val, ",") {
+			results := strings.Split(strings.Replace(v, " ", "", -1), ":")
+			if len(results) != 3 || !cacheZoneNameRegex.MatchString(results[0]) ||
+				!cacheSizeRegex.MatchString(results[1]) || !cacheSizeRegex.MatchString(results[2]) {
+				klog.Errorf("Ignoring poorly formatted cache zone %v, expected name:keys_zone_size:max_size", v)
+				continue
+			}
+
+			proxyCacheZones[results[0]] = config.ProxyCacheZone{KeysZoneSize: results[1], MaxSize: results[2]}
+		}
+	}
+	// set default cache zones
+	for k, v := range defaultProxyCacheZones {
+		if _, ok := proxyCacheZones[k]; !ok {
+			proxyCacheZones[k] = v
+		}
+	}
+
 	if val, ok := conf[customHTTPErrors]; ok {
 		delete(conf, customHTTPErrors)
 		for _, i := range splitAndTrimSpace(val, ",") {
@@ -362,6 +395,15 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, plugins)
 	}
 
+	if val, ok := conf[loadBalance]; ok {
+		delete(conf, loadBalance)
+		if loadbalancing.IsValidAlgorithm(val) {
+			to.LoadBalancing = val
+		} else {
+			klog.Warningf("%v is not a valid load balancing algorithm, expected one of %v. Using the default.", val, strings.Join(loadbalancing.Algorithms, ", "))
+		}
+	}
+
 	to.CustomHTTPErrors = filterErrors(errors)
 	to.SkipAccessLogURLs = skipUrls
 	to.WhitelistSourceRange = whiteList
@@ -375,6 +417,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 	to.ProxyStreamResponses = streamResponses
 	to.DisableIpv6DNS = !ing_net.IsIPv6Enabled()
 	to.LuaSharedDicts = luaSharedDicts
+	to.ProxyCacheZones = proxyCacheZones
 
 	config := &mapstructure.DecoderConfig{
 		Metadata:         nil,
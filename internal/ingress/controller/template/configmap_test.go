@@ -97,6 +97,7 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 	def.NginxStatusIpv6Whitelist = []string{"::1", "2001::/16"}
 	def.ProxyAddOriginalURIHeader = false
 	def.LuaSharedDicts = defaultLuaSharedDicts
+	def.ProxyCacheZones = defaultProxyCacheZones
 	def.DisableIpv6DNS = true
 	def.DefaultType = "text/plain"
 
@@ -128,6 +129,7 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 
 	def = config.NewDefault()
 	def.LuaSharedDicts = defaultLuaSharedDicts
+	def.ProxyCacheZones = defaultProxyCacheZones
 	def.DisableIpv6DNS = true
 
 	hash, err = hashstructure.Hash(def, &hashstructure.HashOptions{
@@ -147,6 +149,7 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 
 	def = config.NewDefault()
 	def.LuaSharedDicts = defaultLuaSharedDicts
+	def.ProxyCacheZones = defaultProxyCacheZones
 	def.WhitelistSourceRange = []string{"1.1.1.1/32"}
 	def.DisableIpv6DNS = true
 
@@ -207,6 +210,26 @@ func TestGlobalExternalAuthMethodParsing(t *testing.T) {
 	}
 }
 
+func TestLoadBalanceParsing(t *testing.T) {
+	def := config.NewDefault().LoadBalancing
+
+	testCases := map[string]struct {
+		algorithm string
+		expect    string
+	}{
+		"invalid algorithm": {"ip_hash", def},
+		"ewma":              {"ewma", "ewma"},
+		"least request":     {"least_request", "least_request"},
+	}
+
+	for n, tc := range testCases {
+		cfg := ReadConfig(map[string]string{"load-balance": tc.algorithm})
+		if cfg.LoadBalancing != tc.expect {
+			t.Errorf("Testing %v. Expected \"%v\" but \"%v\" was returned", n, tc.expect, cfg.LoadBalancing)
+		}
+	}
+}
+
 func TestGlobalExternalAuthSigninParsing(t *testing.T) {
 	errorURL := ""
 	validURL := "http://bar.foo.com/auth-error-page"
@@ -388,6 +411,51 @@ func TestLuaSharedDictsParsing(t *testing.T) {
 	}
 }
 
+func TestProxyCacheZonesParsing(t *testing.T) {
+	testsCases := []struct {
+		name   string
+		entry  map[string]string
+		expect map[string]config.ProxyCacheZone
+	}{
+		{
+			name:   "default zone configured when proxy-cache-zones is not set",
+			entry:  make(map[string]string),
+			expect: defaultProxyCacheZones,
+		},
+		{
+			name:  "custom zones",
+			entry: map[string]string{"proxy-cache-zones": "static: 64m:10g, api:16m:512m"},
+			expect: map[string]config.ProxyCacheZone{
+				"default": {KeysZoneSize: "10m", MaxSize: "1g"},
+				"static":  {KeysZoneSize: "64m", MaxSize: "10g"},
+				"api":     {KeysZoneSize: "16m", MaxSize: "512m"},
+			},
+		},
+		{
+			name:  "default zone resized",
+			entry: map[string]string{"proxy-cache-zones": "default:32m:2g"},
+			expect: map[string]config.ProxyCacheZone{
+				"default": {KeysZoneSize: "32m", MaxSize: "2g"},
+			},
+		},
+		{
+			name:  "invalid zones should be ignored",
+			entry: map[string]string{"proxy-cache-zones": "static:64m, Bad:1m:1g, big:1mb:1g, ok:1m:1g"},
+			expect: map[string]config.ProxyCacheZone{
+				"default": {KeysZoneSize: "10m", MaxSize: "1g"},
+				"ok":      {KeysZoneSize: "1m", MaxSize: "1g"},
+			},
+		},
+	}
+
+	for _, tc := range testsCases {
+		cfg := ReadConfig(tc.entry)
+		if !reflect.DeepEqual(cfg.ProxyCacheZones, tc.expect) {
+			t.Errorf("Testing %v. Expected \"%v\" but \"%v\" was returned", tc.name, tc.expect, cfg.ProxyCacheZones)
+		}
+	}
+}
+
 func TestSplitAndTrimSpace(t *testing.T) {
 	testsCases := []struct {
 		name   string
@@ -41,7 +41,9 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/headermodifier"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
@@ -228,12 +230,23 @@ var (
 		"shouldApplyGlobalAuth":           shouldApplyGlobalAuth,
 		"buildAuthResponseHeaders":        buildAuthResponseHeaders,
 		"buildAuthProxySetHeaders":        buildAuthProxySetHeaders,
+		"isRequestHeaderModified":         isRequestHeaderModified,
+		"buildRequestHeaders":             buildRequestHeaders,
+		"buildResponseHeaders":            buildResponseHeaders,
+		"buildProxyCache":                 buildProxyCache,
+		"faultInjectionConfigForLua":      faultInjectionConfigForLua,
+		"hstsConfigForLua":                hstsConfigForLua,
+		"buildProxyCacheZones":            buildProxyCacheZones,
 		"buildProxyPass":                  buildProxyPass,
 		"filterRateLimits":                filterRateLimits,
 		"buildRateLimitZones":             buildRateLimitZones,
 		"buildRateLimit":                  buildRateLimit,
 		"configForLua":                    configForLua,
 		"locationConfigForLua":            locationConfigForLua,
+		"jwtAuthConfigForLua":             jwtAuthConfigForLua,
+		"oidcConfigForLua":                oidcConfigForLua,
+		"buildOIDCCallbacks":              buildOIDCCallbacks,
+		"isOIDCEnabled":                   isOIDCEnabled,
 		"buildResolvers":                  buildResolvers,
 		"buildUpstreamName":               buildUpstreamName,
 		"isLocationInLocationList":        isLocationInLocationList,
@@ -451,6 +464,195 @@ func locationConfigForLua(l interface{}, a interface{}) string {
 	)
 }
 
+// jwtAuthConfigForLua returns the JSON Web Token validation of a location as
+// the Lua table expected by jwt_auth.access
+func jwtAuthConfigForLua(l interface{}) string {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was given", l)
+		return "{}"
+	}
+
+	cfg := location.JWTAuth
+
+	audiences := []string{}
+	for _, audience := range cfg.Audiences {
+		audiences = append(audiences, luaString(audience))
+	}
+
+	return fmt.Sprintf(`{
+		jwks_key = %v,
+		issuer = %v,
+		audiences = { %v },
+		required_claims = %v,
+		claim_headers = %v,
+	}`,
+		luaString(cfg.JWKSKey),
+		luaString(cfg.Issuer),
+		strings.Join(audiences, ", "),
+		luaStringMap(cfg.RequiredClaims),
+		luaStringMap(cfg.ClaimHeaders),
+	)
+}
+
+// faultInjectionConfigForLua returns the faults injected in a location as the
+// Lua table expected by fault_injection.inject
+func faultInjectionConfigForLua(l interface{}) string {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was given", l)
+		return "{}"
+	}
+
+	cfg := location.FaultInjection
+	fields := []string{}
+
+	if cfg.DelayMax > 0 {
+		fields = append(fields,
+			fmt.Sprintf("delay_min = %v,", cfg.DelayMin),
+			fmt.Sprintf("delay_max = %v,", cfg.DelayMax),
+			fmt.Sprintf("delay_percentage = %v,", cfg.DelayPercentage))
+	}
+
+	if cfg.AbortStatus > 0 {
+		fields = append(fields,
+			fmt.Sprintf("abort_status = %v,", cfg.AbortStatus),
+			fmt.Sprintf("abort_percentage = %v,", cfg.AbortPercentage))
+	}
+
+	if cfg.Header != "" {
+		hvar := strings.NewReplacer("-", "_").Replace(strings.ToLower(cfg.Header))
+		fields = append(fields, fmt.Sprintf("header_var = %v,", luaString("http_"+hvar)))
+		if cfg.HeaderValue != "" {
+			fields = append(fields, fmt.Sprintf("header_value = %v,", luaString(cfg.HeaderValue)))
+		}
+	}
+
+	return fmt.Sprintf("{ %v }", strings.Join(fields, " "))
+}
+
+// hstsConfigForLua returns the HSTS configuration of a server as the Lua
+// table expected by lua_ingress.header, or nil to use the global configuration
+func hstsConfigForLua(s interface{}) string {
+	server, ok := s.(*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Server' type but %T was given", s)
+		return "nil"
+	}
+
+	if server.HSTS == nil {
+		return "nil"
+	}
+
+	return fmt.Sprintf("{ hsts = %t, hsts_max_age = %v, hsts_include_subdomains = %t, hsts_preload = %t }",
+		server.HSTS.Enable, server.HSTS.MaxAge, server.HSTS.IncludeSubdomains, server.HSTS.Preload)
+}
+
+// oidcConfigForLua returns the OpenID Connect login of a location as the Lua
+// table expected by oidc.access and oidc.callback
+func oidcConfigForLua(c interface{}) string {
+	cfg, ok := c.(oidc.Config)
+	if !ok {
+		klog.Errorf("expected an 'oidc.Config' type but %T was given", c)
+		return "{}"
+	}
+
+	return fmt.Sprintf(`{
+		key = %v,
+		issuer = %v,
+		client_id = %v,
+		scopes = %v,
+		redirect_path = %v,
+	}`,
+		luaString(cfg.Key),
+		luaString(cfg.Issuer),
+		luaString(cfg.ClientID),
+		luaString(strings.Join(cfg.Scopes, " ")),
+		luaString(cfg.RedirectPath),
+	)
+}
+
+// buildOIDCCallbacks returns the OpenID Connect logins of the locations of a
+// server with a distinct redirect path. Each path is handled by the first
+// location using it.
+func buildOIDCCallbacks(input interface{}) []oidc.Config {
+	locations, ok := input.([]*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Location' type but %T was given", input)
+		return []oidc.Config{}
+	}
+
+	callbacks := []oidc.Config{}
+	paths := sets.NewString()
+	for _, location := range locations {
+		if location.OIDC.Key == "" || paths.Has(location.OIDC.RedirectPath) {
+			continue
+		}
+		paths.Insert(location.OIDC.RedirectPath)
+		callbacks = append(callbacks, location.OIDC)
+	}
+
+	return callbacks
+}
+
+// isOIDCEnabled returns true if a location of the servers logs in with an
+// OpenID provider
+func isOIDCEnabled(input interface{}) bool {
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Server' type but %T was given", input)
+		return false
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.OIDC.Key != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// luaString returns a Lua string literal, escaping the quotes, backslashes
+// and non printable characters
+func luaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// luaStringMap returns a Lua table with the entries of the map sorted by key
+func luaStringMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := []string{}
+	for _, k := range keys {
+		entries = append(entries, fmt.Sprintf("[%v] = %v", luaString(k), luaString(m[k])))
+	}
+
+	return fmt.Sprintf("{ %v }", strings.Join(entries, ", "))
+}
+
 // buildResolvers returns the resolvers reading the /etc/resolv.conf file
 func buildResolvers(res interface{}, disableIpv6 interface{}) string {
 	// NGINX need IPV6 addresses to be surrounded by brackets
@@ -602,6 +804,178 @@ func buildAuthProxySetHeaders(headers map[string]string) []string {
 	return res
 }
 
+// buildRequestHeaders returns the directives modifying the headers of the
+// requests sent to the upstream. Added headers keep the value sent by the client.
+func buildRequestHeaders(proxySetHeader string, headers headermodifier.Config) []string {
+	res := []string{}
+
+	for _, h := range headers.RequestSet {
+		res = append(res, fmt.Sprintf("%s %s \"%s\";", proxySetHeader, h.Name, h.Value))
+	}
+
+	added := sets.NewString()
+	for _, h := range headers.RequestAdd {
+		if !added.Has(h.Name) {
+			hvar := strings.NewReplacer("-", "_").Replace(strings.ToLower(h.Name))
+			res = append(res, fmt.Sprintf("%s %s $http_%s;", proxySetHeader, h.Name, hvar))
+			added.Insert(h.Name)
+		}
+		res = append(res, fmt.Sprintf("%s %s \"%s\";", proxySetHeader, h.Name, h.Value))
+	}
+
+	for _, name := range headers.RequestRemove {
+		res = append(res, fmt.Sprintf("%s %s \"\";", proxySetHeader, name))
+	}
+
+	return res
+}
+
+// isRequestHeaderModified returns true if the headers of a location set, add or
+// remove a request header, so the value set by default is left out and the
+// header is not sent twice or kept when it is removed.
+func isRequestHeaderModified(headers headermodifier.Config, name string) bool {
+	for _, h := range headers.RequestSet {
+		if strings.EqualFold(h.Name, name) {
+			return true
+		}
+	}
+
+	for _, h := range headers.RequestAdd {
+		if strings.EqualFold(h.Name, name) {
+			return true
+		}
+	}
+
+	for _, n := range headers.RequestRemove {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// buildResponseHeaders returns the directives modifying the headers of the
+// responses sent to the client. Added headers keep the value sent by the upstream.
+func buildResponseHeaders(headers headermodifier.Config) []string {
+	res := []string{}
+
+	for _, h := range headers.ResponseSet {
+		res = append(res, fmt.Sprintf("more_set_headers \"%s: %s\";", h.Name, h.Value))
+	}
+
+	for _, h := range headers.ResponseAdd {
+		res = append(res, fmt.Sprintf("add_header %s \"%s\" always;", h.Name, h.Value))
+	}
+
+	for _, name := range headers.ResponseRemove {
+		res = append(res, fmt.Sprintf("more_clear_headers %s;", name))
+	}
+
+	return res
+}
+
+// proxyCacheZoneName returns the name of the shared memory zone of a cache zone
+func proxyCacheZoneName(zone string) string {
+	return fmt.Sprintf("mci_cache_%v", zone)
+}
+
+// buildProxyCacheZones returns the proxy_cache_path directives of the cache
+// zones used by the locations of the servers
+func buildProxyCacheZones(c interface{}, s interface{}) []string {
+	res := []string{}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was given", c)
+		return res
+	}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Server' type but %T was given", s)
+		return res
+	}
+
+	used := sets.NewString()
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.ProxyCache.Enabled {
+				used.Insert(location.ProxyCache.Zone)
+			}
+		}
+	}
+
+	for _, name := range used.List() {
+		zone, ok := cfg.ProxyCacheZones[name]
+		if !ok {
+			continue
+		}
+		res = append(res, fmt.Sprintf("proxy_cache_path /tmp/nginx-cache/%v levels=1:2 keys_zone=%v:%v max_size=%v inactive=%v use_temp_path=off;",
+			name, proxyCacheZoneName(name), zone.KeysZoneSize, zone.MaxSize, cfg.ProxyCacheInactive))
+	}
+
+	return res
+}
+
+// buildProxyCache returns the directives caching the responses of a location.
+// Locations using a cache zone that is not configured are not cached, and the
+// requests with an Authorization header or cookies bypass the cache.
+func buildProxyCache(l interface{}, c interface{}) []string {
+	res := []string{}
+
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was given", l)
+		return res
+	}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was given", c)
+		return res
+	}
+
+	cache := location.ProxyCache
+	if !cache.Enabled {
+		return res
+	}
+
+	if _, ok := cfg.ProxyCacheZones[cache.Zone]; !ok {
+		klog.Warningf("Cache zone %q of location %q is not configured in proxy-cache-zones, the responses are not cached", cache.Zone, location.Path)
+		return res
+	}
+
+	res = append(res,
+		fmt.Sprintf("proxy_cache %v;", proxyCacheZoneName(cache.Zone)),
+		fmt.Sprintf("proxy_cache_key \"%v\";", cache.Key))
+
+	for _, valid := range cache.Valid {
+		res = append(res, fmt.Sprintf("proxy_cache_valid %v;", valid))
+	}
+
+	// the responses to the requests with credentials are never cached nor
+	// served from the cache, so they cannot leak to other clients
+	vars := []string{"$http_authorization", "$http_cookie"}
+	seen := sets.NewString(vars...)
+	for _, name := range cache.Bypass {
+		v := fmt.Sprintf("$http_%v", strings.NewReplacer("-", "_").Replace(strings.ToLower(name)))
+		if !seen.Has(v) {
+			seen.Insert(v)
+			vars = append(vars, v)
+		}
+	}
+	res = append(res,
+		fmt.Sprintf("proxy_cache_bypass %v;", strings.Join(vars, " ")),
+		fmt.Sprintf("proxy_no_cache %v;", strings.Join(vars, " ")))
+
+	if !cache.HonorCacheControl {
+		res = append(res, "proxy_ignore_headers Cache-Control Expires;")
+	}
+
+	return res
+}
+
 // buildProxyPass produces the proxy pass string, if the ingress has redirects
 // (specified through the nginx.ingress.kubernetes.io/rewrite-target annotation)
 // If the annotation nginx.ingress.kubernetes.io/add-base-url:"true" is specified it will
@@ -1548,13 +1922,16 @@ func buildModSecurityForLocation(cfg config.Configuration, location *ingress.Loc
 	return buffer.String()
 }
 
-func buildMirrorLocations(locs []*ingress.Location) string {
+// buildMirrorLocations returns the internal locations receiving the copies of
+// the requests. The copies sent to a backend go through the Lua balancer, are
+// marked with the X-Mirrored-Request header and reported in the mirror metrics.
+func buildMirrorLocations(locs []*ingress.Location, enableMetrics bool) string {
 	var buffer bytes.Buffer
 
 	mapped := sets.String{}
 
 	for _, loc := range locs {
-		if loc.Mirror.Source == "" || loc.Mirror.Target == "" {
+		if loc.Mirror.Source == "" || (loc.Mirror.Target == "" && loc.Mirror.Upstream == "") {
 			continue
 		}
 
@@ -1563,17 +1940,60 @@ func buildMirrorLocations(locs []*ingress.Location) string {
 		}
 
 		mapped.Insert(loc.Mirror.Source)
+
+		if loc.Mirror.Upstream == "" {
+			buffer.WriteString(fmt.Sprintf(`location = %v {
+internal;
+%vproxy_pass %v;
+}
+
+`, loc.Mirror.Source, mirrorSampling(loc.Mirror.Sample, ""), loc.Mirror.Target))
+			continue
+		}
+
 		buffer.WriteString(fmt.Sprintf(`location = %v {
 internal;
-proxy_pass %v;
+log_subrequest on;
+%vproxy_set_header Host $best_http_host;
+proxy_set_header X-Request-ID $req_id;
+proxy_set_header X-Real-IP $remote_addr;
+proxy_set_header X-Forwarded-For $remote_addr;
+proxy_set_header X-Mirrored-Request "true";
+proxy_pass http://upstream_balancer$request_uri;
+log_by_lua_block {
+balancer.log()
+mirror.log(%v)
+}
 }
 
-`, loc.Mirror.Source, loc.Mirror.Target))
+`, loc.Mirror.Source, mirrorSampling(loc.Mirror.Sample, loc.Mirror.Upstream), enableMetrics))
 	}
 
 	return buffer.String()
 }
 
+// mirrorSampling returns the rewrite phase of a mirror location, which drops
+// the copies of the requests outside of the sample and selects the backend
+// of the copies
+func mirrorSampling(sample int, upstream string) string {
+	if upstream == "" && (sample <= 0 || sample >= 100) {
+		return ""
+	}
+
+	config := []string{}
+	if sample > 0 && sample < 100 {
+		config = append(config, fmt.Sprintf("sample = %v", sample))
+	}
+	if upstream != "" {
+		config = append(config, fmt.Sprintf("backend = %q", upstream))
+	}
+
+	return fmt.Sprintf(`rewrite_by_lua_block {
+mirror.rewrite({ %v })
+}
+`, strings.Join(config, ", "))
+}
+
 // shouldLoadAuthDigestModule determines whether or not the ngx_http_auth_digest_module module needs to be loaded.
 func shouldLoadAuthDigestModule(s interface{}) bool {
 	servers, ok := s.([]*ingress.Server)
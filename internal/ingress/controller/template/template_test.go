@@ -36,9 +36,15 @@ import (
 
 	"k8s.io/ingress-nginx/internal/ingress"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/headermodifier"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/faultinjection"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/jwtauth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
@@ -533,6 +539,273 @@ func TestBuildAuthProxySetHeaders(t *testing.T) {
 	}
 }
 
+func TestBuildRequestHeaders(t *testing.T) {
+	headers := headermodifier.Config{
+		RequestSet:    []headermodifier.Header{{Name: "X-Tenant", Value: "blue"}},
+		RequestAdd:    []headermodifier.Header{{Name: "X-Tags", Value: "edge"}, {Name: "X-Tags", Value: "eu"}},
+		RequestRemove: []string{"X-Debug"},
+	}
+	expected := []string{
+		`grpc_set_header X-Tenant "blue";`,
+		`grpc_set_header X-Tags $http_x_tags;`,
+		`grpc_set_header X-Tags "edge";`,
+		`grpc_set_header X-Tags "eu";`,
+		`grpc_set_header X-Debug "";`,
+	}
+
+	lines := buildRequestHeaders("grpc_set_header", headers)
+
+	if !reflect.DeepEqual(expected, lines) {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, lines)
+	}
+}
+
+func TestBuildResponseHeaders(t *testing.T) {
+	headers := headermodifier.Config{
+		ResponseSet:    []headermodifier.Header{{Name: "Cache-Control", Value: "no-store"}},
+		ResponseAdd:    []headermodifier.Header{{Name: "Link", Value: "</style.css>; rel=preload"}},
+		ResponseRemove: []string{"Server"},
+	}
+	expected := []string{
+		`more_set_headers "Cache-Control: no-store";`,
+		`add_header Link "</style.css>; rel=preload" always;`,
+		`more_clear_headers Server;`,
+	}
+
+	lines := buildResponseHeaders(headers)
+
+	if !reflect.DeepEqual(expected, lines) {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, lines)
+	}
+}
+
+func TestIsRequestHeaderModified(t *testing.T) {
+	headers := headermodifier.Config{
+		RequestSet:     []headermodifier.Header{{Name: "X-Forwarded-For", Value: "$remote_addr"}},
+		RequestAdd:     []headermodifier.Header{{Name: "X-Request-ID", Value: "edge"}},
+		RequestRemove:  []string{"X-Real-IP"},
+		ResponseRemove: []string{"Host"},
+	}
+
+	testCases := map[string]bool{
+		"X-Forwarded-For": true,
+		"x-request-id":    true,
+		"X-REAL-IP":       true,
+		"Host":            false,
+		"X-Scheme":        false,
+	}
+
+	for name, expected := range testCases {
+		if modified := isRequestHeaderModified(headers, name); modified != expected {
+			t.Errorf("%v: expected %v but returned %v", name, expected, modified)
+		}
+	}
+}
+
+func TestBuildProxyCache(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.ProxyCacheZones = defaultProxyCacheZones
+	location := &ingress.Location{
+		Path: "/assets",
+		ProxyCache: proxycache.Config{
+			Enabled:           true,
+			Zone:              "default",
+			Key:               "$scheme$host$request_uri",
+			Valid:             []string{"200 302 10m", "any 30s"},
+			Bypass:            []string{"X-No-Cache", "Authorization"},
+			HonorCacheControl: false,
+		},
+	}
+	expected := []string{
+		`proxy_cache mci_cache_default;`,
+		`proxy_cache_key "$scheme$host$request_uri";`,
+		`proxy_cache_valid 200 302 10m;`,
+		`proxy_cache_valid any 30s;`,
+		`proxy_cache_bypass $http_authorization $http_cookie $http_x_no_cache;`,
+		`proxy_no_cache $http_authorization $http_cookie $http_x_no_cache;`,
+		`proxy_ignore_headers Cache-Control Expires;`,
+	}
+
+	if lines := buildProxyCache(location, cfg); !reflect.DeepEqual(expected, lines) {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, lines)
+	}
+
+	location.ProxyCache.Bypass = nil
+	location.ProxyCache.HonorCacheControl = true
+	expected = []string{
+		`proxy_cache mci_cache_default;`,
+		`proxy_cache_key "$scheme$host$request_uri";`,
+		`proxy_cache_valid 200 302 10m;`,
+		`proxy_cache_valid any 30s;`,
+		`proxy_cache_bypass $http_authorization $http_cookie;`,
+		`proxy_no_cache $http_authorization $http_cookie;`,
+	}
+
+	if lines := buildProxyCache(location, cfg); !reflect.DeepEqual(expected, lines) {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, lines)
+	}
+
+	location.ProxyCache.Zone = "missing"
+	if lines := buildProxyCache(location, cfg); len(lines) != 0 {
+		t.Errorf("Expected no directives for a zone that is not configured but returned %v", lines)
+	}
+
+	location.ProxyCache.Enabled = false
+	location.ProxyCache.Zone = "default"
+	if lines := buildProxyCache(location, cfg); len(lines) != 0 {
+		t.Errorf("Expected no directives for a location without cache but returned %v", lines)
+	}
+}
+
+func TestBuildProxyCacheZones(t *testing.T) {
+	cfg := config.NewDefault()
+	cfg.ProxyCacheZones = map[string]config.ProxyCacheZone{
+		"default": {KeysZoneSize: "10m", MaxSize: "1g"},
+		"static":  {KeysZoneSize: "64m", MaxSize: "10g"},
+		"unused":  {KeysZoneSize: "1m", MaxSize: "1g"},
+	}
+	servers := []*ingress.Server{
+		{
+			Locations: []*ingress.Location{
+				{ProxyCache: proxycache.Config{Enabled: true, Zone: "static"}},
+				{ProxyCache: proxycache.Config{Enabled: true, Zone: "default"}},
+				{ProxyCache: proxycache.Config{Enabled: true, Zone: "missing"}},
+				{},
+			},
+		},
+		{
+			Locations: []*ingress.Location{
+				{ProxyCache: proxycache.Config{Enabled: true, Zone: "static"}},
+			},
+		},
+	}
+	expected := []string{
+		"proxy_cache_path /tmp/nginx-cache/default levels=1:2 keys_zone=mci_cache_default:10m max_size=1g inactive=10m use_temp_path=off;",
+		"proxy_cache_path /tmp/nginx-cache/static levels=1:2 keys_zone=mci_cache_static:64m max_size=10g inactive=10m use_temp_path=off;",
+	}
+
+	if zones := buildProxyCacheZones(cfg, servers); !reflect.DeepEqual(expected, zones) {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, zones)
+	}
+}
+
+func TestJWTAuthConfigForLua(t *testing.T) {
+	location := &ingress.Location{
+		JWTAuth: jwtauth.Config{
+			JWKSKey:        "url:https://issuer.example.com/jwks.json",
+			Issuer:         "https://issuer.example.com/",
+			Audiences:      []string{"api", "web"},
+			RequiredClaims: map[string]string{"scope": "write", "role": "admin"},
+			ClaimHeaders:   map[string]string{"sub": "X-User"},
+		},
+	}
+	expected := `{
+		jwks_key = "url:https://issuer.example.com/jwks.json",
+		issuer = "https://issuer.example.com/",
+		audiences = { "api", "web" },
+		required_claims = { ["role"] = "admin", ["scope"] = "write" },
+		claim_headers = { ["sub"] = "X-User" },
+	}`
+
+	if actual := jwtAuthConfigForLua(location); actual != expected {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+
+	if actual := luaString("a\"b\\c\n"); actual != `"a\"b\\c\010"` {
+		t.Errorf("unexpected escaped string %v", actual)
+	}
+}
+
+func TestFaultInjectionConfigForLua(t *testing.T) {
+	location := &ingress.Location{
+		FaultInjection: faultinjection.Config{
+			DelayMin:        100,
+			DelayMax:        2000,
+			DelayPercentage: 50,
+			AbortStatus:     503,
+			AbortPercentage: 12.5,
+			Header:          "X-Fault-Inject",
+			HeaderValue:     "checkout",
+		},
+	}
+	expected := `{ delay_min = 100, delay_max = 2000, delay_percentage = 50, abort_status = 503, abort_percentage = 12.5, ` +
+		`header_var = "http_x_fault_inject", header_value = "checkout", }`
+
+	if actual := faultInjectionConfigForLua(location); actual != expected {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+
+	location.FaultInjection = faultinjection.Config{AbortStatus: 429, AbortPercentage: 100}
+	expected = `{ abort_status = 429, abort_percentage = 100, }`
+	if actual := faultInjectionConfigForLua(location); actual != expected {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+}
+
+func TestHSTSConfigForLua(t *testing.T) {
+	server := &ingress.Server{}
+	if actual := hstsConfigForLua(server); actual != "nil" {
+		t.Errorf("Expected 'nil' but returned '%v'", actual)
+	}
+
+	server.HSTS = &ingress.HSTS{Enable: true, MaxAge: "63072000", Preload: true}
+	expected := `{ hsts = true, hsts_max_age = 63072000, hsts_include_subdomains = false, hsts_preload = true }`
+	if actual := hstsConfigForLua(server); actual != expected {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+}
+
+func TestOIDCConfigForLua(t *testing.T) {
+	cfg := oidc.Config{
+		Issuer:       "https://issuer.example.com",
+		ClientID:     "dashboard",
+		Scopes:       []string{"openid", "email"},
+		RedirectPath: "/oauth2/callback",
+		Key:          "0123456789abcdef",
+		Secrets:      oidc.Secrets{ClientSecret: "client-secret", CookieSecret: "cookie-secret"},
+	}
+	expected := `{
+		key = "0123456789abcdef",
+		issuer = "https://issuer.example.com",
+		client_id = "dashboard",
+		scopes = "openid email",
+		redirect_path = "/oauth2/callback",
+	}`
+
+	actual := oidcConfigForLua(cfg)
+	if actual != expected {
+		t.Errorf("Expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+	if strings.Contains(actual, "secret") {
+		t.Errorf("expected the secrets not to be rendered but returned %v", actual)
+	}
+}
+
+func TestBuildOIDCCallbacks(t *testing.T) {
+	team := oidc.Config{Key: "a", RedirectPath: "/oauth2/callback"}
+	admin := oidc.Config{Key: "b", RedirectPath: "/admin/callback"}
+	locations := []*ingress.Location{
+		{Path: "/"},
+		{Path: "/team", OIDC: team},
+		{Path: "/other", OIDC: oidc.Config{Key: "c", RedirectPath: "/oauth2/callback"}},
+		{Path: "/admin", OIDC: admin},
+	}
+
+	expected := []oidc.Config{team, admin}
+	if actual := buildOIDCCallbacks(locations); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected '%v' but returned '%v'", expected, actual)
+	}
+
+	servers := []*ingress.Server{{Locations: locations[:1]}}
+	if isOIDCEnabled(servers) {
+		t.Errorf("expected OpenID Connect to be disabled")
+	}
+	servers = append(servers, &ingress.Server{Locations: locations})
+	if !isOIDCEnabled(servers) {
+		t.Errorf("expected OpenID Connect to be enabled")
+	}
+}
+
 func TestTemplateWithData(t *testing.T) {
 	pwd, _ := os.Getwd()
 	f, err := os.Open(path.Join(pwd, "../../../../test/data/config.json"))
@@ -577,6 +850,62 @@ func TestTemplateWithData(t *testing.T) {
 	}
 }
 
+func TestTemplateWithModifiedRequestHeaders(t *testing.T) {
+	pwd, _ := os.Getwd()
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.HeaderModifier = headermodifier.Config{
+				RequestSet:    []headermodifier.Header{{Name: "Host", Value: "app.internal"}, {Name: "X-Forwarded-For", Value: "$remote_addr, edge"}},
+				RequestRemove: []string{"X-Real-IP"},
+			}
+		}
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	rt, err := ngxTpl.Write(dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, directive := range []string{
+		`proxy_set_header Host                   $best_http_host;`,
+		`proxy_set_header X-Real-IP              $remote_addr;`,
+		`proxy_set_header X-Forwarded-For        $remote_addr;`,
+		`proxy_set_header X-Forwarded-For        $full_x_forwarded_for;`,
+	} {
+		if strings.Contains(string(rt), directive) {
+			t.Errorf("invalid NGINX template, unexpected default directive %q", directive)
+		}
+	}
+
+	for _, directive := range []string{
+		`proxy_set_header Host "app.internal";`,
+		`proxy_set_header X-Forwarded-For "$remote_addr, edge";`,
+		`proxy_set_header X-Real-IP "";`,
+	} {
+		if !strings.Contains(string(rt), directive) {
+			t.Errorf("invalid NGINX template, expected directive %q not present", directive)
+		}
+	}
+}
+
 func BenchmarkTemplateWithData(b *testing.B) {
 	pwd, _ := os.Getwd()
 	f, err := os.Open(path.Join(pwd, "../../../../test/data/config.json"))
@@ -1941,3 +2270,49 @@ func TestCleanConf(t *testing.T) {
 		t.Errorf("cleanConf result don't match with expected: %s", diff)
 	}
 }
+
+func TestBuildMirrorLocations(t *testing.T) {
+	locs := []*ingress.Location{
+		{Path: "/", Mirror: mirror.Config{Source: "/_mirror-a", RequestBody: "on", Target: "https://test.env.com$request_uri", Sample: 100}},
+		{Path: "/sampled", Mirror: mirror.Config{Source: "/_mirror-b", RequestBody: "on", Target: "https://test.env.com$request_uri", Sample: 25}},
+		{Path: "/cluster", Mirror: mirror.Config{Source: "/_mirror-c-default-app-80-mirror-member2", RequestBody: "on", Cluster: "member2", Sample: 10, Upstream: "default-app-80-mirror-member2"}},
+		{Path: "/unresolved", Mirror: mirror.Config{Source: "/_mirror-d", RequestBody: "on", Backend: "shadow:80", Sample: 100}},
+	}
+
+	expected := `location = /_mirror-a {
+internal;
+proxy_pass https://test.env.com$request_uri;
+}
+
+location = /_mirror-b {
+internal;
+rewrite_by_lua_block {
+mirror.rewrite({ sample = 25 })
+}
+proxy_pass https://test.env.com$request_uri;
+}
+
+location = /_mirror-c-default-app-80-mirror-member2 {
+internal;
+log_subrequest on;
+rewrite_by_lua_block {
+mirror.rewrite({ sample = 10, backend = "default-app-80-mirror-member2" })
+}
+proxy_set_header Host $best_http_host;
+proxy_set_header X-Request-ID $req_id;
+proxy_set_header X-Real-IP $remote_addr;
+proxy_set_header X-Forwarded-For $remote_addr;
+proxy_set_header X-Mirrored-Request "true";
+proxy_pass http://upstream_balancer$request_uri;
+log_by_lua_block {
+balancer.log()
+mirror.log(true)
+}
+}
+
+`
+
+	if actual := buildMirrorLocations(locs, true); actual != expected {
+		t.Errorf("expected mirror locations\n%v\nbut returned\n%v", expected, actual)
+	}
+}
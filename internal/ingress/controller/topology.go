@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+)
+
+// applyZoneAwareRouting restricts the endpoints of the backends with zone
+// aware routing to the zone of the controller. It runs after the health
+// checks so only the healthy endpoints count as capacity.
+func (n *NGINXController) applyZoneAwareRouting(backends []*ingress.Backend) {
+	if n.cfg.Zone == "" {
+		return
+	}
+
+	for i, backend := range backends {
+		if !backend.ZoneAwareRouting.Enabled {
+			continue
+		}
+
+		endpoints, ok := zoneEndpoints(backend.Endpoints, n.cfg.Zone, backend.ZoneAwareRouting.MinCapacity)
+		if !ok {
+			klog.V(3).InfoS("Not enough capacity in the zone, using the endpoints of every zone", "backend", backend.Name, "zone", n.cfg.Zone)
+			continue
+		}
+
+		backends[i] = withEndpoints(backend, endpoints)
+	}
+}
+
+// zoneEndpoints returns the endpoints of a zone, using the topology hints
+// when every endpoint has one, or false when the healthy capacity of the
+// zone is below minCapacity percent of its even share of the capacity of
+// the endpoints.
+func zoneEndpoints(endpoints []ingress.Endpoint, zone string, minCapacity int) ([]ingress.Endpoint, bool) {
+	if len(endpoints) == 0 {
+		return nil, false
+	}
+
+	useHints := true
+	for _, ep := range endpoints {
+		if len(ep.ForZones) == 0 {
+			useHints = false
+			break
+		}
+	}
+
+	zones := map[string]struct{}{}
+	local := []ingress.Endpoint{}
+	total, localCapacity := 0, 0
+	for _, ep := range endpoints {
+		epZones := []string{ep.Zone}
+		if useHints {
+			epZones = ep.ForZones
+		}
+
+		inZone := false
+		for _, z := range epZones {
+			if z == "" {
+				continue
+			}
+			zones[z] = struct{}{}
+			if z == zone {
+				inZone = true
+			}
+		}
+		if inZone {
+			local = append(local, ep)
+		}
+
+		if ep.Health == endpointUnhealthy {
+			continue
+		}
+		capacity := ep.Weight
+		if capacity < 1 {
+			capacity = 1
+		}
+		total += capacity
+		if inZone {
+			localCapacity += capacity
+		}
+	}
+
+	if localCapacity == 0 || localCapacity*len(zones)*100 < total*minCapacity {
+		return nil, false
+	}
+
+	return local, true
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/zoneaware"
+)
+
+func TestZoneEndpoints(t *testing.T) {
+	a1 := ingress.Endpoint{Address: "10.0.0.1", Port: "8080", Zone: "zone-a"}
+	a2 := ingress.Endpoint{Address: "10.0.0.2", Port: "8080", Zone: "zone-a"}
+	b1 := ingress.Endpoint{Address: "10.0.1.1", Port: "8080", Zone: "zone-b"}
+	b2 := ingress.Endpoint{Address: "10.0.1.2", Port: "8080", Zone: "zone-b"}
+
+	unhealthy := func(ep ingress.Endpoint) ingress.Endpoint {
+		ep.Health = endpointUnhealthy
+		return ep
+	}
+	hinted := func(ep ingress.Endpoint, zones ...string) ingress.Endpoint {
+		ep.ForZones = zones
+		return ep
+	}
+
+	testCases := map[string]struct {
+		endpoints   []ingress.Endpoint
+		minCapacity int
+		expected    []ingress.Endpoint
+		local       bool
+	}{
+		"no endpoints": {},
+		"endpoints of the zone": {
+			endpoints:   []ingress.Endpoint{a1, b1, a2, b2},
+			minCapacity: 50,
+			expected:    []ingress.Endpoint{a1, a2},
+			local:       true,
+		},
+		"no endpoint in the zone": {
+			endpoints:   []ingress.Endpoint{b1, b2},
+			minCapacity: 0,
+		},
+		"unhealthy endpoints of the zone": {
+			endpoints:   []ingress.Endpoint{unhealthy(a1), unhealthy(a2), b1},
+			minCapacity: 0,
+		},
+		"capacity of the zone below the minimum": {
+			endpoints:   []ingress.Endpoint{a1, unhealthy(a2), b1, b2},
+			minCapacity: 75,
+		},
+		"capacity of the zone at the minimum": {
+			endpoints:   []ingress.Endpoint{a1, unhealthy(a2), b1, b2},
+			minCapacity: 50,
+			expected:    []ingress.Endpoint{a1, unhealthy(a2)},
+			local:       true,
+		},
+		"weights count as capacity": {
+			endpoints:   []ingress.Endpoint{a1, {Address: "10.0.1.1", Port: "8080", Zone: "zone-b", Weight: 4}},
+			minCapacity: 50,
+		},
+		"topology hints": {
+			endpoints:   []ingress.Endpoint{hinted(a1, "zone-a"), hinted(b1, "zone-a"), hinted(b2, "zone-b")},
+			minCapacity: 50,
+			expected:    []ingress.Endpoint{hinted(a1, "zone-a"), hinted(b1, "zone-a")},
+			local:       true,
+		},
+		"endpoint without topology hint": {
+			endpoints:   []ingress.Endpoint{hinted(a1, "zone-b"), b1},
+			minCapacity: 0,
+			expected:    []ingress.Endpoint{hinted(a1, "zone-b")},
+			local:       true,
+		},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			endpoints, local := zoneEndpoints(tc.endpoints, "zone-a", tc.minCapacity)
+			if local != tc.local {
+				t.Fatalf("expected local %v but got %v", tc.local, local)
+			}
+			if !reflect.DeepEqual(endpoints, tc.expected) {
+				t.Errorf("expected endpoints %+v but got %+v", tc.expected, endpoints)
+			}
+		})
+	}
+}
+
+func TestApplyZoneAwareRouting(t *testing.T) {
+	endpoints := []ingress.Endpoint{
+		{Address: "10.0.0.1", Port: "8080", Zone: "zone-a"},
+		{Address: "10.0.1.1", Port: "8080", Zone: "zone-b"},
+	}
+	enabled := &ingress.Backend{
+		Name:             "enabled",
+		Endpoints:        endpoints,
+		ZoneAwareRouting: zoneaware.Config{Enabled: true, MinCapacity: 50},
+	}
+	disabled := &ingress.Backend{
+		Name:      "disabled",
+		Endpoints: endpoints,
+	}
+	backends := []*ingress.Backend{enabled, disabled}
+
+	n := &NGINXController{cfg: &Configuration{Zone: "zone-a"}}
+	n.applyZoneAwareRouting(backends)
+
+	if !reflect.DeepEqual(backends[0].Endpoints, endpoints[:1]) {
+		t.Errorf("expected the endpoints of the zone but got %+v", backends[0].Endpoints)
+	}
+	if len(enabled.Endpoints) != 2 {
+		t.Errorf("expected the original backend to be left unchanged but got %+v", enabled.Endpoints)
+	}
+	if backends[1] != disabled {
+		t.Errorf("expected the backend without zone aware routing to be left unchanged")
+	}
+}
@@ -29,6 +29,7 @@ import (
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/ingress-nginx/internal/ingress"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/retrypolicy"
 	"k8s.io/klog/v2"
 )
 
@@ -46,6 +47,31 @@ func newUpstream(name string) *ingress.Backend {
 	}
 }
 
+// withEndpoints returns a copy of the backend with the given endpoints. The
+// backends of a new configuration are updated through copies since they can
+// be shared with the running configuration.
+func withEndpoints(backend *ingress.Backend, endpoints []ingress.Endpoint) *ingress.Backend {
+	b := *backend
+	b.Endpoints = endpoints
+	return &b
+}
+
+// newRetryPolicy returns the part of a retry policy applied by the balancer of a backend
+func newRetryPolicy(config retrypolicy.Config) ingress.RetryPolicy {
+	if config.Attempts == 0 {
+		return ingress.RetryPolicy{}
+	}
+
+	return ingress.RetryPolicy{
+		Attempts:         config.Attempts,
+		Methods:          config.Methods,
+		PerTryTimeout:    config.PerTryTimeout,
+		Backoff:          config.Backoff,
+		BudgetPercent:    config.BudgetPercent,
+		BudgetMinRetries: config.BudgetMinRetries,
+	}
+}
+
 // upstreamName returns a formatted upstream name based on namespace, service, and port
 func upstreamName(namespace string, service *networking.IngressServiceBackend) string {
 	if service != nil {
@@ -100,6 +126,8 @@ func rlimitMaxNumFiles() int {
 const (
 	defBinary = "/usr/local/nginx/sbin/nginx"
 	cfgPath   = "/etc/nginx/nginx.conf"
+	// lastGoodCfgPath contains the last configuration accepted by NGINX
+	lastGoodCfgPath = "/etc/nginx/nginx.conf.last-good"
 )
 
 // NginxExecTester defines the interface to execute
@@ -165,4 +165,16 @@ type Backend struct {
 	// By default, the NGINX ingress controller uses a list of all endpoints (Pod IP/port) in the NGINX upstream configuration.
 	// It disables that behavior and instead uses a single upstream in NGINX, the service's Cluster IP and port.
 	ServiceUpstream bool `json:"service-upstream"`
+
+	// Number of seconds during which the traffic sent to a new endpoint of a backend
+	// ramps up linearly from a tenth to its full share. The zero value disables slow start.
+	SlowStartWindow int `json:"slow-start-window"`
+
+	// Restricts the endpoints of the backends to the zone of the controller, or to
+	// the endpoints the topology hints assign to the zone.
+	ZoneAwareRouting bool `json:"zone-aware-routing"`
+
+	// Percentage of its even share of the capacity of a backend the zone of the
+	// controller needs for zone aware routing. Below it every zone is used.
+	ZoneAwareRoutingMinCapacity int `json:"zone-aware-routing-min-capacity"`
 }
@@ -30,7 +30,9 @@ import (
 var (
 	operation        = []string{"controller_namespace", "controller_class", "controller_pod"}
 	ingressOperation = []string{"controller_namespace", "controller_class", "controller_pod", "namespace", "ingress"}
-	sslLabelHost     = []string{"namespace", "class", "host"}
+	sslLabelHost     = []string{"namespace", "class", "host", "key_type"}
+	buildOperation   = []string{"controller_namespace", "controller_class", "controller_pod", "mode"}
+	upstreamEndpoint = []string{"controller_namespace", "controller_class", "controller_pod", "backend", "endpoint"}
 )
 
 // Controller defines base metrics about the ingress controller
@@ -45,6 +47,9 @@ type Controller struct {
 	reloadOperationErrors       *prometheus.CounterVec
 	checkIngressOperation       *prometheus.CounterVec
 	checkIngressOperationErrors *prometheus.CounterVec
+	quarantinedIngress          *prometheus.GaugeVec
+	upstreamHealth              *prometheus.GaugeVec
+	configBuildDuration         *prometheus.HistogramVec
 	sslExpireTime               *prometheus.GaugeVec
 
 	constLabels prometheus.Labels
@@ -143,6 +148,30 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			ingressOperation,
 		),
+		quarantinedIngress: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "quarantined_ingress",
+				Help:      `Indicates the ingress is excluded from the configuration after a failed reload, until it changes`,
+			},
+			ingressOperation,
+		),
+		upstreamHealth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "upstream_health",
+				Help:      `Result of the active health checks of an endpoint, 1 indicates healthy and 0 unhealthy`,
+			},
+			upstreamEndpoint,
+		),
+		configBuildDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "config_build_duration_seconds",
+				Help:      `Time required to build the configuration from the ingresses, by mode (full or incremental)`,
+			},
+			buildOperation,
+		),
 		sslExpireTime: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: PrometheusNamespace,
@@ -204,6 +233,49 @@ func (cm *Controller) IncCheckErrorCount(namespace, name string) {
 	cm.checkIngressOperationErrors.MustCurryWith(cm.constLabels).With(labels).Inc()
 }
 
+// ObserveConfigBuild records the duration of a configuration build
+func (cm *Controller) ObserveConfigBuild(mode string, duration float64) {
+	cm.configBuildDuration.MustCurryWith(cm.constLabels).With(prometheus.Labels{"mode": mode}).Observe(duration)
+}
+
+// SetMCIQuarantined sets or removes the quarantine flag of an ingress
+func (cm *Controller) SetMCIQuarantined(namespace, name string, quarantined bool) {
+	labels := prometheus.Labels{
+		"namespace": namespace,
+		"ingress":   name,
+	}
+
+	if !quarantined {
+		cm.quarantinedIngress.MustCurryWith(cm.constLabels).Delete(labels)
+		return
+	}
+
+	cm.quarantinedIngress.MustCurryWith(cm.constLabels).With(labels).Set(1)
+}
+
+// SetUpstreamHealth sets the health of an endpoint of a backend probed by the active health checks
+func (cm *Controller) SetUpstreamHealth(backend, endpoint string, healthy bool) {
+	labels := prometheus.Labels{
+		"backend":  backend,
+		"endpoint": endpoint,
+	}
+
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	cm.upstreamHealth.MustCurryWith(cm.constLabels).With(labels).Set(value)
+}
+
+// RemoveUpstreamHealth removes the health of an endpoint no longer probed
+func (cm *Controller) RemoveUpstreamHealth(backend, endpoint string) {
+	labels := prometheus.Labels{
+		"backend":  backend,
+		"endpoint": endpoint,
+	}
+	cm.upstreamHealth.MustCurryWith(cm.constLabels).Delete(labels)
+}
+
 // ConfigSuccess set a boolean flag according to the output of the controller configuration reload
 func (cm *Controller) ConfigSuccess(hash uint64, success bool) {
 	if success {
@@ -228,6 +300,9 @@ func (cm Controller) Describe(ch chan<- *prometheus.Desc) {
 	cm.reloadOperationErrors.Describe(ch)
 	cm.checkIngressOperation.Describe(ch)
 	cm.checkIngressOperationErrors.Describe(ch)
+	cm.quarantinedIngress.Describe(ch)
+	cm.upstreamHealth.Describe(ch)
+	cm.configBuildDuration.Describe(ch)
 	cm.sslExpireTime.Describe(ch)
 	cm.leaderElection.Describe(ch)
 	cm.buildInfo.Describe(ch)
@@ -242,22 +317,35 @@ func (cm Controller) Collect(ch chan<- prometheus.Metric) {
 	cm.reloadOperationErrors.Collect(ch)
 	cm.checkIngressOperation.Collect(ch)
 	cm.checkIngressOperationErrors.Collect(ch)
+	cm.quarantinedIngress.Collect(ch)
+	cm.upstreamHealth.Collect(ch)
+	cm.configBuildDuration.Collect(ch)
 	cm.sslExpireTime.Collect(ch)
 	cm.leaderElection.Collect(ch)
 	cm.buildInfo.Collect(ch)
 }
 
-// SetSSLExpireTime sets the expiration time of SSL Certificates
+// SetSSLExpireTime sets the expiration time of SSL Certificates, for
+// each key type of the certificates of a server
 func (cm *Controller) SetSSLExpireTime(servers []*ingress.Server) {
 	for _, s := range servers {
-		if s.Hostname != "" && s.SSLCert != nil && s.SSLCert.ExpireTime.Unix() > 0 {
-			labels := make(prometheus.Labels, len(cm.labels)+1)
+		if s.Hostname == "" {
+			continue
+		}
+
+		for cert := s.SSLCert; cert != nil; cert = cert.Alternate {
+			if cert.ExpireTime.Unix() <= 0 {
+				continue
+			}
+
+			labels := make(prometheus.Labels, len(cm.labels)+2)
 			for k, v := range cm.labels {
 				labels[k] = v
 			}
 			labels["host"] = s.Hostname
+			labels["key_type"] = cert.KeyType
 
-			cm.sslExpireTime.With(labels).Set(float64(s.SSLCert.ExpireTime.Unix()))
+			cm.sslExpireTime.With(labels).Set(float64(cert.ExpireTime.Unix()))
 		}
 	}
 }
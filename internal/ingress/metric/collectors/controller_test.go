@@ -70,6 +70,61 @@ func TestControllerCounters(t *testing.T) {
 			`,
 			metrics: []string{"nginx_ingress_controller_errors"},
 		},
+		{
+			name: "quarantined ingress should be reported until it is released",
+			test: func(cm *Controller) {
+				cm.SetMCIQuarantined("default", "demo", true)
+				cm.SetMCIQuarantined("default", "other", true)
+				cm.SetMCIQuarantined("default", "other", false)
+			},
+			want: `
+				# HELP nginx_ingress_controller_quarantined_ingress Indicates the ingress is excluded from the configuration after a failed reload, until it changes
+				# TYPE nginx_ingress_controller_quarantined_ingress gauge
+				nginx_ingress_controller_quarantined_ingress{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="demo",namespace="default"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_quarantined_ingress"},
+		},
+		{
+			name: "upstream health should be reported until the endpoint is removed",
+			test: func(cm *Controller) {
+				cm.SetUpstreamHealth("default-web-80", "10.0.0.1:8080", true)
+				cm.SetUpstreamHealth("default-web-80", "10.0.0.2:8080", false)
+				cm.SetUpstreamHealth("default-web-80", "10.0.0.3:8080", true)
+				cm.RemoveUpstreamHealth("default-web-80", "10.0.0.3:8080")
+			},
+			want: `
+				# HELP nginx_ingress_controller_upstream_health Result of the active health checks of an endpoint, 1 indicates healthy and 0 unhealthy
+				# TYPE nginx_ingress_controller_upstream_health gauge
+				nginx_ingress_controller_upstream_health{backend="default-web-80",controller_class="nginx",controller_namespace="default",controller_pod="pod",endpoint="10.0.0.1:8080"} 1
+				nginx_ingress_controller_upstream_health{backend="default-web-80",controller_class="nginx",controller_namespace="default",controller_pod="pod",endpoint="10.0.0.2:8080"} 0
+			`,
+			metrics: []string{"nginx_ingress_controller_upstream_health"},
+		},
+		{
+			name: "configuration build should be observed by mode",
+			test: func(cm *Controller) {
+				cm.ObserveConfigBuild("incremental", 0.02)
+			},
+			want: `
+				# HELP nginx_ingress_controller_config_build_duration_seconds Time required to build the configuration from the ingresses, by mode (full or incremental)
+				# TYPE nginx_ingress_controller_config_build_duration_seconds histogram
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.005"} 0
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.01"} 0
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.025"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.05"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.1"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.25"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="0.5"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="1"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="2.5"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="5"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="10"} 1
+				nginx_ingress_controller_config_build_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental",le="+Inf"} 1
+				nginx_ingress_controller_config_build_duration_seconds_sum{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental"} 0.02
+				nginx_ingress_controller_config_build_duration_seconds_count{controller_class="nginx",controller_namespace="default",controller_pod="pod",mode="incremental"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_config_build_duration_seconds"},
+		},
 		{
 			name: "should set SSL certificates metrics",
 			test: func(cm *Controller) {
@@ -77,11 +132,27 @@ func TestControllerCounters(t *testing.T) {
 					time.RFC3339,
 					"2012-11-01T22:08:41+00:00")
 
+				t2, _ := time.Parse(
+					time.RFC3339,
+					"2013-11-01T22:08:41+00:00")
+
 				servers := []*ingress.Server{
 					{
 						Hostname: "demo",
 						SSLCert: &ingress.SSLCert{
 							ExpireTime: t1,
+							KeyType:    "RSA",
+						},
+					},
+					{
+						Hostname: "dual",
+						SSLCert: &ingress.SSLCert{
+							ExpireTime: t1,
+							KeyType:    "RSA",
+							Alternate: &ingress.SSLCert{
+								ExpireTime: t2,
+								KeyType:    "ECDSA",
+							},
 						},
 					},
 					{
@@ -96,7 +167,9 @@ func TestControllerCounters(t *testing.T) {
 			want: `
 				# HELP nginx_ingress_controller_ssl_expire_time_seconds Number of seconds since 1970 to the SSL Certificate expire.\n			An example to check if this certificate will expire in 10 days is: "nginx_ingress_controller_ssl_expire_time_seconds < (time() + (10 * 24 * 3600))"
 				# TYPE nginx_ingress_controller_ssl_expire_time_seconds gauge
-				nginx_ingress_controller_ssl_expire_time_seconds{class="nginx",host="demo",namespace="default"} 1.351807721e+09
+				nginx_ingress_controller_ssl_expire_time_seconds{class="nginx",host="demo",key_type="RSA",namespace="default"} 1.351807721e+09
+				nginx_ingress_controller_ssl_expire_time_seconds{class="nginx",host="dual",key_type="ECDSA",namespace="default"} 1.383343721e+09
+				nginx_ingress_controller_ssl_expire_time_seconds{class="nginx",host="dual",key_type="RSA",namespace="default"} 1.351807721e+09
 			`,
 			metrics: []string{"nginx_ingress_controller_ssl_expire_time_seconds"},
 		},
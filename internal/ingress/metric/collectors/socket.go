@@ -33,9 +33,22 @@ type upstream struct {
 	Latency        float64 `json:"upstreamLatency"`
 	ResponseLength float64 `json:"upstreamResponseLength"`
 	ResponseTime   float64 `json:"upstreamResponseTime"`
+	CacheStatus    string  `json:"upstreamCacheStatus"`
+	// Retries is the number of upstream servers tried after the first one
+	Retries float64 `json:"upstreamRetries"`
+	// RetryBudgetExhausted is true when the retry budget of the backend
+	// forbade retrying the request
+	RetryBudgetExhausted bool `json:"retryBudgetExhausted"`
 	//Status         string  `json:"upstreamStatus"`
 }
 
+// circuitBreakerTransition is the change of state of the circuit breaker of
+// a backend caused by a request
+type circuitBreakerTransition struct {
+	Backend string `json:"backend"`
+	State   string `json:"state"`
+}
+
 type socketData struct {
 	Host   string `json:"host"`
 	Status string `json:"status"`
@@ -54,6 +67,17 @@ type socketData struct {
 	Service   string `json:"service"`
 	Canary    string `json:"canary"`
 	Path      string `json:"path"`
+
+	// InjectedFault is the fault injected in the request by the location, if any
+	InjectedFault string `json:"injectedFault"`
+
+	// Mirror is the backend receiving the copy of a request, empty for the
+	// requests of the clients
+	Mirror string `json:"mirror"`
+
+	// CircuitBreaker is the transition of the circuit breaker of the backend
+	// caused by the request, if any
+	CircuitBreaker *circuitBreakerTransition `json:"circuitBreaker"`
 }
 
 // SocketCollector stores prometheus metrics and ingress meta-data
@@ -72,6 +96,17 @@ type SocketCollector struct {
 
 	requests *prometheus.CounterVec
 
+	cacheRequests *prometheus.CounterVec
+
+	upstreamRetries      *prometheus.CounterVec
+	retryBudgetExhausted *prometheus.CounterVec
+
+	mirrorRequests     *prometheus.CounterVec
+	mirrorResponseTime *prometheus.HistogramVec
+
+	circuitBreakerTransitions *prometheus.CounterVec
+	circuitBreakerOpen        *prometheus.GaugeVec
+
 	listener net.Listener
 
 	metricMapping map[string]interface{}
@@ -92,6 +127,8 @@ var (
 		"ingress",
 		"service",
 		"canary",
+
+		"injected_fault",
 	}
 )
 
@@ -178,7 +215,77 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost bool) (*Soc
 				Namespace:   PrometheusNamespace,
 				ConstLabels: constLabels,
 			},
-			[]string{"ingress", "namespace", "status", "service", "canary"},
+			[]string{"ingress", "namespace", "status", "service", "canary", "injected_fault"},
+		),
+
+		cacheRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "cache_requests",
+				Help:        "The total number of client requests served by a location with a response cache, by cache status.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "service", "canary", "cache_status"},
+		),
+
+		upstreamRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "upstream_retries",
+				Help:        "The total number of requests sent again to another upstream server.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "service", "canary"},
+		),
+
+		retryBudgetExhausted: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "upstream_retry_budget_exhausted",
+				Help:        "The total number of client requests not allowed to be retried because the retry budget of the backend was exhausted.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "service", "canary"},
+		),
+
+		mirrorRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "mirror_requests",
+				Help:        "The total number of copies of the client requests sent to a mirror backend.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "service", "mirror", "status"},
+		),
+
+		mirrorResponseTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        "mirror_response_duration_seconds",
+				Help:        "The time spent on receiving the response to the copy of a request from a mirror backend",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"ingress", "namespace", "service", "mirror"},
+		),
+
+		circuitBreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        "circuit_breaker_transitions",
+				Help:        "The total number of transitions of the circuit breakers of the backends to the open or closed state.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"backend", "state"},
+		),
+
+		circuitBreakerOpen: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        "circuit_breaker_open",
+				Help:        "Whether the circuit breaker of a backend is open, rejecting the requests.",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			[]string{"backend"},
 		),
 
 		bytesSent: prometheus.NewHistogramVec(
@@ -214,6 +321,14 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost bool) (*Soc
 		prometheus.BuildFQName(PrometheusNamespace, "", "bytes_sent"): sc.bytesSent,
 
 		prometheus.BuildFQName(PrometheusNamespace, "", "ingress_upstream_latency_seconds"): sc.upstreamLatency,
+
+		prometheus.BuildFQName(PrometheusNamespace, "", "cache_requests"): sc.cacheRequests,
+
+		prometheus.BuildFQName(PrometheusNamespace, "", "upstream_retries"):                sc.upstreamRetries,
+		prometheus.BuildFQName(PrometheusNamespace, "", "upstream_retry_budget_exhausted"): sc.retryBudgetExhausted,
+
+		prometheus.BuildFQName(PrometheusNamespace, "", "mirror_requests"):                  sc.mirrorRequests,
+		prometheus.BuildFQName(PrometheusNamespace, "", "mirror_response_duration_seconds"): sc.mirrorResponseTime,
 	}
 
 	return sc, nil
@@ -236,6 +351,16 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			continue
 		}
 
+		if stats.CircuitBreaker != nil {
+			sc.handleCircuitBreaker(stats.CircuitBreaker)
+		}
+
+		// the copies of the requests are reported apart from the client requests
+		if stats.Mirror != "" {
+			sc.handleMirror(&stats)
+			continue
+		}
+
 		// Note these must match the order in requestTags at the top
 		requestLabels := prometheus.Labels{
 			"status":    stats.Status,
@@ -245,17 +370,20 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			"ingress":   stats.Ingress,
 			"service":   stats.Service,
 			"canary":    stats.Canary,
+
+			"injected_fault": stats.InjectedFault,
 		}
 		if sc.metricsPerHost {
 			requestLabels["host"] = stats.Host
 		}
 
 		collectorLabels := prometheus.Labels{
-			"namespace": stats.Namespace,
-			"ingress":   stats.Ingress,
-			"status":    stats.Status,
-			"service":   stats.Service,
-			"canary":    stats.Canary,
+			"namespace":      stats.Namespace,
+			"ingress":        stats.Ingress,
+			"status":         stats.Status,
+			"service":        stats.Service,
+			"canary":         stats.Canary,
+			"injected_fault": stats.InjectedFault,
 		}
 
 		latencyLabels := prometheus.Labels{
@@ -272,6 +400,41 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			requestsMetric.Inc()
 		}
 
+		// the cache status is only defined when the location caches the responses
+		if stats.CacheStatus != "" && stats.CacheStatus != "-" {
+			cacheLabels := prometheus.Labels{
+				"namespace":    stats.Namespace,
+				"ingress":      stats.Ingress,
+				"service":      stats.Service,
+				"canary":       stats.Canary,
+				"cache_status": stats.CacheStatus,
+			}
+			cacheMetric, err := sc.cacheRequests.GetMetricWith(cacheLabels)
+			if err != nil {
+				klog.ErrorS(err, "Error fetching cache requests metric")
+			} else {
+				cacheMetric.Inc()
+			}
+		}
+
+		if stats.Retries > 0 {
+			retriesMetric, err := sc.upstreamRetries.GetMetricWith(latencyLabels)
+			if err != nil {
+				klog.ErrorS(err, "Error fetching upstream retries metric")
+			} else {
+				retriesMetric.Add(stats.Retries)
+			}
+		}
+
+		if stats.RetryBudgetExhausted {
+			budgetMetric, err := sc.retryBudgetExhausted.GetMetricWith(latencyLabels)
+			if err != nil {
+				klog.ErrorS(err, "Error fetching retry budget metric")
+			} else {
+				budgetMetric.Inc()
+			}
+		}
+
 		if stats.Latency != -1 {
 			latencyMetric, err := sc.upstreamLatency.GetMetricWith(latencyLabels)
 			if err != nil {
@@ -326,6 +489,73 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 	}
 }
 
+// handleMirror reports the response to the copy of a request sent to a mirror backend
+func (sc *SocketCollector) handleMirror(stats *socketData) {
+	mirrorLabels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"mirror":    stats.Mirror,
+	}
+
+	requestsMetric, err := sc.mirrorRequests.GetMetricWith(prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"mirror":    stats.Mirror,
+		"status":    stats.Status,
+	})
+	if err != nil {
+		klog.ErrorS(err, "Error fetching mirror requests metric")
+	} else {
+		requestsMetric.Inc()
+	}
+
+	if stats.ResponseTime != -1 {
+		responseTimeMetric, err := sc.mirrorResponseTime.GetMetricWith(mirrorLabels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching mirror response time metric")
+		} else {
+			responseTimeMetric.Observe(stats.ResponseTime)
+		}
+	}
+}
+
+// handleCircuitBreaker reports the transition of the circuit breaker of a backend
+func (sc *SocketCollector) handleCircuitBreaker(transition *circuitBreakerTransition) {
+	if transition.Backend == "" {
+		return
+	}
+
+	var open float64
+	switch transition.State {
+	case "open":
+		open = 1
+	case "closed":
+		open = 0
+	default:
+		klog.V(3).InfoS("Unknown circuit breaker state", "backend", transition.Backend, "state", transition.State)
+		return
+	}
+
+	transitionsMetric, err := sc.circuitBreakerTransitions.GetMetricWith(prometheus.Labels{
+		"backend": transition.Backend,
+		"state":   transition.State,
+	})
+	if err != nil {
+		klog.ErrorS(err, "Error fetching circuit breaker transitions metric")
+	} else {
+		transitionsMetric.Inc()
+	}
+
+	openMetric, err := sc.circuitBreakerOpen.GetMetricWith(prometheus.Labels{"backend": transition.Backend})
+	if err != nil {
+		klog.ErrorS(err, "Error fetching circuit breaker state metric")
+	} else {
+		openMetric.Set(open)
+	}
+}
+
 // Start listen for connections in the unix socket and spawns a goroutine to process the content
 func (sc *SocketCollector) Start() {
 	for {
@@ -403,6 +633,14 @@ func (sc *SocketCollector) RemoveMetrics(ingresses []string, registry prometheus
 					klog.V(2).InfoS("metric not removed", "name", metricName, "ingress", ingKey, "labels", labels)
 				}
 			}
+
+			c, ok := metric.(*prometheus.CounterVec)
+			if ok {
+				removed := c.Delete(labels)
+				if !removed {
+					klog.V(2).InfoS("metric not removed", "name", metricName, "ingress", ingKey, "labels", labels)
+				}
+			}
 		}
 	}
 }
@@ -413,6 +651,13 @@ func (sc SocketCollector) Describe(ch chan<- *prometheus.Desc) {
 	sc.requestLength.Describe(ch)
 
 	sc.requests.Describe(ch)
+	sc.cacheRequests.Describe(ch)
+	sc.upstreamRetries.Describe(ch)
+	sc.retryBudgetExhausted.Describe(ch)
+	sc.mirrorRequests.Describe(ch)
+	sc.mirrorResponseTime.Describe(ch)
+	sc.circuitBreakerTransitions.Describe(ch)
+	sc.circuitBreakerOpen.Describe(ch)
 
 	sc.upstreamLatency.Describe(ch)
 
@@ -428,6 +673,13 @@ func (sc SocketCollector) Collect(ch chan<- prometheus.Metric) {
 	sc.requestLength.Collect(ch)
 
 	sc.requests.Collect(ch)
+	sc.cacheRequests.Collect(ch)
+	sc.upstreamRetries.Collect(ch)
+	sc.retryBudgetExhausted.Collect(ch)
+	sc.mirrorRequests.Collect(ch)
+	sc.mirrorResponseTime.Collect(ch)
+	sc.circuitBreakerTransitions.Collect(ch)
+	sc.circuitBreakerOpen.Collect(ch)
 
 	sc.upstreamLatency.Collect(ch)
 
@@ -47,6 +47,18 @@ func (dc DummyCollector) IncCheckCount(string, string) {}
 // IncCheckErrorCount ...
 func (dc DummyCollector) IncCheckErrorCount(string, string) {}
 
+// ObserveConfigBuild ...
+func (dc DummyCollector) ObserveConfigBuild(string, float64) {}
+
+// SetMCIQuarantined ...
+func (dc DummyCollector) SetMCIQuarantined(string, string, bool) {}
+
+// SetUpstreamHealth ...
+func (dc DummyCollector) SetUpstreamHealth(string, string, bool) {}
+
+// RemoveUpstreamHealth ...
+func (dc DummyCollector) RemoveUpstreamHealth(string, string) {}
+
 // RemoveMetrics ...
 func (dc DummyCollector) RemoveMetrics(ingresses, endpoints []string) {}
 
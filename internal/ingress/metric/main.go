@@ -44,6 +44,17 @@ type Collector interface {
 	IncCheckCount(string, string)
 	IncCheckErrorCount(string, string)
 
+	// ObserveConfigBuild records the duration of a configuration build by mode
+	ObserveConfigBuild(string, float64)
+
+	// SetMCIQuarantined reports if a MultiClusterIngress is excluded from the configuration
+	SetMCIQuarantined(string, string, bool)
+
+	// SetUpstreamHealth reports the result of the active health checks of an endpoint
+	SetUpstreamHealth(string, string, bool)
+	// RemoveUpstreamHealth removes the health of an endpoint no longer probed
+	RemoveUpstreamHealth(string, string)
+
 	RemoveMetrics(ingresses, endpoints []string)
 
 	SetSSLExpireTime([]*ingress.Server)
@@ -120,6 +131,22 @@ func (c *collector) IncCheckErrorCount(namespace string, name string) {
 	c.ingressController.IncCheckErrorCount(namespace, name)
 }
 
+func (c *collector) ObserveConfigBuild(mode string, duration float64) {
+	c.ingressController.ObserveConfigBuild(mode, duration)
+}
+
+func (c *collector) SetMCIQuarantined(namespace string, name string, quarantined bool) {
+	c.ingressController.SetMCIQuarantined(namespace, name, quarantined)
+}
+
+func (c *collector) SetUpstreamHealth(backend string, endpoint string, healthy bool) {
+	c.ingressController.SetUpstreamHealth(backend, endpoint, healthy)
+}
+
+func (c *collector) RemoveUpstreamHealth(backend string, endpoint string) {
+	c.ingressController.RemoveUpstreamHealth(backend, endpoint)
+}
+
 func (c *collector) IncReloadCount() {
 	c.ingressController.IncReloadCount()
 }
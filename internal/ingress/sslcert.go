@@ -62,6 +62,14 @@ type SSLCert struct {
 
 	// UID unique identifier of the Kubernetes Secret
 	UID string `json:"uid"`
+
+	// KeyType contains the algorithm of the public key of the certificate, like RSA or ECDSA
+	KeyType string `json:"keyType,omitempty"`
+
+	// Alternate contains a certificate for the same hosts with a different key type.
+	// Both certificates are offered and the TLS handshake selects the one
+	// supported by the client.
+	Alternate *SSLCert `json:"alternate,omitempty"`
 }
 
 // GetObjectKind implements the ObjectKind interface as a noop
@@ -72,7 +80,7 @@ func (s SSLCert) GetObjectKind() schema.ObjectKind {
 // HashInclude defines if a field should be used or not to calculate the hash
 func (s SSLCert) HashInclude(field string, v interface{}) (bool, error) {
 	switch field {
-	case "PemSHA", "CASHA", "ExpireTime":
+	case "PemSHA", "CASHA", "ExpireTime", "Alternate":
 		return true, nil
 	default:
 		return false, nil
@@ -26,21 +26,30 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/circuitbreaker"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/faultinjection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/globalratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/headermodifier"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/healthcheck"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/influxdb"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipwhitelist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/jwtauth"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentracing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/retrypolicy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/zoneaware"
 )
 
 var (
@@ -79,6 +88,21 @@ type Configuration struct {
 	DefaultSSLCertificate *SSLCert `json:"-"`
 
 	StreamSnippets []string
+
+	// JWKS contains the JSON Web Key Sets used by the locations validating
+	// tokens, indexed by the key of the set. They are configured dynamically,
+	// so a change does not require a reload.
+	JWKS map[string]string `json:"-"`
+
+	// OIDCSecrets contains the client and cookie secrets of the OpenID
+	// providers, indexed by the key of the provider. Like the key sets, they
+	// are configured dynamically without a reload.
+	OIDCSecrets map[string]oidc.Secrets `json:"-"`
+
+	// ACMEChallenges contains the responses to the pending ACME HTTP-01
+	// challenges, indexed by token. They are configured dynamically without
+	// a reload.
+	ACMEChallenges map[string]string `json:"-"`
 }
 
 // Backend describes one or more remote server/s (endpoints) associated with a service
@@ -108,6 +132,69 @@ type Backend struct {
 	// Contains a list of backends without servers that are associated with this backend.
 	// +optional
 	AlternativeBackends []string `json:"alternativeBackends,omitempty"`
+	// Contains the list of conditions, evaluated in order, sending requests to other backends.
+	// Requests not satisfying any of them are served by this backend.
+	// +optional
+	RouteMatches []RouteMatch `json:"routeMatches,omitempty"`
+	// RetryPolicy limits the requests retried by the balancer.
+	// +optional
+	RetryPolicy RetryPolicy `json:"retryPolicy,omitempty"`
+	// HealthCheck probes the endpoints from the controller. The endpoints
+	// failing the probes are not used by the balancer.
+	// +optional
+	HealthCheck healthcheck.Config `json:"healthCheck,omitempty"`
+	// SlowStartWindow is the number of seconds during which the balancer ramps
+	// up the traffic sent to the new endpoints.
+	// +optional
+	SlowStartWindow int `json:"slowStartWindow,omitempty"`
+	// ZoneAwareRouting restricts the endpoints to the zone of the controller
+	// while the zone has enough capacity.
+	// +optional
+	ZoneAwareRouting zoneaware.Config `json:"zoneAwareRouting,omitempty"`
+	// CircuitBreaker limits the requests in flight and pending to the backend.
+	// +optional
+	CircuitBreaker circuitbreaker.Config `json:"circuitBreaker,omitempty"`
+}
+
+// RetryPolicy describes which requests sent to a backend the balancer can retry
+// +k8s:deepcopy-gen=true
+type RetryPolicy struct {
+	// Attempts is the maximum number of tries of a request, including the first one.
+	// Zero means the backend has no retry policy.
+	Attempts int `json:"attempts,omitempty"`
+	// Methods is the list of HTTP methods of the requests that can be retried
+	Methods []string `json:"methods,omitempty"`
+	// PerTryTimeout is the timeout in milliseconds of each try
+	PerTryTimeout int `json:"perTryTimeout,omitempty"`
+	// Backoff is the base delay in milliseconds during which an endpoint failing a try
+	// receives no retry
+	Backoff int `json:"backoff,omitempty"`
+	// BudgetPercent is the maximum percentage of retries over the requests of the backend
+	BudgetPercent int `json:"budgetPercent,omitempty"`
+	// BudgetMinRetries is the number of retries per second allowed regardless of BudgetPercent
+	BudgetMinRetries int `json:"budgetMinRetries,omitempty"`
+}
+
+// RouteMatch describes the conditions a request must satisfy to be sent to a backend
+// instead of the backend of the path. Every condition set must be satisfied.
+// +k8s:deepcopy-gen=true
+type RouteMatch struct {
+	// Backend is the name of the backend serving the matching requests
+	Backend string `json:"backend"`
+	// Methods is the list of HTTP methods of the request
+	Methods []string `json:"methods,omitempty"`
+	// Header is the name of a header of the request
+	Header string `json:"header,omitempty"`
+	// HeaderValue is the exact value of the header
+	HeaderValue string `json:"headerValue,omitempty"`
+	// HeaderPattern is a regular expression matching the value of the header
+	HeaderPattern string `json:"headerPattern,omitempty"`
+	// QueryParam is the name of a query parameter of the request
+	QueryParam string `json:"queryParam,omitempty"`
+	// QueryValue is the exact value of the query parameter
+	QueryValue string `json:"queryValue,omitempty"`
+	// QueryPattern is a regular expression matching the value of the query parameter
+	QueryPattern string `json:"queryPattern,omitempty"`
 }
 
 // TrafficShapingPolicy describes the policies to put in place when a backend has no server and is used as an
@@ -186,6 +273,23 @@ type Endpoint struct {
 	Port string `json:"port"`
 	// Target returns a reference to the object providing the endpoint
 	Target *apiv1.ObjectReference `json:"target,omitempty"`
+	// Health is the result of the active health checks of the endpoint, healthy
+	// or unhealthy, or empty when the backend has no health check
+	Health string `json:"health,omitempty"`
+	// Weight is the share of the requests of the backend the endpoint
+	// receives relative to the other endpoints. Zero means the default of 1.
+	Weight int `json:"weight,omitempty"`
+	// Zone is the topology zone of the node running the endpoint
+	Zone string `json:"zone,omitempty"`
+	// ForZones contains the zones the topology hints of the endpoint assign
+	// it to
+	ForZones []string `json:"forZones,omitempty"`
+	// Draining indicates the endpoint was removed from the backend. Until the
+	// end of the draining period, it only receives the requests of its sticky
+	// sessions.
+	Draining bool `json:"draining,omitempty"`
+	// Cluster is the member cluster running the endpoint
+	Cluster string `json:"cluster,omitempty"`
 }
 
 // Server describes a website
@@ -217,10 +321,24 @@ type Server struct {
 	// SSLPreferServerCiphers indicates that server ciphers should be preferred
 	// over client ciphers when using the SSLv3 and TLS protocols.
 	SSLPreferServerCiphers string `json:"sslPreferServerCiphers,omitempty"`
+	// SSLProtocols returns list of protocols to be enabled, overriding the
+	// global configuration
+	SSLProtocols string `json:"sslProtocols,omitempty"`
+	// HSTS contains the HSTS configuration of the server, overriding the
+	// global configuration
+	HSTS *HSTS `json:"hsts,omitempty"`
 	// AuthTLSError contains the reason why the access to a server should be denied
 	AuthTLSError string `json:"authTLSError,omitempty"`
 }
 
+// HSTS describes the Strict-Transport-Security header returned by a server
+type HSTS struct {
+	Enable            bool   `json:"enable"`
+	MaxAge            string `json:"maxAge"`
+	IncludeSubdomains bool   `json:"includeSubdomains"`
+	Preload           bool   `json:"preload"`
+}
+
 // Location describes an URI inside a server.
 // Also contains additional information about annotations in the Ingress.
 //
@@ -361,6 +479,25 @@ type Location struct {
 	// Opentracing allows the global opentracing setting to be overridden for a location
 	// +optional
 	Opentracing opentracing.Config `json:"opentracing"`
+	// JWTAuth validates the JSON Web Token of the requests
+	// +optional
+	JWTAuth jwtauth.Config `json:"jwtAuth,omitempty"`
+	// OIDC logs in the users with an OpenID provider
+	// +optional
+	OIDC oidc.Config `json:"oidc,omitempty"`
+	// HeaderModifier sets, adds or removes headers of the requests sent to the
+	// upstream and of the responses sent to the client
+	// +optional
+	HeaderModifier headermodifier.Config `json:"headerModifier,omitempty"`
+	// ProxyCache caches the responses of the upstream in a shared cache zone
+	// +optional
+	ProxyCache proxycache.Config `json:"proxyCache,omitempty"`
+	// FaultInjection delays or aborts a percentage of the requests
+	// +optional
+	FaultInjection faultinjection.Config `json:"faultInjection,omitempty"`
+	// RetryPolicy defines which requests are retried and the conditions of the retries
+	// +optional
+	RetryPolicy retrypolicy.Config `json:"retryPolicy,omitempty"`
 }
 
 // SSLPassthroughBackend describes a SSL upstream server configured
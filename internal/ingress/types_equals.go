@@ -17,6 +17,7 @@ limitations under the License.
 package ingress
 
 import (
+	"k8s.io/ingress-nginx/internal/ingress/annotations/oidc"
 	"k8s.io/ingress-nginx/internal/sets"
 )
 
@@ -76,6 +77,18 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 		}
 	}
 
+	if !compareStringMaps(c1.JWKS, c2.JWKS) {
+		return false
+	}
+
+	if !compareOIDCSecrets(c1.OIDCSecrets, c2.OIDCSecrets) {
+		return false
+	}
+
+	if !compareStringMaps(c1.ACMEChallenges, c2.ACMEChallenges) {
+		return false
+	}
+
 	if c1.BackendConfigChecksum != c2.BackendConfigChecksum {
 		return false
 	}
@@ -135,7 +148,36 @@ func (b1 *Backend) Equal(b2 *Backend) bool {
 		return false
 	}
 
-	return sets.StringElementsMatch(b1.AlternativeBackends, b2.AlternativeBackends)
+	if !sets.StringElementsMatch(b1.AlternativeBackends, b2.AlternativeBackends) {
+		return false
+	}
+
+	if len(b1.RouteMatches) != len(b2.RouteMatches) {
+		return false
+	}
+	for i := range b1.RouteMatches {
+		if !b1.RouteMatches[i].Equal(&b2.RouteMatches[i]) {
+			return false
+		}
+	}
+
+	if !b1.RetryPolicy.Equal(&b2.RetryPolicy) {
+		return false
+	}
+
+	if !b1.HealthCheck.Equal(&b2.HealthCheck) {
+		return false
+	}
+
+	if b1.SlowStartWindow != b2.SlowStartWindow {
+		return false
+	}
+
+	if !b1.ZoneAwareRouting.Equal(&b2.ZoneAwareRouting) {
+		return false
+	}
+
+	return b1.CircuitBreaker.Equal(&b2.CircuitBreaker)
 }
 
 // Equal tests for equality between two SessionAffinityConfig types
@@ -227,6 +269,24 @@ func (e1 *Endpoint) Equal(e2 *Endpoint) bool {
 	if e1.Port != e2.Port {
 		return false
 	}
+	if e1.Health != e2.Health {
+		return false
+	}
+	if e1.Weight != e2.Weight {
+		return false
+	}
+	if e1.Zone != e2.Zone {
+		return false
+	}
+	if e1.Draining != e2.Draining {
+		return false
+	}
+	if e1.Cluster != e2.Cluster {
+		return false
+	}
+	if !sets.StringElementsMatch(e1.ForZones, e2.ForZones) {
+		return false
+	}
 
 	if e1.Target != e2.Target {
 		if e1.Target == nil || e2.Target == nil {
@@ -264,6 +324,72 @@ func (tsp1 TrafficShapingPolicy) Equal(tsp2 TrafficShapingPolicy) bool {
 	return true
 }
 
+// Equal checks for equality between two RetryPolicy types
+func (rp1 *RetryPolicy) Equal(rp2 *RetryPolicy) bool {
+	if rp1 == rp2 {
+		return true
+	}
+	if rp1 == nil || rp2 == nil {
+		return false
+	}
+	if rp1.Attempts != rp2.Attempts || rp1.PerTryTimeout != rp2.PerTryTimeout || rp1.Backoff != rp2.Backoff {
+		return false
+	}
+	if rp1.BudgetPercent != rp2.BudgetPercent || rp1.BudgetMinRetries != rp2.BudgetMinRetries {
+		return false
+	}
+
+	return sets.StringElementsMatch(rp1.Methods, rp2.Methods)
+}
+
+// Equal checks for equality between two HSTS types
+func (h1 *HSTS) Equal(h2 *HSTS) bool {
+	if h1 == h2 {
+		return true
+	}
+	if h1 == nil || h2 == nil {
+		return false
+	}
+
+	return *h1 == *h2
+}
+
+// Equal checks for equality between two RouteMatch types
+func (rm1 *RouteMatch) Equal(rm2 *RouteMatch) bool {
+	if rm1 == rm2 {
+		return true
+	}
+	if rm1 == nil || rm2 == nil {
+		return false
+	}
+	if rm1.Backend != rm2.Backend {
+		return false
+	}
+	if !sets.StringElementsMatch(rm1.Methods, rm2.Methods) {
+		return false
+	}
+	if rm1.Header != rm2.Header {
+		return false
+	}
+	if rm1.HeaderValue != rm2.HeaderValue {
+		return false
+	}
+	if rm1.HeaderPattern != rm2.HeaderPattern {
+		return false
+	}
+	if rm1.QueryParam != rm2.QueryParam {
+		return false
+	}
+	if rm1.QueryValue != rm2.QueryValue {
+		return false
+	}
+	if rm1.QueryPattern != rm2.QueryPattern {
+		return false
+	}
+
+	return true
+}
+
 // Equal tests for equality between two Server types
 func (s1 *Server) Equal(s2 *Server) bool {
 	if s1 == s2 {
@@ -314,6 +440,12 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPreferServerCiphers != s2.SSLPreferServerCiphers {
 		return false
 	}
+	if s1.SSLProtocols != s2.SSLProtocols {
+		return false
+	}
+	if !s1.HSTS.Equal(s2.HSTS) {
+		return false
+	}
 	if s1.AuthTLSError != s2.AuthTLSError {
 		return false
 	}
@@ -466,6 +598,30 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		return false
 	}
 
+	if !l1.JWTAuth.Equal(&l2.JWTAuth) {
+		return false
+	}
+
+	if !l1.OIDC.Equal(&l2.OIDC) {
+		return false
+	}
+
+	if !l1.HeaderModifier.Equal(&l2.HeaderModifier) {
+		return false
+	}
+
+	if !l1.ProxyCache.Equal(&l2.ProxyCache) {
+		return false
+	}
+
+	if !l1.FaultInjection.Equal(&l2.FaultInjection) {
+		return false
+	}
+
+	if !l1.RetryPolicy.Equal(&l2.RetryPolicy) {
+		return false
+	}
+
 	return true
 }
 
@@ -579,6 +735,12 @@ func (s1 *SSLCert) Equal(s2 *SSLCert) bool {
 	if s1.UID != s2.UID {
 		return false
 	}
+	if s1.KeyType != s2.KeyType {
+		return false
+	}
+	if !s1.Alternate.Equal(s2.Alternate) {
+		return false
+	}
 
 	return sets.StringElementsMatch(s1.CN, s2.CN)
 }
@@ -654,3 +816,34 @@ var compareL4ServiceFunc = func(e1, e2 interface{}) bool {
 func compareL4Service(a, b []L4Service) bool {
 	return sets.Compare(a, b, compareL4ServiceFunc)
 }
+
+// compareStringMaps compares two maps, a nil map being equal to an empty one
+func compareStringMaps(m1, m2 map[string]string) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; !ok || v1 != v2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareOIDCSecrets compares the secrets of the OpenID providers, a nil map
+// being equal to an empty one
+func compareOIDCSecrets(m1, m2 map[string]oidc.Secrets) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; !ok || v1 != v2 {
+			return false
+		}
+	}
+
+	return true
+}
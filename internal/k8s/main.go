@@ -75,6 +75,22 @@ func GetNodeIPOrName(kubeClient clientset.Interface, name string, useInternalIP
 	return defaultOrInternalIP
 }
 
+// GetNodeZone returns the topology zone of a node in the cluster, or an
+// empty string when the node has no zone label
+func GetNodeZone(kubeClient clientset.Interface, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Error getting node", "name", name)
+		return ""
+	}
+
+	return node.Labels[apiv1.LabelTopologyZone]
+}
+
 var (
 	// IngressPodDetails hold information about the ingress-nginx pod
 	IngressPodDetails *PodInfo
@@ -85,6 +101,9 @@ var (
 type PodInfo struct {
 	metav1.TypeMeta
 	metav1.ObjectMeta
+
+	// NodeName is the name of the node running the pod
+	NodeName string
 }
 
 // GetIngressPod load the ingress-nginx pod
@@ -103,6 +122,7 @@ func GetIngressPod(kubeClient clientset.Interface) error {
 
 	IngressPodDetails = &PodInfo{
 		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		NodeName: pod.Spec.NodeName,
 	}
 
 	pod.ObjectMeta.DeepCopyInto(&IngressPodDetails.ObjectMeta)
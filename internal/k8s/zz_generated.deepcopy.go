@@ -26,6 +26,7 @@ import "k8s.io/apimachinery/pkg/runtime"
 func (in *PodInfo) DeepCopyInto(out *PodInfo) {
 	out.TypeMeta = in.TypeMeta
 	out.ObjectMeta = in.ObjectMeta
+	out.NodeName = in.NodeName
 }
 
 // DeepCopyObject returns a generically typed copy of an object
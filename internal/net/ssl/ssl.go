@@ -134,9 +134,25 @@ func CreateSSLCert(cert, key []byte, uid string) (*ingress.SSLCert, error) {
 		ExpireTime:  pemCert.NotAfter,
 		PemCertKey:  pemCertBuffer.String(),
 		UID:         uid,
+		KeyType:     pemCert.PublicKeyAlgorithm.String(),
 	}, nil
 }
 
+// SetAlternateSSLCert sets the certificate offered to the clients supporting its key
+// type, which must be different from the key type of the certificate of sslCert
+func SetAlternateSSLCert(sslCert, alternate *ingress.SSLCert) error {
+	if alternate.Certificate == nil {
+		return fmt.Errorf("no alternate certificate found")
+	}
+
+	if alternate.KeyType == sslCert.KeyType {
+		return fmt.Errorf("alternate certificate must not have the same key type %v as the certificate", sslCert.KeyType)
+	}
+
+	sslCert.Alternate = alternate
+	return nil
+}
+
 // CreateCACert is similar to CreateSSLCert but it creates instance of SSLCert only based on given ca after
 // parsing and validating it
 func CreateCACert(ca []byte) (*ingress.SSLCert, error) {
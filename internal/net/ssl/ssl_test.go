@@ -19,6 +19,8 @@ package ssl
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	cryptorand "crypto/rand"
 	"crypto/rsa"
@@ -270,6 +272,57 @@ func TestCreateSSLCert(t *testing.T) {
 	}
 }
 
+func TestSetAlternateSSLCert(t *testing.T) {
+	rsaCert, ca, err := generateRSACerts("echoheaders")
+	if err != nil {
+		t.Fatalf("unexpected error creating SSL certificate: %v", err)
+	}
+
+	sslCert, err := CreateSSLCert(encodeCertPEM(rsaCert.Cert), encodePrivateKeyPEM(rsaCert.Key), "rsa")
+	if err != nil {
+		t.Fatalf("unexpected error checking SSL certificate: %v", err)
+	}
+	if sslCert.KeyType != "RSA" {
+		t.Fatalf("expected the RSA key type but returned %v", sslCert.KeyType)
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error creating ECDSA key: %v", err)
+	}
+	config := certutil.Config{
+		CommonName: "echoheaders",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	ecdsaCert, err := newSignedCert(config, ecdsaKey, ca.Cert, ca.Key)
+	if err != nil {
+		t.Fatalf("unexpected error signing ECDSA certificate: %v", err)
+	}
+	ecdsaKeyDER, err := x509.MarshalECPrivateKey(ecdsaKey)
+	if err != nil {
+		t.Fatalf("unexpected error encoding ECDSA key: %v", err)
+	}
+
+	alternate, err := CreateSSLCert(encodeCertPEM(ecdsaCert), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecdsaKeyDER}), "ecdsa")
+	if err != nil {
+		t.Fatalf("unexpected error checking ECDSA certificate: %v", err)
+	}
+	if alternate.KeyType != "ECDSA" {
+		t.Fatalf("expected the ECDSA key type but returned %v", alternate.KeyType)
+	}
+
+	if err := SetAlternateSSLCert(sslCert, sslCert); err == nil {
+		t.Errorf("expected an error setting an alternate certificate with the same key type")
+	}
+
+	if err := SetAlternateSSLCert(sslCert, alternate); err != nil {
+		t.Fatalf("unexpected error setting alternate certificate: %v", err)
+	}
+	if sslCert.Alternate != alternate {
+		t.Errorf("expected the ECDSA certificate as alternate")
+	}
+}
+
 type keyPair struct {
 	Key  *rsa.PrivateKey
 	Cert *x509.Certificate
@@ -0,0 +1,43 @@
+local ngx = ngx
+local cjson = require("cjson.safe")
+local configuration = require("configuration")
+
+local string_match = string.match
+
+local _M = {}
+
+-- the responses decoded from the raw data last sent by the controller
+local raw_challenges
+local challenges = {}
+
+local function get_challenges()
+  local raw = configuration.get_acme_challenges_data()
+  if raw ~= raw_challenges then
+    challenges = cjson.decode(raw or "{}") or {}
+    raw_challenges = raw
+  end
+
+  return challenges
+end
+
+-- rewrite answers the ACME HTTP-01 challenges pending in any member cluster,
+-- so the certificate authority can validate the hosts whatever the cluster
+-- it reaches. Other requests are left untouched.
+function _M.rewrite()
+  local token = string_match(ngx.var.uri, "^/%.well%-known/acme%-challenge/([%w_%-]+)$")
+  if not token then
+    return
+  end
+
+  local key_authorization = get_challenges()[token]
+  if not key_authorization then
+    return
+  end
+
+  ngx.status = ngx.HTTP_OK
+  ngx.header["Content-Type"] = "text/plain"
+  ngx.print(key_authorization)
+  return ngx.exit(ngx.HTTP_OK)
+end
+
+return _M
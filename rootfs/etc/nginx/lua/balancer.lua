@@ -9,6 +9,10 @@ local chashsubset = require("balancer.chashsubset")
 local sticky_balanced = require("balancer.sticky_balanced")
 local sticky_persistent = require("balancer.sticky_persistent")
 local ewma = require("balancer.ewma")
+local least_request = require("balancer.least_request")
+local retry_policy = require("retry_policy")
+local circuit_breaker = require("circuit_breaker")
+local slow_start = require("slow_start")
 local string = string
 local ipairs = ipairs
 local table = table
@@ -32,6 +36,24 @@ local IMPLEMENTATIONS = {
   sticky_balanced = sticky_balanced,
   sticky_persistent = sticky_persistent,
   ewma = ewma,
+  least_request = least_request,
+}
+
+-- the implementations whose retries can move to another endpoint when the one
+-- picked is backing off, the others keep sessions or count the requests of the
+-- endpoints they pick
+local BACKOFF_IMPLEMENTATIONS = {
+  round_robin = true,
+  chash = true,
+  chashsubset = true,
+  ewma = true,
+}
+
+-- the implementations keeping the sessions of the draining endpoints, the
+-- others send no request to them
+local DRAINING_IMPLEMENTATIONS = {
+  [sticky_balanced] = true,
+  [sticky_persistent] = true,
 }
 
 local PROHIBITED_LOCALHOST_PORT = configuration.prohibited_localhost_port or '10246'
@@ -97,6 +119,40 @@ local function format_ipv6_endpoints(endpoints)
   return formatted_endpoints
 end
 
+-- serving_endpoints removes the draining endpoints, which receive no new
+-- requests while the requests in flight finish
+local function serving_endpoints(endpoints)
+  if not endpoints then
+    return nil
+  end
+
+  local serving = {}
+  for _, endpoint in ipairs(endpoints) do
+    if not endpoint.draining then
+      table.insert(serving, endpoint)
+    end
+  end
+
+  return serving
+end
+
+-- healthy_endpoints removes the endpoints failing the active health checks
+-- of the controller, unless every endpoint of the backend fails them
+local function healthy_endpoints(endpoints)
+  local healthy = {}
+  for _, endpoint in ipairs(endpoints) do
+    if endpoint.health ~= "unhealthy" then
+      table.insert(healthy, endpoint)
+    end
+  end
+
+  if #healthy == 0 then
+    return endpoints
+  end
+
+  return healthy
+end
+
 local function is_backend_with_external_name(backend)
   local serv_type = backend.service and backend.service.spec
                       and backend.service.spec["type"]
@@ -104,7 +160,19 @@ local function is_backend_with_external_name(backend)
 end
 
 local function sync_backend(backend)
+  circuit_breaker.sync(backend)
+
+  local implementation = get_implementation(backend)
+
+  -- the draining endpoints keep the sessions of the sticky backends, even when
+  -- every endpoint is draining, and receive no request of the other backends
+  if not DRAINING_IMPLEMENTATIONS[implementation] then
+    backend.endpoints = serving_endpoints(backend.endpoints)
+  end
+
   if not backend.endpoints or #backend.endpoints == 0 then
+    retry_policy.sync(backend)
+    slow_start.sync(backend)
     balancers[backend.name] = nil
     return
   end
@@ -113,9 +181,11 @@ local function sync_backend(backend)
     backend = resolve_external_names(backend)
   end
 
+  backend.endpoints = healthy_endpoints(backend.endpoints)
   backend.endpoints = format_ipv6_endpoints(backend.endpoints)
+  retry_policy.sync(backend)
+  slow_start.sync(backend)
 
-  local implementation = get_implementation(backend)
   local balancer = balancers[backend.name]
 
   if not balancer then
@@ -152,6 +222,9 @@ local function sync_backends()
   local backends_data = configuration.get_backends_data()
   if not backends_data then
     balancers = {}
+    retry_policy.keep({})
+    circuit_breaker.keep({})
+    slow_start.keep({})
     return
   end
 
@@ -178,47 +251,16 @@ local function sync_backends()
       backends_with_external_name[backend_name] = nil
     end
   end
+  retry_policy.keep(balancers_to_keep)
+  circuit_breaker.keep(balancers_to_keep)
+  slow_start.keep(balancers_to_keep)
   backends_last_synced_at = raw_backends_last_synced_at
 end
 
-local function route_to_alternative_balancer(balancer)
-  if balancer.is_affinitized(balancer) then
-    -- If request is already affinitized to a primary balancer, keep the primary balancer.
-    return false
-  end
-
-  if not balancer.alternative_backends then
-    return false
-  end
-
-  -- TODO: support traffic shaping for n > 1 alternative backends
-  local backend_name = balancer.alternative_backends[1]
-  if not backend_name then
-    ngx.log(ngx.ERR, "empty alternative backend")
-    return false
-  end
-
-  local alternative_balancer = balancers[backend_name]
-  if not alternative_balancer then
-    ngx.log(ngx.ERR, "no alternative balancer for backend: ",
-            tostring(backend_name))
-    return false
-  end
-
-  if alternative_balancer.is_affinitized(alternative_balancer) then
-    -- If request is affinitized to an alternative balancer, instruct caller to
-    -- switch to alternative.
-    return true
-  end
-
-  -- Use traffic shaping policy, if request didn't have affinity set.
-  local traffic_shaping_policy =  alternative_balancer.traffic_shaping_policy
-  if not traffic_shaping_policy then
-    ngx.log(ngx.ERR, "traffic shaping policy is not set for balancer ",
-            "of backend: ", tostring(backend_name))
-    return false
-  end
-
+-- match_traffic_shaping_policy returns true when the header or cookie of the
+-- request selects the alternative backend, false when they exclude it and nil
+-- when the weight must decide
+local function match_traffic_shaping_policy(traffic_shaping_policy)
   local target_header = util.replace_special_char(traffic_shaping_policy.header,
                                                   "-", "_")
   local header = ngx.var["http_" .. target_header]
@@ -255,26 +297,190 @@ local function route_to_alternative_balancer(balancer)
     end
   end
 
+  return nil
+end
+
+-- route_to_alternative_balancer returns true and the name of the alternative
+-- backend when the request must not be served by the primary backend.
+-- Header and cookie matchers are evaluated in the order of the alternative
+-- backends before the weights, which split the traffic using a single draw.
+local function route_to_alternative_balancer(balancer)
+  if balancer.is_affinitized(balancer) then
+    -- If request is already affinitized to a primary balancer, keep the primary balancer.
+    return false
+  end
+
+  if not balancer.alternative_backends then
+    return false
+  end
+
+  local candidates = {}
+  for _, backend_name in ipairs(balancer.alternative_backends) do
+    local alternative_balancer = balancers[backend_name]
+    if not alternative_balancer then
+      ngx.log(ngx.ERR, "no alternative balancer for backend: ",
+              tostring(backend_name))
+    elseif alternative_balancer.is_affinitized(alternative_balancer) then
+      -- If request is affinitized to an alternative balancer, instruct caller to
+      -- switch to alternative.
+      return true, backend_name
+    elseif alternative_balancer.draining then
+      -- every endpoint of the alternative backend is draining, it only keeps
+      -- its sessions
+      ngx.log(ngx.INFO, "alternative backend is draining: ", tostring(backend_name))
+    elseif not alternative_balancer.traffic_shaping_policy then
+      ngx.log(ngx.ERR, "traffic shaping policy is not set for balancer ",
+              "of backend: ", tostring(backend_name))
+    else
+      table.insert(candidates, {
+        name = backend_name,
+        policy = alternative_balancer.traffic_shaping_policy,
+      })
+    end
+  end
+
+  if #candidates == 0 then
+    return false
+  end
+
+  -- Use traffic shaping policy, if request didn't have affinity set.
+  local excluded = {}
+  for _, candidate in ipairs(candidates) do
+    local matched = match_traffic_shaping_policy(candidate.policy)
+    if matched then
+      return true, candidate.name
+    end
+    excluded[candidate.name] = matched == false
+  end
+
   local weightTotal = 100
-  if traffic_shaping_policy.weightTotal ~= nil and traffic_shaping_policy.weightTotal > 100 then
-    weightTotal = traffic_shaping_policy.weightTotal
+  for _, candidate in ipairs(candidates) do
+    if candidate.policy.weightTotal ~= nil and candidate.policy.weightTotal > weightTotal then
+      weightTotal = candidate.policy.weightTotal
+    end
   end
-  if math.random(weightTotal) <= traffic_shaping_policy.weight then
-    return true
+
+  -- the traffic of an excluded backend stays with the primary backend
+  local draw = math.random(weightTotal)
+  local weight = 0
+  for _, candidate in ipairs(candidates) do
+    weight = weight + (candidate.policy.weight or 0)
+    if draw <= weight then
+      if excluded[candidate.name] then
+        return false
+      end
+      return true, candidate.name
+    end
   end
 
   return false
 end
 
+-- match_value returns true when the value equals the expected value or
+-- matches the pattern. Without an expected value or pattern any value matches.
+local function match_value(value, expected, pattern)
+  if not value then
+    return false
+  end
+
+  if expected and #expected > 0 then
+    return value == expected
+  end
+
+  if pattern and #pattern > 0 then
+    local m, err = ngx.re.match(value, pattern, "jo")
+    if err then
+      ngx.log(ngx.ERR, "error when matching route pattern: '", pattern,
+              "', error: ", err)
+      return false
+    end
+    return m ~= nil
+  end
+
+  return true
+end
+
+-- match_route returns true when the request satisfies every condition of the
+-- route match
+local function match_route(route_match)
+  if route_match.methods and #route_match.methods > 0 then
+    local method = ngx.req.get_method()
+    local found = false
+    for _, m in ipairs(route_match.methods) do
+      if m == method then
+        found = true
+        break
+      end
+    end
+    if not found then
+      return false
+    end
+  end
+
+  if route_match.header and #route_match.header > 0 then
+    local target_header = util.replace_special_char(route_match.header, "-", "_")
+    if not match_value(ngx.var["http_" .. target_header],
+                       route_match.headerValue, route_match.headerPattern) then
+      return false
+    end
+  end
+
+  if route_match.queryParam and #route_match.queryParam > 0 then
+    if not match_value(ngx.var["arg_" .. route_match.queryParam],
+                       route_match.queryValue, route_match.queryPattern) then
+      return false
+    end
+  end
+
+  return true
+end
+
+-- route_to_matching_balancer returns the name of the backend of the first
+-- route match satisfied by the request, or nil when the request must be
+-- served by the backend of the path
+local function route_to_matching_balancer(balancer)
+  if not balancer.route_matches then
+    return nil
+  end
+
+  for _, route_match in ipairs(balancer.route_matches) do
+    if match_route(route_match) then
+      if balancers[route_match.backend] then
+        return route_match.backend
+      end
+      ngx.log(ngx.ERR, "no balancer for the backend of the route match: ",
+              tostring(route_match.backend))
+    end
+  end
+
+  return nil
+end
+
 local function get_balancer_by_upstream_name(upstream_name)
   return balancers[upstream_name]
 end
 
+-- get_backend_name returns the name of the backend serving the request
+local function get_backend_name()
+  local backend_name = ngx.ctx.mirror_backend or ngx.var.proxy_alternative_upstream_name
+  if not backend_name or backend_name == "" then
+    backend_name = ngx.var.proxy_upstream_name
+  end
+  return backend_name
+end
+
 local function get_balancer()
   if ngx.ctx.balancer then
     return ngx.ctx.balancer
   end
 
+  -- the copies of the requests go to the backend of the mirror
+  local mirror_backend = ngx.ctx.mirror_backend
+  if mirror_backend then
+    ngx.ctx.balancer = balancers[mirror_backend]
+    return ngx.ctx.balancer
+  end
+
   local backend_name = ngx.var.proxy_upstream_name
 
   local balancer = balancers[backend_name]
@@ -282,8 +488,16 @@ local function get_balancer()
     return nil
   end
 
-  if route_to_alternative_balancer(balancer) then
-    local alternative_backend_name = balancer.alternative_backends[1]
+  local matched_backend_name = route_to_matching_balancer(balancer)
+  if matched_backend_name then
+    ngx.var.proxy_alternative_upstream_name = matched_backend_name
+    ngx.ctx.balancer = balancers[matched_backend_name]
+
+    return ngx.ctx.balancer
+  end
+
+  local alternative, alternative_backend_name = route_to_alternative_balancer(balancer)
+  if alternative then
     ngx.var.proxy_alternative_upstream_name = alternative_backend_name
 
     balancer = balancers[alternative_backend_name]
@@ -322,6 +536,19 @@ function _M.rewrite()
     ngx.status = ngx.HTTP_SERVICE_UNAVAILABLE
     return ngx.exit(ngx.status)
   end
+
+  -- a backend whose endpoints are all draining accepts no new session
+  if balancer.draining and not balancer:is_affinitized() then
+    ngx.status = ngx.HTTP_SERVICE_UNAVAILABLE
+    return ngx.exit(ngx.status)
+  end
+
+  -- the circuit breaker of the backend fails fast while it is open
+  local status = circuit_breaker.acquire(get_backend_name())
+  if status then
+    ngx.status = status
+    return ngx.exit(status)
+  end
 end
 
 function _M.balance()
@@ -330,19 +557,28 @@ function _M.balance()
     return
   end
 
+  local backend_name = get_backend_name()
+
   local peer = balancer:balance()
   if not peer then
     ngx.log(ngx.WARN, "no peer was returned, balancer: " .. balancer.name)
     return
   end
 
+  -- the retry policy of the backend moves the retries away from the endpoints
+  -- backing off, and can forbid NGINX to try the request again
+  local more_tries
+  peer, more_tries = retry_policy.before_try(backend_name, peer,
+                                             BACKOFF_IMPLEMENTATIONS[balancer.name])
+  if more_tries then
+    ngx_balancer.set_more_tries(1)
+  end
+
   if peer:match(PROHIBITED_PEER_PATTERN) then
     ngx.log(ngx.ERR, "attempted to proxy to self, balancer: ", balancer.name, ", peer: ", peer)
     return
   end
 
-  ngx_balancer.set_more_tries(1)
-
   local ok, err = ngx_balancer.set_current_peer(peer)
   if not ok then
     ngx.log(ngx.ERR, "error while setting current upstream peer ", peer,
@@ -350,7 +586,16 @@ function _M.balance()
   end
 end
 
+-- release stops counting the request in the circuit breaker of its backend,
+-- in the log phase of the locations not balancing requests
+function _M.release()
+  circuit_breaker.release()
+end
+
 function _M.log()
+  circuit_breaker.release()
+  least_request.release()
+
   local balancer = get_balancer()
   if not balancer then
     return
@@ -367,6 +612,7 @@ setmetatable(_M, {__index = {
   get_implementation = get_implementation,
   sync_backend = sync_backend,
   route_to_alternative_balancer = route_to_alternative_balancer,
+  route_to_matching_balancer = route_to_matching_balancer,
   get_balancer = get_balancer,
   get_balancer_by_upstream_name = get_balancer_by_upstream_name,
 }})
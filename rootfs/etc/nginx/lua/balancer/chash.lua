@@ -20,6 +20,7 @@ function _M.new(self, backend)
     hash_by = complex_val,
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
+    route_matches = backend.routeMatches,
   }
   setmetatable(o, self)
   self.__index = self
@@ -63,6 +63,7 @@ function _M.new(self, backend)
     current_endpoints = backend.endpoints,
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
+    route_matches = backend.routeMatches,
   }
   setmetatable(o, self)
   self.__index = self
@@ -6,6 +6,7 @@
 
 
 local resty_lock = require("resty.lock")
+local slow_start = require("slow_start")
 local util = require("util")
 local split = require("util.split")
 
@@ -131,14 +132,24 @@ local function shuffle_peers(peers, k)
   -- peers[1 .. k] will now contain a randomly selected k from #peers
 end
 
-local function pick_and_score(peers, k)
+-- weight returns the weight of an endpoint multiplied by its slow start
+-- factor, so the endpoints in their slow start window receive less requests
+local function weight(backend_name, peer)
+  return util.get_weight(peer) * slow_start.factor(backend_name, get_upstream_name(peer))
+end
+
+-- pick_and_score compares the scores divided by the weights of the
+-- endpoints, so the endpoints with a higher weight receive more requests
+local function pick_and_score(backend_name, peers, k)
   shuffle_peers(peers, k)
   local lowest_score_index = 1
   local lowest_score = score(peers[lowest_score_index])
+  local lowest_weighted_score = lowest_score / weight(backend_name, peers[lowest_score_index])
   for i = 2, k do
     local new_score = score(peers[i])
-    if new_score < lowest_score then
-      lowest_score_index, lowest_score = i, new_score
+    local new_weighted_score = new_score / weight(backend_name, peers[i])
+    if new_weighted_score < lowest_weighted_score then
+      lowest_score_index, lowest_score, lowest_weighted_score = i, new_score, new_weighted_score
     end
   end
 
@@ -205,7 +216,7 @@ function _M.balance(self)
     end
 
     if #filtered_peers > 1 then
-      endpoint, ewma_score = pick_and_score(filtered_peers, k)
+      endpoint, ewma_score = pick_and_score(self.backend_name, filtered_peers, k)
     else
       endpoint, ewma_score = filtered_peers[1], score(filtered_peers[1])
     end
@@ -235,12 +246,15 @@ end
 function _M.sync(self, backend)
   self.traffic_shaping_policy = backend.trafficShapingPolicy
   self.alternative_backends = backend.alternativeBackends
+  self.route_matches = backend.routeMatches
 
   local normalized_endpoints_added, normalized_endpoints_removed =
     util.diff_endpoints(self.peers, backend.endpoints)
 
   if #normalized_endpoints_added == 0 and #normalized_endpoints_removed == 0 then
     ngx.log(ngx.INFO, "endpoints did not change for backend " .. tostring(backend.name))
+    -- the weights of the endpoints can change without the endpoints changing
+    self.peers = backend.endpoints
     return
   end
 
@@ -264,9 +278,11 @@ end
 
 function _M.new(self, backend)
   local o = {
+    backend_name = backend.name,
     peers = backend.endpoints,
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
+    route_matches = backend.routeMatches,
   }
   setmetatable(o, self)
   self.__index = self
@@ -0,0 +1,145 @@
+-- Power of two choices balancer: picks two random endpoints and sends the
+-- request to the one with the fewest requests in flight, relative to its
+-- weight. The requests in flight are counted per worker.
+--
+-- The endpoints picked for the tries of a request are kept in the variable
+-- $least_request_tried_endpoints rather than in ngx.ctx, which the internal
+-- redirects to the custom error pages clear, so they are released by the log
+-- phase of the location the request ends in.
+
+local util = require("util")
+
+local ngx = ngx
+local math = math
+local ipairs = ipairs
+local setmetatable = setmetatable
+local table_insert = table.insert
+
+local _M = { name = "least_request" }
+
+-- the requests in flight of the balancers, per backend
+local in_flight_by_backend = setmetatable({}, { __mode = "v" })
+
+local function get_upstream_name(endpoint)
+  return endpoint.address .. ":" .. endpoint.port
+end
+
+local function score(self, endpoint)
+  local in_flight = self.in_flight[get_upstream_name(endpoint)] or 0
+  return (in_flight + 1) / util.get_weight(endpoint)
+end
+
+local function pick_two(self, peers)
+  local first = math.random(#peers)
+  local second = math.random(#peers - 1)
+  if second >= first then
+    second = second + 1
+  end
+
+  if score(self, peers[second]) < score(self, peers[first]) then
+    return peers[second]
+  end
+  return peers[first]
+end
+
+function _M.is_affinitized()
+  return false
+end
+
+-- tried_endpoints iterates over the backends and the endpoints picked for the
+-- tries of the request, kept as a comma separated list of "backend endpoint"
+local function tried_endpoints(tried)
+  return tried:gmatch("([^ ,]+) ([^ ,]+)")
+end
+
+function _M.balance(self)
+  local tried = ngx.var.least_request_tried_endpoints or ""
+
+  -- a retry goes to an endpoint not tried yet when there is one
+  local tried_by_self = {}
+  for backend_name, upstream_name in tried_endpoints(tried) do
+    if backend_name == self.backend_name then
+      tried_by_self[upstream_name] = true
+    end
+  end
+
+  local peers = {}
+  for _, peer in ipairs(self.peers) do
+    if not tried_by_self[get_upstream_name(peer)] then
+      table_insert(peers, peer)
+    end
+  end
+  if #peers == 0 then
+    peers = self.peers
+  end
+
+  local endpoint = peers[1]
+  if #peers > 1 then
+    endpoint = pick_two(self, peers)
+  end
+
+  local upstream_name = get_upstream_name(endpoint)
+  self.in_flight[upstream_name] = (self.in_flight[upstream_name] or 0) + 1
+
+  local try = self.backend_name .. " " .. upstream_name
+  if tried == "" then
+    ngx.var.least_request_tried_endpoints = try
+  else
+    ngx.var.least_request_tried_endpoints = tried .. "," .. try
+  end
+
+  return upstream_name
+end
+
+-- release stops counting the endpoints picked for every try of the request,
+-- called in the log phase
+function _M.release()
+  local tried = ngx.var.least_request_tried_endpoints
+  if not tried or tried == "" then
+    return
+  end
+
+  ngx.var.least_request_tried_endpoints = ""
+  for backend_name, upstream_name in tried_endpoints(tried) do
+    local in_flight = in_flight_by_backend[backend_name]
+    if in_flight then
+      local count = in_flight[upstream_name]
+      if count and count > 1 then
+        in_flight[upstream_name] = count - 1
+      else
+        in_flight[upstream_name] = nil
+      end
+    end
+  end
+end
+
+function _M.sync(self, backend)
+  self.traffic_shaping_policy = backend.trafficShapingPolicy
+  self.alternative_backends = backend.alternativeBackends
+  self.route_matches = backend.routeMatches
+
+  local _, endpoints_removed = util.diff_endpoints(self.peers, backend.endpoints)
+  for _, upstream_name in ipairs(endpoints_removed) do
+    self.in_flight[upstream_name] = nil
+  end
+
+  -- the weights can change without the endpoints changing
+  self.peers = backend.endpoints
+end
+
+function _M.new(self, backend)
+  local o = {
+    backend_name = backend.name,
+    peers = backend.endpoints,
+    in_flight = {},
+    traffic_shaping_policy = backend.trafficShapingPolicy,
+    alternative_backends = backend.alternativeBackends,
+    route_matches = backend.routeMatches,
+  }
+  setmetatable(o, self)
+  self.__index = self
+  in_flight_by_backend[backend.name] = o.in_flight
+  return o
+end
+
+return _M
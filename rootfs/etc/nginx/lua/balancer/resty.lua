@@ -18,11 +18,18 @@ function _M.is_affinitized()
   return false
 end
 
+-- get_nodes returns the nodes of the endpoints of the backend, mapping the
+-- peers to their weight
+function _M.get_nodes(_, backend)
+  return util.get_nodes(backend.endpoints)
+end
+
 function _M.sync(self, backend)
   self.traffic_shaping_policy = backend.trafficShapingPolicy
   self.alternative_backends = backend.alternativeBackends
+  self.route_matches = backend.routeMatches
 
-  local nodes = util.get_nodes(backend.endpoints)
+  local nodes = self:get_nodes(backend)
   local changed = not util.deep_compare(self.instance.nodes, nodes)
   if not changed then
     return
@@ -1,24 +1,49 @@
 local balancer_resty = require("balancer.resty")
 local resty_roundrobin = require("resty.roundrobin")
+local slow_start = require("slow_start")
 local util = require("util")
 
+local ngx = ngx
 local setmetatable = setmetatable
 
+-- measured in seconds
+-- the interval between the updates of the weights of the endpoints in their
+-- slow start window
+local SLOW_START_INTERVAL = 1
+
 local _M = balancer_resty:new({ factory = resty_roundrobin, name = "round_robin" })
 
+-- get_nodes weighs the endpoints in their slow start window, and keeps the
+-- backend to update their weights until the end of the window
+function _M.get_nodes(self, backend)
+  local nodes, warming = slow_start.weigh(backend.name, util.get_nodes(backend.endpoints))
+  if warming then
+    self.warming_backend = backend
+    self.weighed_at = ngx.now()
+  else
+    self.warming_backend = nil
+  end
+  return nodes
+end
+
 function _M.new(self, backend)
-  local nodes = util.get_nodes(backend.endpoints)
   local o = {
-    instance = self.factory:new(nodes),
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
+    route_matches = backend.routeMatches,
   }
   setmetatable(o, self)
   self.__index = self
+  o.instance = self.factory:new(o:get_nodes(backend))
   return o
 end
 
 function _M.balance(self)
+  local warming_backend = self.warming_backend
+  if warming_backend and ngx.now() - self.weighed_at >= SLOW_START_INTERVAL then
+    self.instance:reinit(self:get_nodes(warming_backend))
+  end
+
   return self.instance:find()
 end
 
@@ -22,6 +22,7 @@ end
 function _M.new(self)
   local o = {
     alternative_backends = nil,
+    route_matches = nil,
     cookie_session_affinity = nil,
     traffic_shaping_policy = nil,
     backend_key = nil
@@ -136,6 +137,19 @@ local function get_failed_upstreams()
   return indexed_upstream_addrs
 end
 
+-- get_excluded_upstreams returns the upstreams a new session is not sent to:
+-- the ones that failed for the request and the draining ones, which only keep
+-- their sessions
+local function get_excluded_upstreams(self)
+  local excluded_upstreams = get_failed_upstreams()
+
+  for upstream, _ in pairs(self.draining_upstreams or {}) do
+    excluded_upstreams[upstream] = true
+  end
+
+  return excluded_upstreams
+end
+
 local function should_set_cookie(self)
   local host = ngx.var.host
   if ngx.var.server_name == '_' then
@@ -184,7 +198,7 @@ function _M.balance(self)
 
   local new_upstream
 
-  new_upstream, key = self:pick_new_upstream(get_failed_upstreams())
+  new_upstream, key = self:pick_new_upstream(get_excluded_upstreams(self))
   if not new_upstream then
     ngx.log(ngx.WARN, string.format("failed to get new upstream; using upstream %s", new_upstream))
   elseif should_set_cookie(self) then
@@ -200,8 +214,21 @@ function _M.sync(self, backend)
 
   self.traffic_shaping_policy = backend.trafficShapingPolicy
   self.alternative_backends = backend.alternativeBackends
+  self.route_matches = backend.routeMatches
   self.cookie_session_affinity = backend.sessionAffinityConfig.cookieSessionAffinity
   self.backend_key = ngx.md5(ngx.md5(backend.name) .. backend.name)
+
+  -- the backend keeps its sessions but accepts no new one when every endpoint
+  -- is draining
+  self.draining = true
+  self.draining_upstreams = {}
+  for _, endpoint in ipairs(backend.endpoints or {}) do
+    if endpoint.draining then
+      self.draining_upstreams[endpoint.address .. ":" .. endpoint.port] = true
+    else
+      self.draining = false
+    end
+  end
 end
 
 return _M
@@ -17,6 +17,7 @@ local DEFAULT_CERT_HOSTNAME = "_"
 
 local certificate_data = ngx.shared.certificate_data
 local certificate_servers = ngx.shared.certificate_servers
+local certificate_alternates = ngx.shared.certificate_alternates
 local ocsp_response_cache = ngx.shared.ocsp_response_cache
 
 local function get_der_cert_and_priv_key(pem_cert_key)
@@ -45,14 +46,14 @@ local function set_der_cert_and_key(der_cert, der_priv_key)
   end
 end
 
-local function get_pem_cert_uid(raw_hostname)
+local function get_uid(servers, raw_hostname)
   -- Convert hostname to ASCII lowercase (see RFC 6125 6.4.1) so that requests with uppercase
   -- host would lead to the right certificate being chosen (controller serves certificates for
   -- lowercase hostnames as specified in Ingress object's spec.rules.host)
   local hostname = re_sub(raw_hostname, "\\.$", "", "jo"):gsub("[A-Z]",
     function(c) return c:lower() end)
 
-  local uid = certificate_servers:get(hostname)
+  local uid = servers:get(hostname)
   if uid then
     return uid
   end
@@ -64,12 +65,27 @@ local function get_pem_cert_uid(raw_hostname)
   end
 
   if wildcard_hostname then
-    uid = certificate_servers:get(wildcard_hostname)
+    uid = servers:get(wildcard_hostname)
   end
 
   return uid
 end
 
+local function get_pem_cert_uid(raw_hostname)
+  return get_uid(certificate_servers, raw_hostname)
+end
+
+-- get_alternate_pem_cert returns the certificate of the hostname with a second
+-- key type, like an ECDSA certificate next to a RSA one
+local function get_alternate_pem_cert(raw_hostname)
+  local uid = get_uid(certificate_alternates, raw_hostname)
+  if not uid then
+    return nil
+  end
+
+  return certificate_data:get(uid)
+end
+
 local function is_ocsp_stapling_enabled_for(_)
   -- TODO: implement per ingress OCSP stapling control
   -- and make use of uid. The idea is to have configureCertificates
@@ -229,8 +245,10 @@ function _M.call()
   end
 
   local pem_cert
+  local pem_cert_hostname = hostname
   local pem_cert_uid = get_pem_cert_uid(hostname)
   if not pem_cert_uid then
+    pem_cert_hostname = DEFAULT_CERT_HOSTNAME
     pem_cert_uid = get_pem_cert_uid(DEFAULT_CERT_HOSTNAME)
   end
   if pem_cert_uid then
@@ -260,6 +278,26 @@ function _M.call()
     return ngx.exit(ngx.ERROR)
   end
 
+  -- the certificate with a second key type is set next to the first one and
+  -- OpenSSL selects the one supported by the signature algorithms of the client
+  local alternate_pem_cert = get_alternate_pem_cert(pem_cert_hostname)
+  if alternate_pem_cert then
+    local alt_der_cert, alt_der_priv_key, alt_der_err =
+      get_der_cert_and_priv_key(alternate_pem_cert)
+    if alt_der_err then
+      ngx.log(ngx.ERR, "alternate certificate: ", alt_der_err)
+    else
+      local set_alt_der_err = set_der_cert_and_key(alt_der_cert, alt_der_priv_key)
+      if set_alt_der_err then
+        ngx.log(ngx.ERR, "alternate certificate: ", set_alt_der_err)
+      end
+    end
+
+    -- the OCSP response of the first certificate would not match the
+    -- certificate selected by the handshake
+    return
+  end
+
   if is_ocsp_stapling_enabled_for(pem_cert_uid) then
     local _, err = ocsp_staple(pem_cert_uid, der_cert)
     if err then
@@ -0,0 +1,149 @@
+-- Circuit breakers limit the requests in flight to a backend. The counters
+-- are kept in a shared dictionary so the limits apply to every worker.
+--
+-- The backend whose request in flight is counted is kept in the variable
+-- $circuit_breaker_backend rather than in ngx.ctx, which the internal
+-- redirects to the custom error pages clear, so the request is released by
+-- the log phase of the location it ends in.
+
+local ngx = ngx
+local pairs = pairs
+
+-- measured in seconds
+-- a pending request waits at most PENDING_TIMEOUT for one of the requests in
+-- flight to finish, checking every PENDING_INTERVAL
+local PENDING_TIMEOUT = 1
+local PENDING_INTERVAL = 0.005
+
+local counters = ngx.shared.circuit_breaker
+
+local _M = {}
+
+-- the circuit breakers of the backends, per worker
+local breakers = {}
+
+local function incr(backend_name, counter, value)
+  local count, err = counters:incr(backend_name .. ":" .. counter, value, 0)
+  if not count then
+    ngx.log(ngx.ERR, "error while counting the ", counter, " requests of backend ",
+            backend_name, ": ", err)
+  end
+  return count
+end
+
+-- try_acquire counts the request in flight when the backend has room for it.
+-- The requests are allowed when the counter cannot be updated.
+local function try_acquire(backend_name, breaker)
+  local active = incr(backend_name, "active", 1)
+  if not active then
+    return true
+  end
+
+  if active <= breaker.maxRequests then
+    ngx.var.circuit_breaker_backend = backend_name
+    return true
+  end
+
+  incr(backend_name, "active", -1)
+  return false
+end
+
+local function wait(backend_name, breaker)
+  local deadline = ngx.now() + PENDING_TIMEOUT
+  repeat
+    ngx.sleep(PENDING_INTERVAL)
+    if try_acquire(backend_name, breaker) then
+      return true
+    end
+  until ngx.now() >= deadline
+
+  return false
+end
+
+-- open and close record the transitions of the circuit breaker of a backend.
+-- Only the request changing the state reports the transition in the metrics.
+local function open(backend_name)
+  if counters:add(backend_name .. ":open", 0) then
+    ngx.ctx.circuit_breaker_transition = { backend = backend_name, state = "open" }
+  end
+end
+
+local function close(backend_name)
+  local key = backend_name .. ":open"
+  if not counters:get(key) then
+    return
+  end
+
+  if counters:incr(key, 1) == 1 then
+    counters:delete(key)
+    ngx.ctx.circuit_breaker_transition = { backend = backend_name, state = "closed" }
+  end
+end
+
+-- sync stores the circuit breaker of a backend
+function _M.sync(backend)
+  local breaker = backend.circuitBreaker
+  if not breaker or not breaker.maxRequests or breaker.maxRequests <= 0 then
+    breakers[backend.name] = nil
+    return
+  end
+
+  breakers[backend.name] = breaker
+end
+
+-- keep drops the circuit breakers of the backends not in the given set
+function _M.keep(backend_names)
+  for name, _ in pairs(breakers) do
+    if not backend_names[name] then
+      breakers[name] = nil
+    end
+  end
+end
+
+-- acquire counts the request in flight to the backend. When the backend has
+-- reached its maximum number of requests, the request waits for one of them
+-- to finish if the backend has room for one more pending request. It returns
+-- the status of the response when the circuit breaker rejects the request.
+function _M.acquire(backend_name)
+  local breaker = breakers[backend_name]
+  if not breaker then
+    return nil
+  end
+
+  if try_acquire(backend_name, breaker) then
+    close(backend_name)
+    return nil
+  end
+
+  if breaker.maxPending and breaker.maxPending > 0 then
+    local pending = incr(backend_name, "pending", 1)
+    if pending then
+      local acquired = pending <= breaker.maxPending and wait(backend_name, breaker)
+      incr(backend_name, "pending", -1)
+      if acquired then
+        return nil
+      end
+    end
+  end
+
+  open(backend_name)
+  return breaker.status or ngx.HTTP_SERVICE_UNAVAILABLE
+end
+
+-- release stops counting the request in flight, called in the log phase
+function _M.release()
+  local backend_name = ngx.var.circuit_breaker_backend
+  if not backend_name or backend_name == "" then
+    return
+  end
+
+  ngx.var.circuit_breaker_backend = ""
+  incr(backend_name, "active", -1)
+end
+
+setmetatable(_M, {__index = {
+  breakers = breakers,
+  PENDING_TIMEOUT = PENDING_TIMEOUT,
+}})
+
+return _M
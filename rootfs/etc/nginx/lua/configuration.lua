@@ -11,6 +11,7 @@ local pairs = pairs
 local configuration_data = ngx.shared.configuration_data
 local certificate_data = ngx.shared.certificate_data
 local certificate_servers = ngx.shared.certificate_servers
+local certificate_alternates = ngx.shared.certificate_alternates
 local ocsp_response_cache = ngx.shared.ocsp_response_cache
 
 local EMPTY_UID = "-1"
@@ -25,6 +26,18 @@ function _M.get_general_data()
   return configuration_data:get("general")
 end
 
+function _M.get_jwks_data()
+  return configuration_data:get("jwks")
+end
+
+function _M.get_oidc_data()
+  return configuration_data:get("oidc")
+end
+
+function _M.get_acme_challenges_data()
+  return configuration_data:get("acme_challenges")
+end
+
 function _M.get_raw_backends_last_synced_at()
   local raw_backends_last_synced_at = configuration_data:get("raw_backends_last_synced_at")
   if raw_backends_last_synced_at == nil then
@@ -100,6 +113,24 @@ local function handle_servers()
     end
   end
 
+  for server, uid in pairs(configuration.alternates or {}) do
+    if uid == EMPTY_UID then
+      certificate_alternates:delete(server)
+    else
+      local success, set_err, forcible = certificate_alternates:set(server, uid)
+      if not success then
+        local err_msg = string.format("error setting alternate certificate for %s: %s\n",
+          server, tostring(set_err))
+        table.insert(err_buf, err_msg)
+      end
+      if forcible then
+        local msg = string.format("certificate_alternates dictionary is full, "
+          .. "LRU entry has been removed to store %s", server)
+        ngx.log(ngx.WARN, msg)
+      end
+    end
+  end
+
   for uid, cert in pairs(configuration.certificates) do
     -- don't delete the cache here, certificate_data[uid] is not replaced yet.
     -- there is small chance that nginx worker still get the old certificate,
@@ -220,6 +251,33 @@ local function handle_backends()
   ngx.status = ngx.HTTP_CREATED
 end
 
+-- handle_secret_data stores the key sets, the secrets of the OpenID
+-- providers and the responses to the ACME challenges. GET requests only
+-- return if they are configured.
+local function handle_secret_data(key)
+  if ngx.var.request_method == "GET" then
+    ngx.status = ngx.HTTP_OK
+    ngx.print(configuration_data:get(key) and "configured" or "not configured")
+    return
+  end
+
+  local data = fetch_request_body()
+  if not data then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local success, err = configuration_data:set(key, data)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating " .. key .. ": " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
 function _M.call()
   if ngx.var.request_method ~= "POST" and ngx.var.request_method ~= "GET" then
     ngx.status = ngx.HTTP_BAD_REQUEST
@@ -247,6 +305,21 @@ function _M.call()
     return
   end
 
+  if ngx.var.request_uri == "/configuration/jwks" then
+    handle_secret_data("jwks")
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/oidc" then
+    handle_secret_data("oidc")
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/acme-challenges" then
+    handle_secret_data("acme_challenges")
+    return
+  end
+
   ngx.status = ngx.HTTP_NOT_FOUND
   ngx.print("Not found!")
 end
@@ -0,0 +1,53 @@
+local ngx = ngx
+local ngx_exit = ngx.exit
+local ngx_sleep = ngx.sleep
+local math_random = math.random
+
+local _M = {}
+
+-- is_selected returns true for the given percentage of the requests
+local function is_selected(percentage)
+  if not percentage or percentage <= 0 then
+    return false
+  end
+  return math_random() * 100 < percentage
+end
+
+local function matches_header(config)
+  if not config.header_var then
+    return true
+  end
+
+  local value = ngx.var[config.header_var]
+  if not value then
+    return false
+  end
+
+  return not config.header_value or value == config.header_value
+end
+
+-- inject delays or aborts the request according to the configuration of the
+-- location. The injected fault is stored in $injected_fault so the metrics
+-- can tell synthetic errors from real ones.
+function _M.inject(config)
+  if not matches_header(config) then
+    return
+  end
+
+  if config.delay_max and is_selected(config.delay_percentage) then
+    local delay = config.delay_min
+    if config.delay_max > config.delay_min then
+      delay = math_random(config.delay_min, config.delay_max)
+    end
+
+    ngx.var.injected_fault = "delay"
+    ngx_sleep(delay / 1000)
+  end
+
+  if config.abort_status and is_selected(config.abort_percentage) then
+    ngx.var.injected_fault = "abort"
+    return ngx_exit(config.abort_status)
+  end
+end
+
+return _M
@@ -0,0 +1,382 @@
+local ffi = require("ffi")
+local cjson = require("cjson.safe")
+local b64 = require("ngx.base64")
+local configuration = require("configuration")
+
+local ngx = ngx
+local ngx_log = ngx.log
+local ngx_ERR = ngx.ERR
+local ngx_INFO = ngx.INFO
+local string = string
+local table = table
+local type = type
+local pairs = pairs
+local ipairs = ipairs
+local tostring = tostring
+local tonumber = tonumber
+local setmetatable = setmetatable
+
+local C = ffi.C
+local ffi_gc = ffi.gc
+local ffi_new = ffi.new
+local ffi_cast = ffi.cast
+
+-- each function is declared on its own because other libraries may have
+-- declared some of them already, with their own types
+for _, def in ipairs({
+  "void *d2i_PUBKEY(void **a, const unsigned char **pp, long length);",
+  "void EVP_PKEY_free(void *pkey);",
+  "void *EVP_MD_CTX_new(void);",
+  "void EVP_MD_CTX_free(void *ctx);",
+  "const void *EVP_get_digestbyname(const char *name);",
+  "int EVP_DigestVerifyInit(void *ctx, void **pctx, const void *type, void *e, void *pkey);",
+  "int EVP_DigestVerify(void *ctx, const unsigned char *sig, size_t siglen, " ..
+    "const unsigned char *tbs, size_t tbslen);",
+}) do
+  pcall(ffi.cdef, def)
+end
+
+local _M = {}
+
+-- ALGORITHMS are the signature algorithms supported, with the digest, the
+-- type of key and, for ECDSA, the curve and the size of the coordinates
+local ALGORITHMS = {
+  RS256 = { digest = "SHA256", kty = "RSA" },
+  RS384 = { digest = "SHA384", kty = "RSA" },
+  RS512 = { digest = "SHA512", kty = "RSA" },
+  ES256 = { digest = "SHA256", kty = "EC", crv = "P-256", size = 32 },
+  ES384 = { digest = "SHA384", kty = "EC", crv = "P-384", size = 48 },
+  ES512 = { digest = "SHA512", kty = "EC", crv = "P-521", size = 66 },
+}
+
+local RSA_ENCRYPTION_OID = "\6\9\42\134\72\134\247\13\1\1\1"
+local EC_PUBLIC_KEY_OID = "\6\7\42\134\72\206\61\2\1"
+local CURVE_OIDS = {
+  ["P-256"] = "\6\8\42\134\72\206\61\3\1\7",
+  ["P-384"] = "\6\5\43\129\4\0\34",
+  ["P-521"] = "\6\5\43\129\4\0\35",
+}
+
+-- raw key sets received from the controller and the keys parsed from them,
+-- cached in each worker
+local raw_jwks
+local key_sets = {}
+
+local function der_length(len)
+  if len < 128 then
+    return string.char(len)
+  elseif len < 256 then
+    return string.char(0x81, len)
+  end
+  return string.char(0x82, math.floor(len / 256), len % 256)
+end
+
+local function der(tag, content)
+  return string.char(tag) .. der_length(#content) .. content
+end
+
+-- der_integer encodes an unsigned big endian number as a DER INTEGER
+local function der_integer(bytes)
+  bytes = bytes:gsub("^%z+", "")
+  if bytes == "" or bytes:byte(1) >= 0x80 then
+    bytes = "\0" .. bytes
+  end
+  return der(0x02, bytes)
+end
+
+-- public_key_der builds the SubjectPublicKeyInfo of a JSON Web Key
+local function public_key_der(jwk)
+  if jwk.kty == "RSA" then
+    local n = b64.decode_base64url(jwk.n or "")
+    local e = b64.decode_base64url(jwk.e or "")
+    if not n or not e or n == "" or e == "" then
+      return nil, "invalid RSA key"
+    end
+
+    local algorithm = der(0x30, RSA_ENCRYPTION_OID .. "\5\0")
+    local key = der(0x30, der_integer(n) .. der_integer(e))
+    return der(0x30, algorithm .. der(0x03, "\0" .. key))
+  end
+
+  if jwk.kty == "EC" then
+    local curve = CURVE_OIDS[jwk.crv]
+    if not curve then
+      return nil, "unsupported curve " .. tostring(jwk.crv)
+    end
+
+    local x = b64.decode_base64url(jwk.x or "")
+    local y = b64.decode_base64url(jwk.y or "")
+    if not x or not y or #x ~= #y or x == "" then
+      return nil, "invalid EC key"
+    end
+
+    local algorithm = der(0x30, EC_PUBLIC_KEY_OID .. curve)
+    return der(0x30, algorithm .. der(0x03, "\0\4" .. x .. y))
+  end
+
+  return nil, "unsupported key type " .. tostring(jwk.kty)
+end
+
+local function load_public_key(jwk)
+  local data, err = public_key_der(jwk)
+  if not data then
+    return nil, err
+  end
+
+  local buf = ffi_new("const unsigned char *[1]", ffi_cast("const unsigned char *", data))
+  local pkey = C.d2i_PUBKEY(nil, buf, #data)
+  if pkey == nil then
+    return nil, "invalid public key"
+  end
+
+  return ffi_gc(pkey, C.EVP_PKEY_free)
+end
+
+local function parse_key_set(raw)
+  local jwks, err = cjson.decode(raw)
+  if not jwks then
+    return nil, err
+  end
+  if type(jwks.keys) ~= "table" then
+    return nil, "the key set does not contain keys"
+  end
+
+  local keys = {}
+  for i, jwk in ipairs(jwks.keys) do
+    if type(jwk) == "table" and (jwk.use == nil or jwk.use == "sig") then
+      local pkey
+      pkey, err = load_public_key(jwk)
+      if pkey then
+        table.insert(keys, { kid = jwk.kid, kty = jwk.kty, crv = jwk.crv, alg = jwk.alg, pkey = pkey })
+      else
+        ngx_log(ngx_ERR, "ignoring key ", i, " of the key set: ", err)
+      end
+    end
+  end
+
+  return keys
+end
+
+-- get_keys returns the keys of a key set, parsing the key sets again when
+-- the controller sends new ones
+local function get_keys(jwks_key)
+  local raw = configuration.get_jwks_data()
+  if raw ~= raw_jwks then
+    local data = cjson.decode(raw or "{}") or {}
+    local new_key_sets = {}
+    for name, raw_key_set in pairs(data) do
+      local cached = key_sets[name]
+      if cached and cached.raw == raw_key_set then
+        new_key_sets[name] = cached
+      else
+        local keys, err = parse_key_set(raw_key_set)
+        if keys then
+          ngx_log(ngx_INFO, "loaded key set ", name)
+          new_key_sets[name] = { raw = raw_key_set, keys = keys }
+        else
+          ngx_log(ngx_ERR, "error parsing key set ", name, ": ", err)
+        end
+      end
+    end
+
+    key_sets = new_key_sets
+    raw_jwks = raw
+  end
+
+  local key_set = key_sets[jwks_key]
+  return key_set and key_set.keys
+end
+
+-- der_signature converts an ECDSA signature from the JWS format, the
+-- concatenation of r and s, to DER
+local function der_signature(signature, size)
+  if #signature ~= 2 * size then
+    return nil
+  end
+  local r = signature:sub(1, size)
+  local s = signature:sub(size + 1)
+  return der(0x30, der_integer(r) .. der_integer(s))
+end
+
+local function verify_signature(pkey, digest, signature, data)
+  local md = C.EVP_get_digestbyname(digest)
+  if md == nil then
+    return false
+  end
+
+  local ctx = C.EVP_MD_CTX_new()
+  if ctx == nil then
+    return false
+  end
+  ctx = ffi_gc(ctx, C.EVP_MD_CTX_free)
+
+  if C.EVP_DigestVerifyInit(ctx, nil, md, nil, pkey) ~= 1 then
+    return false
+  end
+
+  return C.EVP_DigestVerify(ctx, signature, #signature, data, #data) == 1
+end
+
+local function decode_segment(segment)
+  local data = b64.decode_base64url(segment)
+  if not data then
+    return nil
+  end
+  local value = cjson.decode(data)
+  if type(value) ~= "table" then
+    return nil
+  end
+  return value
+end
+
+local function contains(value, expected)
+  if type(value) == "table" then
+    for _, v in ipairs(value) do
+      if tostring(v) == expected then
+        return true
+      end
+    end
+    return false
+  end
+
+  return value ~= nil and tostring(value) == expected
+end
+
+-- verify returns the claims of the token when it is signed by one of the
+-- keys, or nil and the reason of the failure
+local function verify(token, keys)
+  local header_b64, payload_b64, signature_b64 = token:match("^([%w_-]+)%.([%w_-]+)%.([%w_-]+)$")
+  if not header_b64 then
+    return nil, "malformed token"
+  end
+
+  local header = decode_segment(header_b64)
+  local claims = decode_segment(payload_b64)
+  local signature = b64.decode_base64url(signature_b64)
+  if not header or not claims or not signature then
+    return nil, "malformed token"
+  end
+
+  local alg = ALGORITHMS[header.alg]
+  if not alg then
+    return nil, "unsupported algorithm " .. tostring(header.alg)
+  end
+
+  if alg.size then
+    signature = der_signature(signature, alg.size)
+    if not signature then
+      return nil, "invalid signature"
+    end
+  end
+
+  local data = header_b64 .. "." .. payload_b64
+  for _, key in ipairs(keys) do
+    if (header.kid == nil or key.kid == header.kid) and
+        key.kty == alg.kty and (alg.crv == nil or key.crv == alg.crv) and
+        (key.alg == nil or key.alg == header.alg) and
+        verify_signature(key.pkey, alg.digest, signature, data) then
+      return claims
+    end
+  end
+
+  return nil, "invalid signature"
+end
+
+local function check_claims(claims, config)
+  local now = ngx.time()
+
+  if claims.exp ~= nil and (tonumber(claims.exp) or 0) <= now then
+    return false, "token expired"
+  end
+
+  if claims.nbf ~= nil and (tonumber(claims.nbf) or now + 1) > now then
+    return false, "token not valid yet"
+  end
+
+  if config.issuer and config.issuer ~= "" and claims.iss ~= config.issuer then
+    return false, "invalid issuer"
+  end
+
+  if config.audiences and #config.audiences > 0 then
+    local valid = false
+    for _, audience in ipairs(config.audiences) do
+      if contains(claims.aud, audience) then
+        valid = true
+        break
+      end
+    end
+    if not valid then
+      return false, "invalid audience"
+    end
+  end
+
+  for claim, value in pairs(config.required_claims or {}) do
+    if not contains(claims[claim], value) then
+      return false, "missing claim " .. claim
+    end
+  end
+
+  return true
+end
+
+local function reject(err)
+  if err then
+    ngx.header["WWW-Authenticate"] = 'Bearer error="invalid_token"'
+  else
+    ngx.header["WWW-Authenticate"] = "Bearer"
+  end
+  return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+end
+
+-- access validates the bearer token of the request, rejecting the requests
+-- without a valid token, and sends the claims configured to the upstream
+function _M.access(config)
+  -- the headers are always removed, so clients cannot set them
+  for _, header in pairs(config.claim_headers or {}) do
+    ngx.req.clear_header(header)
+  end
+
+  local authorization = ngx.var.http_authorization
+  local token = authorization and authorization:match("^[Bb]earer%s+(%S+)%s*$")
+  if not token then
+    return reject()
+  end
+
+  local keys = get_keys(config.jwks_key)
+  if not keys then
+    ngx_log(ngx_ERR, "key set ", config.jwks_key, " is not available")
+    return reject("unavailable key set")
+  end
+
+  local claims, err = verify(token, keys)
+  if not claims then
+    ngx_log(ngx_INFO, "rejecting token: ", err)
+    return reject(err)
+  end
+
+  local valid
+  valid, err = check_claims(claims, config)
+  if not valid then
+    ngx_log(ngx_INFO, "rejecting token: ", err)
+    return reject(err)
+  end
+
+  for claim, header in pairs(config.claim_headers or {}) do
+    local value = claims[claim]
+    if type(value) == "table" then
+      value = cjson.encode(value)
+    end
+    if value ~= nil then
+      ngx.req.set_header(header, tostring(value))
+    end
+  end
+end
+
+setmetatable(_M, {__index = {
+  public_key_der = public_key_der,
+  der_signature = der_signature,
+  verify = verify,
+  check_claims = check_claims,
+}})
+
+return _M
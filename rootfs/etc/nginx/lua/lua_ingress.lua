@@ -167,13 +167,16 @@ function _M.rewrite(location_config)
   global_throttle.throttle(config.global_throttle, location_config.global_throttle)
 end
 
-function _M.header()
-  if config.hsts and ngx.var.scheme == "https" and certificate_configured_for_current_request then
-    local value = "max-age=" .. config.hsts_max_age
-    if config.hsts_include_subdomains then
+-- header sets the HSTS header, using the configuration of the server when
+-- its IngressClass overrides the global one
+function _M.header(server_config)
+  local hsts_config = server_config or config
+  if hsts_config.hsts and ngx.var.scheme == "https" and certificate_configured_for_current_request then
+    local value = "max-age=" .. hsts_config.hsts_max_age
+    if hsts_config.hsts_include_subdomains then
       value = value .. "; includeSubDomains"
     end
-    if config.hsts_preload then
+    if hsts_config.hsts_preload then
       value = value .. "; preload"
     end
     ngx.header["Strict-Transport-Security"] = value
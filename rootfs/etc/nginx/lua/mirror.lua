@@ -0,0 +1,38 @@
+-- Mirror locations receive the copies of the requests as subrequests. The
+-- variables of a subrequest are shared with the original request, so the
+-- state of the copy is kept in ngx.ctx, which is not.
+
+local balancer = require("balancer")
+local monitor = require("monitor")
+
+local ngx = ngx
+local math_random = math.random
+
+local _M = {}
+
+-- rewrite drops the copies of the requests outside of the sample and selects
+-- the backend of the copies sent to another member cluster or backend
+function _M.rewrite(config)
+  if config.sample and math_random() * 100 >= config.sample then
+    ngx.ctx.mirror_skipped = true
+    return ngx.exit(ngx.HTTP_NO_CONTENT)
+  end
+
+  if config.backend then
+    ngx.ctx.mirror_backend = config.backend
+    if not balancer.get_balancer() then
+      return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+    end
+  end
+end
+
+-- log reports the response to the copy of a request in the mirror metrics
+function _M.log(report_metrics)
+  if ngx.ctx.mirror_skipped or not report_metrics then
+    return
+  end
+
+  monitor.call()
+end
+
+return _M
@@ -31,6 +31,18 @@ local function send(payload)
   assert(s:close())
 end
 
+-- upstream_retries returns the number of upstream servers tried after the
+-- first one, which $upstream_addr separates with commas
+local function upstream_retries()
+  local addr = ngx.var.upstream_addr
+  if not addr then
+    return 0
+  end
+
+  local _, retries = string.gsub(addr, ",", ",")
+  return retries
+end
+
 local function metrics()
   return {
     host = ngx.var.host or "-",
@@ -49,6 +61,13 @@ local function metrics()
     upstreamLatency = tonumber(ngx.var.upstream_connect_time) or -1,
     upstreamResponseTime = tonumber(ngx.var.upstream_response_time) or -1,
     upstreamResponseLength = tonumber(ngx.var.upstream_response_length) or -1,
+    upstreamCacheStatus = ngx.var.upstream_cache_status or "-",
+
+    injectedFault = ngx.var.injected_fault or "",
+    upstreamRetries = upstream_retries(),
+    retryBudgetExhausted = ngx.ctx.retry_budget_exhausted or false,
+    mirror = ngx.ctx.mirror_backend or "",
+    circuitBreaker = ngx.ctx.circuit_breaker_transition,
     --upstreamStatus = ngx.var.upstream_status or "-",
   }
 end
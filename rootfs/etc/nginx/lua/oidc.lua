@@ -0,0 +1,465 @@
+local cjson = require("cjson.safe")
+local http = require("resty.http")
+local aes = require("resty.aes")
+local resty_random = require("resty.random")
+local resty_sha256 = require("resty.sha256")
+local b64 = require("ngx.base64")
+local configuration = require("configuration")
+
+local ngx = ngx
+local ngx_log = ngx.log
+local ngx_ERR = ngx.ERR
+local ngx_WARN = ngx.WARN
+local ngx_INFO = ngx.INFO
+local string = string
+local table = table
+local type = type
+local pairs = pairs
+local ipairs = ipairs
+local tostring = tostring
+local tonumber = tonumber
+local setmetatable = setmetatable
+
+local _M = {}
+
+-- DISCOVERY_TTL is the time, in seconds, the provider metadata is cached
+local DISCOVERY_TTL = 3600
+-- STATE_TTL is the time, in seconds, the user has to log in
+local STATE_TTL = 600
+-- EXPIRY_LEEWAY is the time, in seconds, before the expiration of the access
+-- token when it is refreshed
+local EXPIRY_LEEWAY = 30
+-- MAX_COOKIE_SIZE is the maximum size of a cookie accepted by the browsers
+local MAX_COOKIE_SIZE = 4096
+local HTTP_TIMEOUT = 5000
+
+-- headers sent to the upstream, always removed from the client requests
+local USER_HEADER = "X-Auth-Request-User"
+local EMAIL_HEADER = "X-Auth-Request-Email"
+local ACCESS_TOKEN_HEADER = "X-Auth-Request-Access-Token"
+
+-- secrets received from the controller and the keys derived from them,
+-- cached in each worker
+local raw_secrets
+local providers = {}
+
+-- provider metadata indexed by issuer
+local discovery_cache = {}
+
+local function sha256(data)
+  local sha = resty_sha256:new()
+  sha:update(data)
+  return sha:final()
+end
+
+local function random_string(size)
+  return b64.encode_base64url(resty_random.bytes(size, true) or resty_random.bytes(size))
+end
+
+-- get_provider returns the keys of a provider, deriving them again when the
+-- controller sends new secrets
+local function get_provider(key)
+  local raw = configuration.get_oidc_data()
+  if raw ~= raw_secrets then
+    local data = cjson.decode(raw or "{}") or {}
+    local new_providers = {}
+    for name, secrets in pairs(data) do
+      if type(secrets) == "table" and secrets.clientSecret and secrets.cookieSecret then
+        new_providers[name] = {
+          client_secret = secrets.clientSecret,
+          encryption_key = sha256(secrets.cookieSecret .. "\0encryption"),
+          signing_key = sha256(secrets.cookieSecret .. "\0signing"),
+        }
+      end
+    end
+
+    providers = new_providers
+    raw_secrets = raw
+  end
+
+  return providers[key]
+end
+
+local function cookie_name(config)
+  return "_oidc_" .. config.key
+end
+
+local function state_cookie_name(config)
+  return "_oidc_state_" .. config.key
+end
+
+-- encode encrypts and signs a value, binding it to the name of its cookie
+local function encode(provider, name, value)
+  local iv = resty_random.bytes(16, true) or resty_random.bytes(16)
+  local cipher = aes:new(provider.encryption_key, nil, aes.cipher(256, "cbc"), { iv = iv })
+  if not cipher then
+    return nil
+  end
+
+  local encrypted = iv .. cipher:encrypt(cjson.encode(value))
+  local mac = ngx.hmac_sha1(provider.signing_key, name .. encrypted)
+  return b64.encode_base64url(encrypted .. mac)
+end
+
+-- decode verifies and decrypts a value encoded by encode
+local function decode(provider, name, data)
+  if not data then
+    return nil
+  end
+
+  data = b64.decode_base64url(data)
+  -- the IV, one block and the MAC
+  if not data or #data < 16 + 16 + 20 then
+    return nil
+  end
+
+  local encrypted = data:sub(1, -21)
+  local mac = data:sub(-20)
+  if ngx.hmac_sha1(provider.signing_key, name .. encrypted) ~= mac then
+    return nil
+  end
+
+  local cipher = aes:new(provider.encryption_key, nil, aes.cipher(256, "cbc"), { iv = encrypted:sub(1, 16) })
+  if not cipher then
+    return nil
+  end
+
+  local plain = cipher:decrypt(encrypted:sub(17))
+  if not plain then
+    return nil
+  end
+
+  local value = cjson.decode(plain)
+  if type(value) ~= "table" then
+    return nil
+  end
+  return value
+end
+
+local function build_cookie(name, value, path, max_age)
+  local cookie = string.format("%s=%s; Path=%s; HttpOnly; SameSite=Lax", name, value, path)
+  if max_age then
+    cookie = cookie .. "; Max-Age=" .. max_age
+  end
+  if ngx.var.scheme == "https" then
+    cookie = cookie .. "; Secure"
+  end
+  return cookie
+end
+
+local function add_cookie(cookie)
+  local cookies = ngx.header["Set-Cookie"]
+  if type(cookies) == "table" then
+    table.insert(cookies, cookie)
+  elseif cookies then
+    cookies = { cookies, cookie }
+  else
+    cookies = { cookie }
+  end
+  ngx.header["Set-Cookie"] = cookies
+end
+
+local function request(url, params)
+  local httpc = http.new()
+  httpc:set_timeout(HTTP_TIMEOUT)
+  return httpc:request_uri(url, params)
+end
+
+-- get_discovery returns the metadata of the provider, from the issuer's
+-- /.well-known/openid-configuration document
+local function get_discovery(issuer)
+  local cached = discovery_cache[issuer]
+  if cached and cached.expires_at > ngx.now() then
+    return cached.metadata
+  end
+
+  local res, err = request(issuer .. "/.well-known/openid-configuration", { ssl_verify = true })
+  if not res then
+    return nil, err
+  end
+  if res.status ~= ngx.HTTP_OK then
+    return nil, "unexpected status code " .. tostring(res.status)
+  end
+
+  local metadata = cjson.decode(res.body)
+  if type(metadata) ~= "table" or not metadata.authorization_endpoint or not metadata.token_endpoint then
+    return nil, "invalid provider metadata"
+  end
+  if type(metadata.issuer) ~= "string" or metadata.issuer:gsub("/$", "") ~= issuer then
+    return nil, "the issuer of the provider metadata does not match " .. issuer
+  end
+
+  discovery_cache[issuer] = { metadata = metadata, expires_at = ngx.now() + DISCOVERY_TTL }
+  return metadata
+end
+
+local function redirect_uri(config)
+  return ngx.var.scheme .. "://" .. ngx.var.host .. config.redirect_path
+end
+
+-- token_request calls the token endpoint, authenticating the client with
+-- its secret
+local function token_request(config, provider, args)
+  local metadata, err = get_discovery(config.issuer)
+  if not metadata then
+    return nil, err
+  end
+
+  args.client_id = config.client_id
+  args.client_secret = provider.client_secret
+
+  local res
+  res, err = request(metadata.token_endpoint, {
+    method = "POST",
+    body = ngx.encode_args(args),
+    headers = {
+      ["Content-Type"] = "application/x-www-form-urlencoded",
+      ["Accept"] = "application/json",
+    },
+    ssl_verify = true,
+  })
+  if not res then
+    return nil, err
+  end
+  if res.status ~= ngx.HTTP_OK then
+    return nil, "unexpected status code " .. tostring(res.status)
+  end
+
+  local tokens = cjson.decode(res.body)
+  if type(tokens) ~= "table" or type(tokens.access_token) ~= "string" then
+    return nil, "invalid token response"
+  end
+  return tokens
+end
+
+local function contains(value, expected)
+  if type(value) == "table" then
+    for _, v in ipairs(value) do
+      if v == expected then
+        return true
+      end
+    end
+    return false
+  end
+  return value == expected
+end
+
+-- id_token_claims returns the claims of the ID token received from the token
+-- endpoint. The token is received directly from the provider over TLS, so
+-- its signature is not verified.
+local function id_token_claims(config, id_token, nonce)
+  if type(id_token) ~= "string" then
+    return nil, "missing ID token"
+  end
+
+  local payload = id_token:match("^[%w_-]+%.([%w_-]+)%.[%w_-]*$")
+  local claims = payload and cjson.decode(b64.decode_base64url(payload) or "")
+  if type(claims) ~= "table" then
+    return nil, "malformed ID token"
+  end
+
+  if type(claims.iss) ~= "string" or claims.iss:gsub("/$", "") ~= config.issuer then
+    return nil, "invalid issuer"
+  end
+  if not contains(claims.aud, config.client_id) then
+    return nil, "invalid audience"
+  end
+  if nonce and claims.nonce ~= nonce then
+    return nil, "invalid nonce"
+  end
+  if (tonumber(claims.exp) or 0) <= ngx.time() then
+    return nil, "expired ID token"
+  end
+
+  return claims
+end
+
+local function new_session(tokens, claims, previous)
+  previous = previous or {}
+  claims = claims or {}
+  return {
+    sub = claims.sub or previous.sub,
+    email = claims.email or previous.email,
+    access_token = tokens.access_token,
+    refresh_token = tokens.refresh_token or previous.refresh_token,
+    expires_at = ngx.time() + (tonumber(tokens.expires_in) or 300),
+  }
+end
+
+local function write_session(config, provider, session)
+  local value = encode(provider, cookie_name(config), session)
+  if not value then
+    return false, "error encrypting the session"
+  end
+
+  local cookie = build_cookie(cookie_name(config), value, "/")
+  if #cookie > MAX_COOKIE_SIZE then
+    return false, "the session cookie exceeds " .. MAX_COOKIE_SIZE .. " bytes"
+  end
+
+  add_cookie(cookie)
+  return true
+end
+
+local function set_headers(session)
+  if session.sub then
+    ngx.req.set_header(USER_HEADER, tostring(session.sub))
+  end
+  if session.email then
+    ngx.req.set_header(EMAIL_HEADER, tostring(session.email))
+  end
+  ngx.req.set_header(ACCESS_TOKEN_HEADER, session.access_token)
+end
+
+-- login redirects the user to the authorization endpoint of the provider
+local function login(config, provider)
+  local method = ngx.req.get_method()
+  if method ~= "GET" and method ~= "HEAD" then
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  local metadata, err = get_discovery(config.issuer)
+  if not metadata then
+    ngx_log(ngx_ERR, "error reading the metadata of the provider ", config.issuer, ": ", err)
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local state = {
+    state = random_string(16),
+    nonce = random_string(16),
+    code_verifier = random_string(32),
+    redirect = ngx.var.request_uri,
+  }
+
+  local value = encode(provider, state_cookie_name(config), state)
+  if not value then
+    return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+  end
+  add_cookie(build_cookie(state_cookie_name(config), value, config.redirect_path, STATE_TTL))
+
+  local separator = metadata.authorization_endpoint:find("?", 1, true) and "&" or "?"
+  return ngx.redirect(metadata.authorization_endpoint .. separator .. ngx.encode_args({
+    response_type = "code",
+    client_id = config.client_id,
+    redirect_uri = redirect_uri(config),
+    scope = config.scopes,
+    state = state.state,
+    nonce = state.nonce,
+    code_challenge = b64.encode_base64url(sha256(state.code_verifier)),
+    code_challenge_method = "S256",
+  }))
+end
+
+-- access checks the session of the request, refreshing the access token
+-- when it expires, and redirects the users without a session to the provider
+function _M.access(config)
+  -- the headers are always removed, so clients cannot set them
+  ngx.req.clear_header(USER_HEADER)
+  ngx.req.clear_header(EMAIL_HEADER)
+  ngx.req.clear_header(ACCESS_TOKEN_HEADER)
+
+  local provider = get_provider(config.key)
+  if not provider then
+    ngx_log(ngx_ERR, "secrets of the provider ", config.issuer, " are not available")
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local session = decode(provider, cookie_name(config), ngx.var["cookie_" .. cookie_name(config)])
+  if session and type(session.access_token) == "string" then
+    if (tonumber(session.expires_at) or 0) > ngx.time() + EXPIRY_LEEWAY then
+      set_headers(session)
+      return
+    end
+
+    if session.refresh_token then
+      local tokens, err = token_request(config, provider, {
+        grant_type = "refresh_token",
+        refresh_token = session.refresh_token,
+      })
+      if tokens then
+        local claims
+        if tokens.id_token then
+          claims, err = id_token_claims(config, tokens.id_token)
+        end
+        if claims or not tokens.id_token then
+          session = new_session(tokens, claims, session)
+          local ok
+          ok, err = write_session(config, provider, session)
+          if ok then
+            set_headers(session)
+            return
+          end
+        end
+      end
+      ngx_log(ngx_INFO, "error refreshing the access token: ", err)
+    end
+  end
+
+  return login(config, provider)
+end
+
+-- callback exchanges the authorization code sent by the provider for the
+-- tokens, and redirects the user to the page requested before the login
+function _M.callback(config)
+  local provider = get_provider(config.key)
+  if not provider then
+    ngx_log(ngx_ERR, "secrets of the provider ", config.issuer, " are not available")
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local args = ngx.req.get_uri_args()
+  local state = decode(provider, state_cookie_name(config), ngx.var["cookie_" .. state_cookie_name(config)])
+  if not state or type(args.state) ~= "string" or args.state ~= state.state then
+    ngx_log(ngx_WARN, "invalid login state")
+    return ngx.exit(ngx.HTTP_FORBIDDEN)
+  end
+
+  -- the state can be used only once
+  add_cookie(build_cookie(state_cookie_name(config), "", config.redirect_path, 0))
+
+  if args.error or type(args.code) ~= "string" then
+    ngx_log(ngx_INFO, "login failed: ", tostring(args.error))
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  local tokens, err = token_request(config, provider, {
+    grant_type = "authorization_code",
+    code = args.code,
+    redirect_uri = redirect_uri(config),
+    code_verifier = state.code_verifier,
+  })
+  if not tokens then
+    ngx_log(ngx_ERR, "error exchanging the authorization code: ", err)
+    return ngx.exit(ngx.HTTP_BAD_GATEWAY)
+  end
+
+  local claims
+  claims, err = id_token_claims(config, tokens.id_token, state.nonce)
+  if not claims then
+    ngx_log(ngx_WARN, "invalid ID token: ", err)
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  local ok
+  ok, err = write_session(config, provider, new_session(tokens, claims))
+  if not ok then
+    ngx_log(ngx_ERR, "error writing the session: ", err)
+    return ngx.exit(ngx.HTTP_INTERNAL_SERVER_ERROR)
+  end
+
+  -- only redirect to paths of the same host
+  local redirect = state.redirect
+  if type(redirect) ~= "string" or redirect:sub(1, 1) ~= "/" or redirect:find("^/[/\\]") then
+    redirect = "/"
+  end
+  return ngx.redirect(redirect)
+end
+
+setmetatable(_M, {__index = {
+  encode = encode,
+  decode = decode,
+  get_provider = get_provider,
+  reset_discovery_cache = function() discovery_cache = {} end,
+}})
+
+return _M
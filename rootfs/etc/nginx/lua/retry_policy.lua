@@ -0,0 +1,214 @@
+local ngx_balancer = require("ngx.balancer")
+local ngx = ngx
+local ipairs = ipairs
+local pairs = pairs
+local math_max = math.max
+local math_min = math.min
+local math = math
+local table_insert = table.insert
+
+-- measured in seconds
+-- the budget of a backend counts the requests and retries of the current and
+-- the previous windows, so it does not reset all at once
+local BUDGET_WINDOW = 10
+
+-- the backoff of an endpoint doubles with each try of the request that
+-- failed, up to this multiple of the backoff of the policy
+local MAX_BACKOFF_FACTOR = 10
+
+local _M = {}
+
+-- retry budgets are kept per worker and per backend
+local budgets = {}
+
+local function method_set(policy)
+  local methods = {}
+  for _, method in ipairs(policy.methods or {}) do
+    methods[method] = true
+  end
+  return methods
+end
+
+local function peer_set(endpoints)
+  local peers = {}
+  for _, endpoint in ipairs(endpoints or {}) do
+    peers[endpoint.address .. ":" .. endpoint.port] = true
+  end
+  return peers
+end
+
+local function new_budget(policy)
+  return {
+    policy = policy,
+    methods = method_set(policy),
+    peers = {},
+    -- the time until which each endpoint receives no retry
+    backoffs = {},
+    window_start = ngx.now(),
+    requests = 0,
+    retries = 0,
+    previous_requests = 0,
+    previous_retries = 0,
+  }
+end
+
+local function rotate(budget, now)
+  local elapsed = now - budget.window_start
+  if elapsed < BUDGET_WINDOW then
+    return
+  end
+
+  if elapsed < 2 * BUDGET_WINDOW then
+    budget.previous_requests = budget.requests
+    budget.previous_retries = budget.retries
+  else
+    budget.previous_requests = 0
+    budget.previous_retries = 0
+  end
+  budget.requests = 0
+  budget.retries = 0
+  budget.window_start = now - elapsed % BUDGET_WINDOW
+end
+
+-- has_room returns true when the backend can still retry a request without
+-- exceeding the percentage of retries of the policy. The minimum retries per
+-- second are allowed over the time the counters span, from the start of the
+-- previous window.
+local function has_room(budget, now)
+  local policy = budget.policy
+  local requests = budget.requests + budget.previous_requests
+  local retries = budget.retries + budget.previous_retries
+  local span = BUDGET_WINDOW + now - budget.window_start
+
+  local allowed = math_max((policy.budgetMinRetries or 0) * span,
+                           requests * (policy.budgetPercent or 0) / 100)
+  return retries < allowed
+end
+
+-- back_off keeps the retries away from the endpoint that failed the given try
+-- of a request for a random delay, up to the backoff of the policy doubled
+-- for every previous try
+local function back_off(budget, peer, try, now)
+  local backoff = budget.policy.backoff
+  if not peer or not backoff or backoff <= 0 then
+    return
+  end
+
+  local max_backoff = math_min(backoff * 2 ^ (try - 1), backoff * MAX_BACKOFF_FACTOR)
+  local backoff_end = now + math.random() * max_backoff / 1000
+  budget.backoffs[peer] = math_max(budget.backoffs[peer] or 0, backoff_end)
+end
+
+-- available_peer returns a random endpoint of the backend not backing off
+local function available_peer(budget, now)
+  local available = {}
+  for peer, _ in pairs(budget.peers) do
+    local backoff = budget.backoffs[peer]
+    if not backoff or backoff <= now then
+      table_insert(available, peer)
+    end
+  end
+
+  if #available == 0 then
+    return nil
+  end
+  return available[math.random(#available)]
+end
+
+-- sync stores the retry policy of a backend. The counters of the budget are
+-- kept when the policy changes since they describe the traffic of the backend.
+function _M.sync(backend)
+  local policy = backend.retryPolicy
+  if not policy or not policy.attempts or policy.attempts == 0 then
+    budgets[backend.name] = nil
+    return
+  end
+
+  local budget = budgets[backend.name]
+  if not budget then
+    budget = new_budget(policy)
+    budgets[backend.name] = budget
+  end
+
+  budget.policy = policy
+  budget.methods = method_set(policy)
+  budget.peers = peer_set(backend.endpoints)
+  for peer, _ in pairs(budget.backoffs) do
+    if not budget.peers[peer] then
+      budget.backoffs[peer] = nil
+    end
+  end
+end
+
+-- keep drops the budgets of the backends not in the given set
+function _M.keep(backend_names)
+  for name, _ in pairs(budgets) do
+    if not backend_names[name] then
+      budgets[name] = nil
+    end
+  end
+end
+
+-- before_try is called by the balancer before each try of a request sent to
+-- the given peer of the backend. It applies the per try timeout and the
+-- backoff of the endpoint of the previous try when it failed, and returns the
+-- peer of the try and whether NGINX can try the request once more if this try
+-- fails. A retry moves to an endpoint not backing off when movable is true,
+-- for the balancers picking endpoints without keeping state about them.
+function _M.before_try(backend_name, peer, movable)
+  local budget = budgets[backend_name]
+  if not budget then
+    return peer, true
+  end
+
+  local policy = budget.policy
+  local now = ngx.now()
+  rotate(budget, now)
+
+  local ctx = ngx.ctx
+  local tries = ctx.retry_policy_tries or 0
+  local state = ngx_balancer.get_last_failure()
+  if state then
+    budget.retries = budget.retries + 1
+    back_off(budget, ctx.retry_policy_peer, tries, now)
+
+    local backoff = budget.backoffs[peer]
+    if movable and backoff and backoff > now then
+      peer = available_peer(budget, now) or peer
+    end
+  else
+    budget.requests = budget.requests + 1
+  end
+  ctx.retry_policy_tries = tries + 1
+  ctx.retry_policy_peer = peer
+
+  if policy.perTryTimeout and policy.perTryTimeout > 0 then
+    local timeout = policy.perTryTimeout / 1000
+    local ok, err = ngx_balancer.set_timeouts(nil, timeout, timeout)
+    if not ok then
+      ngx.log(ngx.ERR, "error while setting the per try timeout of backend ",
+              backend_name, ": ", err)
+    end
+  end
+
+  if not budget.methods[ngx.req.get_method()] then
+    return peer, false
+  end
+
+  if not has_room(budget, now) then
+    ctx.retry_budget_exhausted = true
+    return peer, false
+  end
+
+  return peer, true
+end
+
+setmetatable(_M, {__index = {
+  budgets = budgets,
+  rotate = rotate,
+  has_room = has_room,
+  BUDGET_WINDOW = BUDGET_WINDOW,
+  MAX_BACKOFF_FACTOR = MAX_BACKOFF_FACTOR,
+}})
+
+return _M
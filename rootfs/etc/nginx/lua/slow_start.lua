@@ -0,0 +1,118 @@
+local ngx = ngx
+local ipairs = ipairs
+local pairs = pairs
+local math_max = math.max
+local math_floor = math.floor
+
+-- the share of the traffic an endpoint receives when it is added, so the
+-- requests warm it up from the beginning of the window
+local MIN_FACTOR = 0.1
+
+-- the weights of the nodes are multiplied by this scale while some endpoints
+-- are in their slow start window, so they stay integers as resty.roundrobin
+-- requires
+local WEIGHT_SCALE = 10
+
+local _M = {}
+
+-- the first time each endpoint of a backend was seen, per worker
+-- { [backend name] = { window = 30, first_seen = { ["addr:port"] = time } } }
+local backends = {}
+
+local function peer_of(endpoint)
+  return endpoint.address .. ":" .. endpoint.port
+end
+
+-- sync records the first time the endpoints of the backend were seen and
+-- forgets the endpoints removed, so an endpoint coming back ramps up again.
+-- The endpoints present at the first sync of a backend in a worker are
+-- considered warm since they were already receiving traffic.
+function _M.sync(backend)
+  local window = backend.slowStartWindow or 0
+  if window <= 0 then
+    backends[backend.name] = nil
+    return
+  end
+
+  local state = backends[backend.name]
+  local initial = state == nil
+  if initial then
+    state = { first_seen = {} }
+    backends[backend.name] = state
+  end
+  state.window = window
+
+  local now = ngx.now()
+  local first_seen = {}
+  for _, endpoint in ipairs(backend.endpoints or {}) do
+    local peer = peer_of(endpoint)
+    if initial then
+      first_seen[peer] = 0
+    else
+      first_seen[peer] = state.first_seen[peer] or now
+    end
+  end
+  state.first_seen = first_seen
+end
+
+-- keep drops the state of the backends removed
+function _M.keep(names)
+  for name, _ in pairs(backends) do
+    if not names[name] then
+      backends[name] = nil
+    end
+  end
+end
+
+-- factor returns the share of its traffic an endpoint of the backend
+-- receives, from MIN_FACTOR when it is added to 1 at the end of the window
+function _M.factor(backend_name, peer)
+  local state = backends[backend_name]
+  if not state then
+    return 1
+  end
+
+  local first_seen = state.first_seen[peer]
+  if not first_seen then
+    return 1
+  end
+
+  local elapsed = ngx.now() - first_seen
+  if elapsed >= state.window then
+    return 1
+  end
+
+  return math_max(elapsed / state.window, MIN_FACTOR)
+end
+
+-- weigh returns the nodes of the backend, mapping the peers to their weight,
+-- with the weights multiplied by the factor of the endpoints, and whether some
+-- endpoints are still in their slow start window
+function _M.weigh(backend_name, nodes)
+  if not backends[backend_name] then
+    return nodes, false
+  end
+
+  local warming = false
+  local weighed = {}
+  for peer, weight in pairs(nodes) do
+    local factor = _M.factor(backend_name, peer)
+    if factor < 1 then
+      warming = true
+    end
+    weighed[peer] = math_max(math_floor(weight * WEIGHT_SCALE * factor + 0.5), 1)
+  end
+
+  if not warming then
+    return nodes, false
+  end
+  return weighed, true
+end
+
+setmetatable(_M, {__index = {
+  backends = backends,
+  MIN_FACTOR = MIN_FACTOR,
+  WEIGHT_SCALE = WEIGHT_SCALE,
+}})
+
+return _M
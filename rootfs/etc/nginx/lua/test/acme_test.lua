@@ -0,0 +1,59 @@
+describe("acme", function()
+  local original_ngx_var = ngx.var
+  local acme
+  local configuration
+
+  before_each(function()
+    ngx.var = { uri = "/" }
+    stub(ngx, "print")
+    stub(ngx, "exit")
+    configuration = require_without_cache("configuration")
+    acme = require_without_cache("acme")
+  end)
+
+  after_each(function()
+    ngx.var = original_ngx_var
+    ngx.shared.configuration_data:delete("acme_challenges")
+  end)
+
+  describe("rewrite()", function()
+    it("answers the pending challenges", function()
+      ngx.shared.configuration_data:set("acme_challenges", '{"abc-123":"abc-123.thumbprint"}')
+      ngx.var.uri = "/.well-known/acme-challenge/abc-123"
+
+      acme.rewrite()
+
+      assert.stub(ngx.print).was_called_with("abc-123.thumbprint")
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_OK)
+    end)
+
+    it("ignores the unknown tokens", function()
+      ngx.shared.configuration_data:set("acme_challenges", '{"abc-123":"abc-123.thumbprint"}')
+      ngx.var.uri = "/.well-known/acme-challenge/def-456"
+
+      acme.rewrite()
+
+      assert.stub(ngx.print).was_not_called()
+      assert.stub(ngx.exit).was_not_called()
+    end)
+
+    it("ignores the other paths", function()
+      ngx.shared.configuration_data:set("acme_challenges", '{"abc-123":"abc-123.thumbprint"}')
+      ngx.var.uri = "/abc-123"
+
+      acme.rewrite()
+
+      assert.stub(ngx.exit).was_not_called()
+    end)
+
+    it("uses the challenges sent last by the controller", function()
+      ngx.var.uri = "/.well-known/acme-challenge/abc-123"
+      acme.rewrite()
+      assert.stub(ngx.exit).was_not_called()
+
+      ngx.shared.configuration_data:set("acme_challenges", '{"abc-123":"abc-123.thumbprint"}')
+      acme.rewrite()
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_OK)
+    end)
+  end)
+end)
@@ -110,6 +110,38 @@ describe("Balancer ewma", function()
       assert.are.equals(0.16240233988393523723, ngx.var.balancer_ewma_score)
     end)
 
+    it("divides the decayed score by the weight of the endpoint", function()
+      local two_endpoints_backend = util.deepcopy(backend)
+      table.remove(two_endpoints_backend.endpoints, 2)
+      two_endpoints_backend.endpoints[1].weight = 2
+      local two_endpoints_instance = balancer_ewma:new(two_endpoints_backend)
+
+      local peer = two_endpoints_instance:balance()
+
+      -- 10.10.10.3:8080 has the lowest decayed score
+      -- but 10.10.10.1:8080 has twice its weight
+      assert.equal("10.10.10.1:8080", peer)
+      assert.are.equals(0.2 * math.exp(-1 / 10), ngx.var.balancer_ewma_score)
+    end)
+
+    it("divides the decayed score by the slow start factor of the endpoint", function()
+      local slow_start = require("slow_start")
+      local two_endpoints_backend = util.deepcopy(backend)
+      table.remove(two_endpoints_backend.endpoints, 2)
+      two_endpoints_backend.slowStartWindow = 30
+      slow_start.sync({ name = backend.name, slowStartWindow = 30,
+                        endpoints = { two_endpoints_backend.endpoints[1] } })
+      slow_start.sync(two_endpoints_backend)
+      local two_endpoints_instance = balancer_ewma:new(two_endpoints_backend)
+
+      local peer = two_endpoints_instance:balance()
+      slow_start.keep({})
+
+      -- 10.10.10.3:8080 has the lowest decayed score
+      -- but it was just added and receives a tenth of its share
+      assert.equal("10.10.10.1:8080", peer)
+    end)
+
     it("doesn't pick the tried endpoint while retry", function()
       local two_endpoints_backend = util.deepcopy(backend)
       table.remove(two_endpoints_backend.endpoints, 2)
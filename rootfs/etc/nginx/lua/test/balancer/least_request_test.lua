@@ -0,0 +1,117 @@
+local original_ngx = ngx
+local function reset_ngx()
+  _G.ngx = original_ngx
+end
+
+local function mock_ngx(mock)
+  local _ngx = mock
+  setmetatable(_ngx, { __index = ngx })
+  _G.ngx = _ngx
+end
+
+describe("Balancer least_request", function()
+  local balancer_least_request = require("balancer.least_request")
+  local backend, instance
+
+  before_each(function()
+    mock_ngx({ ctx = {}, var = {} })
+
+    backend = {
+      name = "namespace-service-port", ["load-balance"] = "least_request",
+      endpoints = {
+        { address = "10.10.10.1", port = "8080", maxFails = 0, failTimeout = 0 },
+        { address = "10.10.10.2", port = "8080", maxFails = 0, failTimeout = 0 },
+      }
+    }
+    instance = balancer_least_request:new(backend)
+  end)
+
+  after_each(function()
+    reset_ngx()
+  end)
+
+  describe("balance()", function()
+    it("returns the single endpoint when there is only one", function()
+      backend.endpoints = { { address = "10.10.10.1", port = "8080", maxFails = 0, failTimeout = 0 } }
+      instance = balancer_least_request:new(backend)
+
+      assert.are.equals("10.10.10.1:8080", instance:balance())
+      assert.are.same({ ["10.10.10.1:8080"] = 1 }, instance.in_flight)
+    end)
+
+    it("picks the endpoint with the fewest requests in flight", function()
+      instance.in_flight["10.10.10.1:8080"] = 3
+
+      assert.are.equals("10.10.10.2:8080", instance:balance())
+      assert.are.equals(1, instance.in_flight["10.10.10.2:8080"])
+    end)
+
+    it("divides the requests in flight by the weight of the endpoints", function()
+      backend.endpoints[1].weight = 4
+      instance:sync(backend)
+      instance.in_flight["10.10.10.1:8080"] = 2
+
+      assert.are.equals("10.10.10.1:8080", instance:balance())
+    end)
+
+    it("retries on another endpoint", function()
+      instance.in_flight["10.10.10.1:8080"] = 3
+
+      assert.are.equals("10.10.10.2:8080", instance:balance())
+      assert.are.equals("10.10.10.1:8080", instance:balance())
+    end)
+  end)
+
+  describe("release()", function()
+    it("releases the endpoints of every try", function()
+      instance.in_flight["10.10.10.1:8080"] = 3
+
+      instance:balance()
+      instance:balance()
+      instance:balance()
+      balancer_least_request.release()
+
+      assert.are.same({ ["10.10.10.1:8080"] = 3 }, instance.in_flight)
+      assert.are.equals("", ngx.var.least_request_tried_endpoints)
+    end)
+
+    it("releases the endpoints after an internal redirect cleared the context", function()
+      instance:balance()
+      ngx.ctx = {}
+      balancer_least_request.release()
+
+      assert.are.same({}, instance.in_flight)
+    end)
+
+    it("releases the endpoints of every backend the request was balanced to", function()
+      local error_backend = {
+        name = "namespace-errors-port", ["load-balance"] = "least_request",
+        endpoints = { { address = "10.10.10.9", port = "8080", maxFails = 0, failTimeout = 0 } },
+      }
+      local error_instance = balancer_least_request:new(error_backend)
+
+      instance:balance()
+      ngx.ctx = {}
+      error_instance:balance()
+      assert.are.same({ ["10.10.10.9:8080"] = 1 }, error_instance.in_flight)
+
+      balancer_least_request.release()
+
+      assert.are.same({}, instance.in_flight)
+      assert.are.same({}, error_instance.in_flight)
+    end)
+  end)
+
+  describe("sync()", function()
+    it("forgets the requests in flight of the removed endpoints", function()
+      instance.in_flight["10.10.10.1:8080"] = 2
+      instance.in_flight["10.10.10.2:8080"] = 1
+
+      backend.endpoints = { { address = "10.10.10.2", port = "8080", maxFails = 0, failTimeout = 0 } }
+      instance:sync(backend)
+
+      assert.are.same({ ["10.10.10.2:8080"] = 1 }, instance.in_flight)
+      assert.are.same(backend.endpoints, instance.peers)
+    end)
+  end)
+end)
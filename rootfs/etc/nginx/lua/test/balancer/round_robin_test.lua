@@ -0,0 +1,64 @@
+local slow_start = require("slow_start")
+
+describe("Balancer round_robin", function()
+  local balancer_round_robin = require("balancer.round_robin")
+  local now
+
+  local backend = {
+    name = "default-web-80", ["load-balance"] = "round_robin", slowStartWindow = 30,
+    endpoints = {
+      { address = "10.10.10.1", port = "8080", maxFails = 0, failTimeout = 0 },
+      { address = "10.10.10.2", port = "8080", maxFails = 0, failTimeout = 0 },
+    }
+  }
+
+  local function count_peers(instance, n)
+    local counts = {}
+    for _ = 1, n do
+      local peer = instance:balance()
+      counts[peer] = (counts[peer] or 0) + 1
+    end
+    return counts
+  end
+
+  before_each(function()
+    now = 1000
+    stub(ngx, "now", function() return now end)
+    slow_start.sync({ name = backend.name, slowStartWindow = 30, endpoints = { backend.endpoints[1] } })
+    slow_start.sync(backend)
+  end)
+
+  after_each(function()
+    ngx.now:revert()
+    slow_start.keep({})
+  end)
+
+  describe("balance()", function()
+    it("sends a tenth of its share to an endpoint added", function()
+      local instance = balancer_round_robin:new(backend)
+      local counts = count_peers(instance, 110)
+      assert.are.same({ ["10.10.10.1:8080"] = 100, ["10.10.10.2:8080"] = 10 }, counts)
+    end)
+
+    it("ramps up the share of an endpoint added linearly", function()
+      local instance = balancer_round_robin:new(backend)
+
+      now = now + 15
+      local counts = count_peers(instance, 150)
+      assert.are.same({ ["10.10.10.1:8080"] = 100, ["10.10.10.2:8080"] = 50 }, counts)
+
+      now = now + 15
+      counts = count_peers(instance, 20)
+      assert.are.same({ ["10.10.10.1:8080"] = 10, ["10.10.10.2:8080"] = 10 }, counts)
+      assert.is_nil(instance.warming_backend)
+    end)
+  end)
+
+  describe("sync()", function()
+    it("weighs the endpoints added", function()
+      local instance = balancer_round_robin:new({ name = backend.name, endpoints = { backend.endpoints[1] } })
+      instance:sync(backend)
+      assert.are.same({ ["10.10.10.1:8080"] = 10, ["10.10.10.2:8080"] = 1 }, instance.instance.nodes)
+    end)
+  end)
+end)
@@ -624,4 +624,88 @@ describe("Sticky", function()
     it("constructs correct cookie value", function() test_with(sticky_persistent) end)
 
   end)
+
+  describe("balance() with draining endpoints", function()
+    local mocked_cookie_new = cookie.new
+
+    local function get_draining_test_backend()
+      local backend = get_several_test_backends(false)
+      backend.endpoints[2].draining = true
+      return backend
+    end
+    local draining_endpoint = "10.184.7.41:8080"
+
+    -- cookie_key_of returns the key of a session of the endpoint
+    local function cookie_key_of(sticky_balancer_instance, endpoint)
+      for key, node in pairs(sticky_balancer_instance.instance.map or {}) do
+        if node == endpoint then
+          return key
+        end
+      end
+
+      for i = 1, 1000 do
+        local key = tostring(i)
+        if sticky_balancer_instance.instance:find(key) == endpoint then
+          return key
+        end
+      end
+    end
+
+    local function mock_cookie(value)
+      cookie.new = function(self)
+        return {
+          set = function(self, payload) return true, nil end,
+          get = function(self, k) return value end,
+        }, false
+      end
+    end
+
+    before_each(function()
+      reset_sticky_balancer()
+    end)
+
+    after_each(function()
+      cookie.new = mocked_cookie_new
+    end)
+
+    local function test_keeps_sessions_with(sticky_balancer_type)
+      local sticky_balancer_instance = sticky_balancer_type:new(get_draining_test_backend())
+      mock_cookie(cookie_key_of(sticky_balancer_instance, draining_endpoint))
+
+      assert.equal(draining_endpoint, sticky_balancer_instance:balance())
+    end
+
+    it("keeps the sessions of a draining endpoint", function() test_keeps_sessions_with(sticky_balanced) end)
+    it("keeps the sessions of a draining endpoint", function() test_keeps_sessions_with(sticky_persistent) end)
+
+    local function test_no_new_session_with(sticky_balancer_type)
+      local sticky_balancer_instance = sticky_balancer_type:new(get_draining_test_backend())
+      mock_cookie(nil)
+
+      for _ = 1, 20 do
+        assert.equal("10.184.7.40:8080", sticky_balancer_instance:balance())
+      end
+    end
+
+    it("sends no new session to a draining endpoint", function() test_no_new_session_with(sticky_balanced) end)
+    it("sends no new session to a draining endpoint", function() test_no_new_session_with(sticky_persistent) end)
+
+    local function test_every_endpoint_draining_with(sticky_balancer_type)
+      local backend = get_draining_test_backend()
+      assert.is_false(sticky_balancer_type:new(backend).draining)
+
+      backend.endpoints[1].draining = true
+      local sticky_balancer_instance = sticky_balancer_type:new(backend)
+      assert.is_true(sticky_balancer_instance.draining)
+
+      mock_cookie(cookie_key_of(sticky_balancer_instance, draining_endpoint))
+      assert.equal(draining_endpoint, sticky_balancer_instance:balance())
+
+      mock_cookie(nil)
+      assert.is_nil(sticky_balancer_instance:balance())
+    end
+
+    it("keeps the sessions when every endpoint is draining", function() test_every_endpoint_draining_with(sticky_balanced) end)
+    it("keeps the sessions when every endpoint is draining", function() test_every_endpoint_draining_with(sticky_persistent) end)
+  end)
 end)
@@ -37,7 +37,8 @@ local function reset_expected_implementations()
     ["my-dummy-app-3"] = package.loaded["balancer.sticky_persistent"],
     ["my-dummy-app-4"] = package.loaded["balancer.ewma"],
     ["my-dummy-app-5"] = package.loaded["balancer.sticky_balanced"],
-    ["my-dummy-app-6"] = package.loaded["balancer.chashsubset"]
+    ["my-dummy-app-6"] = package.loaded["balancer.chashsubset"],
+    ["my-dummy-app-7"] = package.loaded["balancer.least_request"]
   }
 end
 
@@ -88,6 +89,10 @@ local function reset_backends()
       ["load-balance"] = "ewma",                  -- upstreamHashByConfig will take priority.
       upstreamHashByConfig = { ["upstream-hash-by"] = "$request_uri", ["upstream-hash-by-subset"] = "true", }
     },
+    {
+      name = "my-dummy-app-7",
+      ["load-balance"] = "least_request",
+    },
   }
 end
 
@@ -191,6 +196,14 @@ describe("Balancer", function()
         assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
       end)
 
+      it("returns false when every endpoint of the alternative backend is draining", function()
+        backend.trafficShapingPolicy.weight = 100
+        balancer.sync_backend(backend)
+        local alternativeBalancer = balancer.get_balancer_by_upstream_name(backend.name)
+        alternativeBalancer.draining = true
+        assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
+      end)
+
       describe("canary by weight", function()
         it("returns true when weight is 100", function()
           backend.trafficShapingPolicy.weight = 100
@@ -341,6 +354,71 @@ describe("Balancer", function()
 
     end)
 
+    describe("traffic split", function()
+      local second_backend
+
+      before_each(function()
+        _primaryBalancer.is_affinitized = function (_)
+          return false
+        end
+
+        second_backend = util.deepcopy(backend)
+        second_backend.name = "access-router-production-web-v3-80"
+        _primaryBalancer.alternative_backends = { backend.name, second_backend.name }
+      end)
+
+      it("returns the alternative backend selected by the weights", function()
+        backend.trafficShapingPolicy.weight = 0
+        second_backend.trafficShapingPolicy.weight = 100
+        balancer.sync_backend(backend)
+        balancer.sync_backend(second_backend)
+
+        local alternative, name = balancer.route_to_alternative_balancer(_primaryBalancer)
+        assert.is_true(alternative)
+        assert.equal(second_backend.name, name)
+      end)
+
+      it("returns false when the weights leave traffic to the primary backend", function()
+        backend.trafficShapingPolicy.weight = 0
+        second_backend.trafficShapingPolicy.weight = 0
+        balancer.sync_backend(backend)
+        balancer.sync_backend(second_backend)
+
+        assert.is_false(balancer.route_to_alternative_balancer(_primaryBalancer))
+      end)
+
+      it("prefers header matchers over weights", function()
+        mock_ngx({ var = { ["http_x_version"] = "v3", request_uri = "/" } })
+        _primaryBalancer.is_affinitized = function (_)
+          return false
+        end
+
+        backend.trafficShapingPolicy.weight = 100
+        second_backend.trafficShapingPolicy.header = "x-version"
+        second_backend.trafficShapingPolicy.headerValue = "v3"
+        balancer.sync_backend(backend)
+        balancer.sync_backend(second_backend)
+
+        local alternative, name = balancer.route_to_alternative_balancer(_primaryBalancer)
+        assert.is_true(alternative)
+        assert.equal(second_backend.name, name)
+      end)
+
+      it("keeps the traffic of an excluded backend in the primary backend", function()
+        mock_ngx({ var = { ["cookie_split"] = "never", request_uri = "/" } })
+        _primaryBalancer.is_affinitized = function (_)
+          return false
+        end
+
+        backend.trafficShapingPolicy.weight = 100
+        backend.trafficShapingPolicy.cookie = "split"
+        balancer.sync_backend(backend)
+        balancer.sync_backend(second_backend)
+
+        assert.is_false(balancer.route_to_alternative_balancer(_primaryBalancer))
+      end)
+    end)
+
     -- Affinitized request prefers backend it is affinitized to.
     describe("affinitized", function()
 
@@ -385,6 +463,58 @@ describe("Balancer", function()
     end)
   end)
 
+  describe("route_to_matching_balancer()", function()
+    local backend, _primaryBalancer
+
+    before_each(function()
+      backend = backends[1]
+      _primaryBalancer = {
+        route_matches = {
+          { backend = backend.name, methods = { "POST" }, header = "x-api", headerValue = "v2" },
+        }
+      }
+    end)
+
+    it("returns the backend of the route when every condition matches", function()
+      mock_ngx({ var = { ["http_x_api"] = "v2", request_uri = "/" },
+                 req = { get_method = function() return "POST" end } })
+      balancer.sync_backend(backend)
+      assert.equal(backend.name, balancer.route_to_matching_balancer(_primaryBalancer))
+    end)
+
+    it("returns nil when the method does not match", function()
+      mock_ngx({ var = { ["http_x_api"] = "v2", request_uri = "/" },
+                 req = { get_method = function() return "GET" end } })
+      balancer.sync_backend(backend)
+      assert.is_nil(balancer.route_to_matching_balancer(_primaryBalancer))
+    end)
+
+    it("matches query parameters using a pattern", function()
+      mock_ngx({ var = { ["arg_version"] = "2.1", request_uri = "/?version=2.1" } })
+      _primaryBalancer.route_matches = {
+        { backend = backend.name, queryParam = "version", queryPattern = "^2\\." },
+      }
+      balancer.sync_backend(backend)
+      assert.equal(backend.name, balancer.route_to_matching_balancer(_primaryBalancer))
+    end)
+
+    it("returns nil when the query parameter is missing", function()
+      mock_ngx({ var = { request_uri = "/" } })
+      _primaryBalancer.route_matches = {
+        { backend = backend.name, queryParam = "version" },
+      }
+      balancer.sync_backend(backend)
+      assert.is_nil(balancer.route_to_matching_balancer(_primaryBalancer))
+    end)
+
+    it("returns nil when the backend of the route does not exist", function()
+      mock_ngx({ var = { ["http_x_api"] = "v2", request_uri = "/" },
+                 req = { get_method = function() return "POST" end } })
+      _primaryBalancer.route_matches[1].backend = "nonExistingBackend"
+      assert.is_nil(balancer.route_to_matching_balancer(_primaryBalancer))
+    end)
+  end)
+
   describe("sync_backend()", function()
     local backend, implementation
 
@@ -466,6 +596,104 @@ describe("Balancer", function()
       assert.stub(mock_instance.sync).was_called_with(mock_instance, expected_backend)
     end)
 
+    it("excludes the endpoints failing the health checks", function()
+      local backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", health = "healthy" },
+          { address = "10.0.0.2", port = "8080", health = "unhealthy" },
+        }
+      }
+      local expected_backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", health = "healthy" },
+        }
+      }
+
+      local s = spy.on(implementation, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_called_with(implementation, expected_backend)
+    end)
+
+    it("keeps every endpoint when all of them fail the health checks", function()
+      local backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", health = "unhealthy" },
+          { address = "10.0.0.2", port = "8080", health = "unhealthy" },
+        }
+      }
+
+      local s = spy.on(implementation, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_called_with(implementation, backend)
+    end)
+
+    it("excludes the draining endpoints", function()
+      local backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080" },
+          { address = "10.0.0.2", port = "8080", draining = true },
+        }
+      }
+      local expected_backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080" },
+        }
+      }
+
+      local s = spy.on(implementation, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_called_with(implementation, expected_backend)
+    end)
+
+    it("keeps the draining endpoints of the sticky sessions", function()
+      local backend = {
+        name = "example-com",
+        sessionAffinityConfig = { name = "cookie", cookieSessionAffinity = { name = "route" } },
+        endpoints = {
+          { address = "10.0.0.1", port = "8080" },
+          { address = "10.0.0.2", port = "8080", draining = true },
+        }
+      }
+      local sticky = balancer.get_implementation(backend)
+
+      local s = spy.on(sticky, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_called_with(sticky, backend)
+    end)
+
+    it("keeps the sticky sessions when every endpoint is draining", function()
+      local backend = {
+        name = "example-com",
+        sessionAffinityConfig = { name = "cookie", cookieSessionAffinity = { name = "route" } },
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", draining = true },
+        }
+      }
+      local sticky = balancer.get_implementation(backend)
+
+      local s = spy.on(sticky, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_called_with(sticky, backend)
+    end)
+
+    it("removes the balancer when every endpoint is draining", function()
+      local backend = {
+        name = "example-com",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", draining = true },
+        }
+      }
+
+      local s = spy.on(implementation, "new")
+      assert.has_no.errors(function() balancer.sync_backend(util.deepcopy(backend)) end)
+      assert.spy(s).was_not_called()
+    end)
+
     it("replaces the existing balancer when load balancing config changes for backend", function()
       assert.has_no.errors(function() balancer.sync_backend(backend) end)
 
@@ -497,6 +725,28 @@ describe("Balancer", function()
     end)
   end)
 
+  describe("rewrite()", function()
+    it("rejects the new sessions of a sticky backend whose endpoints are all draining", function()
+      local backend = {
+        name = "example-com",
+        sessionAffinityConfig = { name = "cookie", cookieSessionAffinity = { name = "route" } },
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", draining = true },
+        }
+      }
+      balancer.sync_backend(util.deepcopy(backend))
+      local sticky_balancer = balancer.get_balancer_by_upstream_name(backend.name)
+
+      mock_ngx({ var = { proxy_upstream_name = backend.name }, ctx = {}, exit = function(status) return status end })
+      sticky_balancer.is_affinitized = function(_) return false end
+      assert.equal(ngx.HTTP_SERVICE_UNAVAILABLE, balancer.rewrite())
+
+      ngx.ctx = {}
+      sticky_balancer.is_affinitized = function(_) return true end
+      assert.is_nil(balancer.rewrite())
+    end)
+  end)
+
   describe("sync_backends()", function()
 
     after_each(function()
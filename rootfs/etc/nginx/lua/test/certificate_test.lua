@@ -13,6 +13,7 @@ local DEFAULT_CERT = read_file("rootfs/etc/nginx/lua/test/fixtures/default-cert.
 local DEFAULT_CERT_HOSTNAME = "_"
 local UUID = "2ea8adb5-8ebb-4b14-a79b-0cdcd892e884"
 local DEFAULT_UUID = "00000000-0000-0000-0000-000000000000"
+local ALTERNATE_UUID = "2ea8adb5-8ebb-4b14-a79b-0cdcd892e884-alt"
 
 local function assert_certificate_is_set(cert)
   spy.on(ngx, "log")
@@ -65,6 +66,7 @@ describe("Certificate", function()
       ngx = unmocked_ngx
       ngx.shared.certificate_data:flush_all()
       ngx.shared.certificate_servers:flush_all()
+      ngx.shared.certificate_alternates:flush_all()
     end)
 
     it("sets certificate and key when hostname is found in dictionary", function()
@@ -100,6 +102,41 @@ describe("Certificate", function()
       assert_certificate_is_set(EXAMPLE_CERT)
     end)
 
+    it("sets the alternate certificate and key next to the certificate", function()
+      set_certificate("hostname", EXAMPLE_CERT, UUID)
+      ngx.shared.certificate_alternates:set("hostname", ALTERNATE_UUID)
+      ngx.shared.certificate_data:set(ALTERNATE_UUID, DEFAULT_CERT)
+
+      assert_certificate_is_set(EXAMPLE_CERT)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(DEFAULT_CERT))
+      assert.spy(ssl.set_der_priv_key).was_called_with(ssl.priv_key_pem_to_der(DEFAULT_CERT))
+    end)
+
+    it("sets the alternate certificate of a wildcard cert", function()
+      ssl.server_name = function() return "sub.hostname", nil end
+      set_certificate("*.hostname", EXAMPLE_CERT, UUID)
+      ngx.shared.certificate_alternates:set("*.hostname", ALTERNATE_UUID)
+      ngx.shared.certificate_data:set(ALTERNATE_UUID, DEFAULT_CERT)
+
+      assert_certificate_is_set(EXAMPLE_CERT)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(DEFAULT_CERT))
+    end)
+
+    it("keeps the certificate when the alternate certificate is invalid", function()
+      set_certificate("hostname", EXAMPLE_CERT, UUID)
+      ngx.shared.certificate_alternates:set("hostname", ALTERNATE_UUID)
+      ngx.shared.certificate_data:set(ALTERNATE_UUID, "something invalid")
+
+      spy.on(ngx, "log")
+      spy.on(ssl, "set_der_cert")
+
+      assert.has_no.errors(certificate.call)
+      assert.spy(ssl.set_der_cert).was_called(1)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(EXAMPLE_CERT))
+      assert.spy(ngx.log).was_called_with(ngx.ERR, "alternate certificate: ",
+        "failed to convert certificate chain from PEM to DER: PEM_read_bio_X509_AUX() failed")
+    end)
+
     it("logs error message when certificate in dictionary is invalid", function()
       set_certificate("hostname", "something invalid", UUID)
 
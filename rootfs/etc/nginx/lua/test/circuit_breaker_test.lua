@@ -0,0 +1,150 @@
+describe("circuit_breaker", function()
+  local circuit_breaker
+  local now
+  local original_ngx_var = ngx.var
+
+  local backend = {
+    name = "default-web-80",
+    circuitBreaker = {
+      maxRequests = 2,
+      maxPending = 1,
+      status = 429,
+    },
+  }
+
+  local function acquire()
+    ngx.var.circuit_breaker_backend = ""
+    local status = circuit_breaker.acquire(backend.name)
+    local acquired = ngx.var.circuit_breaker_backend
+    if acquired == "" then
+      acquired = nil
+    end
+    return status, acquired
+  end
+
+  local function active()
+    return ngx.shared.circuit_breaker:get(backend.name .. ":active")
+  end
+
+  before_each(function()
+    now = 1000
+    stub(ngx, "now", function() return now end)
+    stub(ngx, "sleep", function(seconds) now = now + seconds end)
+    ngx.shared.circuit_breaker:flush_all()
+    ngx.var = { circuit_breaker_backend = "" }
+    ngx.ctx.circuit_breaker_transition = nil
+    circuit_breaker = require_without_cache("circuit_breaker")
+    circuit_breaker.sync(backend)
+  end)
+
+  after_each(function()
+    ngx.var = original_ngx_var
+    ngx.now:revert()
+    ngx.sleep:revert()
+  end)
+
+  describe("acquire()", function()
+    it("allows any request to the backends without circuit breaker", function()
+      assert.is_nil(circuit_breaker.acquire("default-other-80"))
+      assert.equal("", ngx.var.circuit_breaker_backend)
+    end)
+
+    it("counts the requests in flight", function()
+      assert.is_nil(acquire())
+      assert.is_nil(acquire())
+      assert.equal(2, active())
+      assert.stub(ngx.sleep).was_not_called()
+    end)
+
+    it("rejects the requests when the pending requests time out", function()
+      acquire()
+      acquire()
+
+      local status, acquired = acquire()
+      assert.equal(429, status)
+      assert.is_nil(acquired)
+      assert.is_true(now >= 1000 + circuit_breaker.PENDING_TIMEOUT)
+      assert.equal(2, active())
+      assert.equal(0, ngx.shared.circuit_breaker:get(backend.name .. ":pending"))
+    end)
+
+    it("lets a pending request through when a request in flight finishes", function()
+      acquire()
+      acquire()
+
+      ngx.sleep:revert()
+      stub(ngx, "sleep", function(seconds)
+        now = now + seconds
+        ngx.shared.circuit_breaker:incr(backend.name .. ":active", -1)
+      end)
+
+      local status, acquired = acquire()
+      assert.is_nil(status)
+      assert.equal(backend.name, acquired)
+      assert.equal(2, active())
+    end)
+
+    it("rejects the requests without waiting when the pending requests are full", function()
+      backend.circuitBreaker.maxPending = 0
+      circuit_breaker.sync(backend)
+
+      acquire()
+      acquire()
+      assert.equal(429, acquire())
+      assert.stub(ngx.sleep).was_not_called()
+
+      backend.circuitBreaker.maxPending = 1
+    end)
+
+    it("reports the transitions of the circuit breaker", function()
+      acquire()
+      acquire()
+
+      acquire()
+      assert.are.same({ backend = backend.name, state = "open" }, ngx.ctx.circuit_breaker_transition)
+
+      ngx.ctx.circuit_breaker_transition = nil
+      acquire()
+      assert.is_nil(ngx.ctx.circuit_breaker_transition)
+
+      ngx.shared.circuit_breaker:incr(backend.name .. ":active", -1)
+      acquire()
+      assert.are.same({ backend = backend.name, state = "closed" }, ngx.ctx.circuit_breaker_transition)
+    end)
+  end)
+
+  describe("release()", function()
+    it("stops counting the request in flight", function()
+      acquire()
+      acquire()
+
+      circuit_breaker.release()
+      assert.equal(1, active())
+      assert.equal("", ngx.var.circuit_breaker_backend)
+
+      circuit_breaker.release()
+      assert.equal(1, active())
+    end)
+
+    it("releases the request after an internal redirect clears the context", function()
+      acquire()
+
+      -- the redirects to the custom error pages clear ngx.ctx but keep the variables
+      ngx.ctx = {}
+      circuit_breaker.release()
+      assert.equal(0, active())
+    end)
+  end)
+
+  describe("sync()", function()
+    it("drops the circuit breaker of a backend without maximum", function()
+      circuit_breaker.sync({ name = backend.name, circuitBreaker = { maxRequests = 0 } })
+      assert.is_nil(circuit_breaker.breakers[backend.name])
+    end)
+
+    it("drops the circuit breakers of the backends removed", function()
+      circuit_breaker.keep({})
+      assert.is_nil(circuit_breaker.breakers[backend.name])
+    end)
+  end)
+end)
@@ -256,6 +256,28 @@ describe("Configuration", function()
       assert.same(ngx.HTTP_CREATED, ngx.status)
     end)
 
+    it("stores and deletes the alternate certificates of the hosts", function()
+      mock_ssl_configuration({
+        servers = { ["hostname"] = UUID },
+        alternates = { ["hostname"] = UUID .. "-alt" },
+        certificates = { [UUID] = "pemCertKey", [UUID .. "-alt"] = "alternatePemCertKey" }
+      })
+
+      assert.has_no.errors(configuration.handle_servers)
+      assert.same(UUID .. "-alt", ngx.shared.certificate_alternates:get("hostname"))
+      assert.same("alternatePemCertKey", certificate_data:get(UUID .. "-alt"))
+
+      mock_ssl_configuration({
+        servers = { ["hostname"] = UUID },
+        alternates = { ["hostname"] = "-1" },
+        certificates = { [UUID] = "pemCertKey" }
+      })
+
+      assert.has_no.errors(configuration.handle_servers)
+      assert.same(nil, ngx.shared.certificate_alternates:get("hostname"))
+      assert.same(ngx.HTTP_CREATED, ngx.status)
+    end)
+
     it("should successfully update certificates and keys for each host", function()
       mock_ssl_configuration({
         servers = { ["hostname"] = UUID },
@@ -0,0 +1,89 @@
+describe("fault_injection", function()
+  local original_ngx_var = ngx.var
+  local fault_injection
+
+  before_each(function()
+    ngx.var = { injected_fault = "" }
+    stub(ngx, "sleep")
+    stub(ngx, "exit")
+    fault_injection = require_without_cache("fault_injection")
+  end)
+
+  after_each(function()
+    ngx.var = original_ngx_var
+  end)
+
+  describe("inject()", function()
+    it("delays every request", function()
+      fault_injection.inject({ delay_min = 500, delay_max = 500, delay_percentage = 100 })
+
+      assert.stub(ngx.sleep).was_called_with(0.5)
+      assert.stub(ngx.exit).was_not_called()
+      assert.are.equal("delay", ngx.var.injected_fault)
+    end)
+
+    it("chooses a random delay between the bounds", function()
+      stub(math, "random", function(min, max)
+        if min then
+          return max
+        end
+        return 0
+      end)
+      fault_injection = require_without_cache("fault_injection")
+
+      fault_injection.inject({ delay_min = 100, delay_max = 2000, delay_percentage = 100 })
+
+      assert.stub(ngx.sleep).was_called_with(2)
+      math.random:revert()
+    end)
+
+    it("aborts the requests with the status code", function()
+      fault_injection.inject({ abort_status = 503, abort_percentage = 100 })
+
+      assert.stub(ngx.exit).was_called_with(503)
+      assert.stub(ngx.sleep).was_not_called()
+      assert.are.equal("abort", ngx.var.injected_fault)
+    end)
+
+    it("only injects faults in the given percentage of requests", function()
+      stub(math, "random", function() return 0.5 end)
+      fault_injection = require_without_cache("fault_injection")
+
+      fault_injection.inject({ abort_status = 503, abort_percentage = 25 })
+      assert.stub(ngx.exit).was_not_called()
+
+      fault_injection.inject({ abort_status = 503, abort_percentage = 75 })
+      assert.stub(ngx.exit).was_called_with(503)
+      math.random:revert()
+    end)
+
+    it("only injects faults in the requests with the header", function()
+      local config = {
+        abort_status = 503,
+        abort_percentage = 100,
+        header_var = "http_x_fault_inject",
+        header_value = "checkout",
+      }
+
+      fault_injection.inject(config)
+      assert.stub(ngx.exit).was_not_called()
+
+      ngx.var.http_x_fault_inject = "search"
+      fault_injection.inject(config)
+      assert.stub(ngx.exit).was_not_called()
+
+      ngx.var.http_x_fault_inject = "checkout"
+      fault_injection.inject(config)
+      assert.stub(ngx.exit).was_called_with(503)
+      assert.are.equal("abort", ngx.var.injected_fault)
+    end)
+
+    it("injects faults in the requests with the header and any value", function()
+      ngx.var.http_x_fault_inject = "1"
+
+      fault_injection.inject({ abort_status = 500, abort_percentage = 100, header_var = "http_x_fault_inject" })
+
+      assert.stub(ngx.exit).was_called_with(500)
+    end)
+  end)
+end)
@@ -6,4 +6,29 @@ describe("lua_ingress", function()
     assert.spy(s).was_called_with(ngx.WARN,
       string.format("ignoring math.randomseed(%d) since PRNG is already seeded for worker %d", 100, ngx.worker.pid()))
   end)
+
+  describe("header()", function()
+    local lua_ingress = require("lua_ingress")
+
+    before_each(function()
+      ngx.var = { scheme = "https" }
+      ngx.header = {}
+      lua_ingress.set_config({ hsts = true, hsts_max_age = 15724800, hsts_include_subdomains = true })
+    end)
+
+    it("uses the global configuration", function()
+      lua_ingress.header()
+      assert.are.equal("max-age=15724800; includeSubDomains", ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("uses the configuration of the server", function()
+      lua_ingress.header({ hsts = true, hsts_max_age = 63072000, hsts_include_subdomains = false, hsts_preload = true })
+      assert.are.equal("max-age=63072000; preload", ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("does not set the header when the server disables HSTS", function()
+      lua_ingress.header({ hsts = false, hsts_max_age = 15724800 })
+      assert.is_nil(ngx.header["Strict-Transport-Security"])
+    end)
+  end)
 end)
@@ -0,0 +1,87 @@
+describe("mirror", function()
+  local original_ngx_ctx = ngx.ctx
+  local balancer, monitor, mirror
+
+  before_each(function()
+    ngx.ctx = {}
+    stub(ngx, "exit")
+    balancer = require("balancer")
+    monitor = require("monitor")
+    stub(monitor, "call")
+    mirror = require_without_cache("mirror")
+  end)
+
+  after_each(function()
+    ngx.ctx = original_ngx_ctx
+    ngx.exit:revert()
+    monitor.call:revert()
+  end)
+
+  describe("rewrite()", function()
+    it("drops the copies outside of the sample", function()
+      stub(math, "random", function() return 0.5 end)
+      mirror = require_without_cache("mirror")
+
+      mirror.rewrite({ sample = 10 })
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_NO_CONTENT)
+      assert.is_true(ngx.ctx.mirror_skipped)
+      math.random:revert()
+    end)
+
+    it("keeps the copies in the sample", function()
+      stub(math, "random", function() return 0.05 end)
+      mirror = require_without_cache("mirror")
+
+      mirror.rewrite({ sample = 10 })
+
+      assert.stub(ngx.exit).was_not_called()
+      assert.is_nil(ngx.ctx.mirror_skipped)
+      math.random:revert()
+    end)
+
+    it("selects the backend of the mirror", function()
+      local backend = {
+        name = "default-app-80-mirror-member2", ["load-balance"] = "round_robin",
+        endpoints = { { address = "10.0.0.1", port = "8080", maxFails = 0, failTimeout = 0 } },
+      }
+      balancer.sync_backend(backend)
+
+      mirror.rewrite({ backend = "default-app-80-mirror-member2" })
+
+      assert.stub(ngx.exit).was_not_called()
+      assert.are.equal("default-app-80-mirror-member2", ngx.ctx.mirror_backend)
+      assert.are.equal("round_robin", balancer.get_balancer().name)
+    end)
+
+    it("rejects the copies when the backend of the mirror has no endpoint", function()
+      mirror.rewrite({ backend = "default-app-80-mirror-member3" })
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_SERVICE_UNAVAILABLE)
+    end)
+  end)
+
+  describe("log()", function()
+    it("reports the copies in the metrics", function()
+      ngx.ctx.mirror_backend = "default-app-80-mirror-member2"
+
+      mirror.log(true)
+
+      assert.stub(monitor.call).was_called()
+    end)
+
+    it("does not report the copies outside of the sample", function()
+      ngx.ctx.mirror_skipped = true
+
+      mirror.log(true)
+
+      assert.stub(monitor.call).was_not_called()
+    end)
+
+    it("does not report the copies when the metrics are disabled", function()
+      mirror.log(false)
+
+      assert.stub(monitor.call).was_not_called()
+    end)
+  end)
+end)
@@ -96,6 +96,8 @@ describe("Monitor", function()
         upstream_response_time = "0.02",
         upstream_response_length = "456",
         upstream_status = "200",
+        upstream_cache_status = "MISS",
+        injected_fault = "",
       }
       mock_ngx({ var = ngx_var_mock })
       local monitor = require("monitor")
@@ -104,6 +106,7 @@ describe("Monitor", function()
       local ngx_var_mock1 = ngx_var_mock
       ngx_var_mock1.status = "201"
       ngx_var_mock1.request_method = "POST"
+      ngx_var_mock1.upstream_addr = "10.10.0.1, 10.10.0.2"
       mock_ngx({ var = ngx_var_mock })
       monitor.call()
 
@@ -127,6 +130,12 @@ describe("Monitor", function()
           upstreamLatency = 0.01,
           upstreamResponseTime = 0.02,
           upstreamResponseLength = 456,
+          upstreamCacheStatus = "MISS",
+
+          injectedFault = "",
+          upstreamRetries = 0,
+          retryBudgetExhausted = false,
+          mirror = "",
         },
         {
           host = "example.com",
@@ -145,6 +154,12 @@ describe("Monitor", function()
           upstreamLatency = 0.01,
           upstreamResponseTime = 0.02,
           upstreamResponseLength = 456,
+          upstreamCacheStatus = "MISS",
+
+          injectedFault = "",
+          upstreamRetries = 1,
+          retryBudgetExhausted = false,
+          mirror = "",
         },
       })
 
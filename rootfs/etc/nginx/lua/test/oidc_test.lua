@@ -0,0 +1,275 @@
+local cjson = require("cjson.safe")
+local http = require("resty.http")
+local b64 = require("ngx.base64")
+
+local ISSUER = "https://issuer.example.com"
+local KEY = "0123456789abcdef"
+
+local CONFIG = {
+  key = KEY,
+  issuer = ISSUER,
+  client_id = "dashboard",
+  scopes = "openid email",
+  redirect_path = "/oauth2/callback",
+}
+
+local SESSION_COOKIE = "_oidc_" .. KEY
+local STATE_COOKIE = "_oidc_state_" .. KEY
+
+-- unsigned ID token, the tokens are received directly from the provider
+local function id_token(claims)
+  return b64.encode_base64url(cjson.encode({ alg = "RS256" })) .. "." ..
+    b64.encode_base64url(cjson.encode(claims)) .. ".c2lnbmF0dXJl"
+end
+
+-- the identity provider stands in for the provider, answering the discovery
+-- and token requests and recording them
+local function new_identity_provider()
+  local idp = { token_requests = {}, tokens = {} }
+
+  idp.responses = {
+    [ISSUER .. "/.well-known/openid-configuration"] = function()
+      return {
+        status = 200,
+        body = cjson.encode({
+          issuer = ISSUER .. "/",
+          authorization_endpoint = ISSUER .. "/authorize",
+          token_endpoint = ISSUER .. "/token",
+        }),
+      }
+    end,
+    [ISSUER .. "/token"] = function(params)
+      local args = ngx.decode_args(params.body)
+      table.insert(idp.token_requests, args)
+      if args.client_secret ~= "client-secret" then
+        return { status = 401, body = "{}" }
+      end
+      return { status = 200, body = cjson.encode(idp.tokens) }
+    end,
+  }
+
+  stub(http, "new", function()
+    return {
+      set_timeout = function() end,
+      request_uri = function(_, url, params)
+        local handler = idp.responses[url]
+        if not handler then
+          return nil, "unexpected request to " .. url
+        end
+        return handler(params)
+      end,
+    }
+  end)
+
+  return idp
+end
+
+local function set_secrets(secrets)
+  local success, err = ngx.shared.configuration_data:set("oidc", cjson.encode(secrets))
+  if not success then
+    error(err)
+  end
+end
+
+local function get_cookie(name)
+  local cookies = ngx.header["Set-Cookie"] or {}
+  for _, cookie in ipairs(cookies) do
+    local value = cookie:match("^" .. name .. "=([^;]*)")
+    if value then
+      return value, cookie
+    end
+  end
+end
+
+describe("oidc", function()
+  local original_ngx_var = ngx.var
+  local original_ngx_header = ngx.header
+  local oidc, idp
+
+  before_each(function()
+    set_secrets({ [KEY] = { clientSecret = "client-secret", cookieSecret = "0123456789abcdef0123456789abcdef" } })
+
+    ngx.var = { scheme = "https", host = "app.example.com", request_uri = "/reports?year=2022" }
+    ngx.header = {}
+    stub(ngx, "exit")
+    stub(ngx, "redirect")
+    stub(ngx.req, "get_method", function() return "GET" end)
+    stub(ngx.req, "set_header")
+    stub(ngx.req, "clear_header")
+
+    idp = new_identity_provider()
+    oidc = require_without_cache("oidc")
+    oidc.reset_discovery_cache()
+  end)
+
+  after_each(function()
+    ngx.var = original_ngx_var
+    ngx.header = original_ngx_header
+    ngx.shared.configuration_data:delete("oidc")
+  end)
+
+  local function encode(name, value)
+    return oidc.encode(oidc.get_provider(KEY), name, value)
+  end
+
+  describe("access()", function()
+    it("redirects the users without a session to the provider", function()
+      oidc.access(CONFIG)
+
+      assert.stub(ngx.redirect).was_called()
+      local url = ngx.redirect.calls[1].refs[1]
+      local endpoint, query = url:match("^([^?]+)%?(.*)$")
+      assert.are.equal(ISSUER .. "/authorize", endpoint)
+
+      local args = ngx.decode_args(query)
+      assert.are.equal("code", args.response_type)
+      assert.are.equal("dashboard", args.client_id)
+      assert.are.equal("openid email", args.scope)
+      assert.are.equal("https://app.example.com/oauth2/callback", args.redirect_uri)
+      assert.are.equal("S256", args.code_challenge_method)
+
+      local value, cookie = get_cookie(STATE_COOKIE)
+      assert.is_truthy(cookie:find("Path=/oauth2/callback", 1, true))
+      assert.is_truthy(cookie:find("Secure", 1, true))
+
+      local state = oidc.decode(oidc.get_provider(KEY), STATE_COOKIE, value)
+      assert.are.equal(args.state, state.state)
+      assert.are.equal("/reports?year=2022", state.redirect)
+    end)
+
+    it("rejects requests without a session that cannot be redirected", function()
+      ngx.req.get_method:revert()
+      stub(ngx.req, "get_method", function() return "POST" end)
+
+      oidc.access(CONFIG)
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_UNAUTHORIZED)
+      assert.stub(ngx.redirect).was_not_called()
+    end)
+
+    it("sends the user of a valid session to the upstream", function()
+      ngx.var["cookie_" .. SESSION_COOKIE] = encode(SESSION_COOKIE, {
+        sub = "alice", email = "alice@example.com", access_token = "access", expires_at = ngx.time() + 300,
+      })
+
+      oidc.access(CONFIG)
+
+      assert.stub(ngx.req.clear_header).was_called_with("X-Auth-Request-User")
+      assert.stub(ngx.req.set_header).was_called_with("X-Auth-Request-User", "alice")
+      assert.stub(ngx.req.set_header).was_called_with("X-Auth-Request-Email", "alice@example.com")
+      assert.stub(ngx.req.set_header).was_called_with("X-Auth-Request-Access-Token", "access")
+      assert.stub(ngx.redirect).was_not_called()
+      assert.stub(ngx.exit).was_not_called()
+    end)
+
+    it("ignores sessions of other providers and tampered sessions", function()
+      local value = encode("_oidc_other", { sub = "alice", access_token = "access", expires_at = ngx.time() + 300 })
+      ngx.var["cookie_" .. SESSION_COOKIE] = value
+
+      oidc.access(CONFIG)
+
+      assert.stub(ngx.req.set_header).was_not_called()
+      assert.stub(ngx.redirect).was_called()
+    end)
+
+    it("refreshes the access token of expired sessions", function()
+      ngx.var["cookie_" .. SESSION_COOKIE] = encode(SESSION_COOKIE, {
+        sub = "alice", access_token = "old", refresh_token = "refresh", expires_at = ngx.time() - 10,
+      })
+      idp.tokens = { access_token = "new", expires_in = 600 }
+
+      oidc.access(CONFIG)
+
+      assert.are.equal(1, #idp.token_requests)
+      assert.are.equal("refresh_token", idp.token_requests[1].grant_type)
+      assert.are.equal("refresh", idp.token_requests[1].refresh_token)
+      assert.stub(ngx.req.set_header).was_called_with("X-Auth-Request-Access-Token", "new")
+
+      local session = oidc.decode(oidc.get_provider(KEY), SESSION_COOKIE, get_cookie(SESSION_COOKIE))
+      assert.are.equal("new", session.access_token)
+      assert.are.equal("refresh", session.refresh_token)
+      assert.are.equal("alice", session.sub)
+    end)
+
+    it("fails when the secrets are not available", function()
+      ngx.shared.configuration_data:delete("oidc")
+
+      oidc.access(CONFIG)
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_SERVICE_UNAVAILABLE)
+    end)
+  end)
+
+  describe("callback()", function()
+    local function set_state(state)
+      ngx.var["cookie_" .. STATE_COOKIE] = encode(STATE_COOKIE, state)
+    end
+
+    local function set_args(args)
+      stub(ngx.req, "get_uri_args", function() return args end)
+    end
+
+    it("exchanges the authorization code and creates the session", function()
+      set_state({ state = "xyz", nonce = "n0nce", code_verifier = "verifier", redirect = "/reports?year=2022" })
+      set_args({ state = "xyz", code = "c0de" })
+      idp.tokens = {
+        access_token = "access",
+        refresh_token = "refresh",
+        expires_in = 300,
+        id_token = id_token({
+          iss = ISSUER .. "/", aud = "dashboard", nonce = "n0nce", sub = "alice", exp = ngx.time() + 300,
+        }),
+      }
+
+      oidc.callback(CONFIG)
+
+      local request = idp.token_requests[1]
+      assert.are.equal("authorization_code", request.grant_type)
+      assert.are.equal("c0de", request.code)
+      assert.are.equal("verifier", request.code_verifier)
+      assert.are.equal("https://app.example.com/oauth2/callback", request.redirect_uri)
+
+      local session = oidc.decode(oidc.get_provider(KEY), SESSION_COOKIE, get_cookie(SESSION_COOKIE))
+      assert.are.equal("alice", session.sub)
+      assert.are.equal("access", session.access_token)
+      assert.stub(ngx.redirect).was_called_with("/reports?year=2022")
+    end)
+
+    it("rejects an unexpected state", function()
+      set_state({ state = "xyz", nonce = "n0nce", code_verifier = "verifier", redirect = "/" })
+      set_args({ state = "abc", code = "c0de" })
+
+      oidc.callback(CONFIG)
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_FORBIDDEN)
+      assert.are.equal(0, #idp.token_requests)
+    end)
+
+    it("rejects an ID token with another nonce", function()
+      set_state({ state = "xyz", nonce = "n0nce", code_verifier = "verifier", redirect = "/" })
+      set_args({ state = "xyz", code = "c0de" })
+      idp.tokens = {
+        access_token = "access",
+        id_token = id_token({ iss = ISSUER, aud = "dashboard", nonce = "other", exp = ngx.time() + 300 }),
+      }
+
+      oidc.callback(CONFIG)
+
+      assert.stub(ngx.exit).was_called_with(ngx.HTTP_UNAUTHORIZED)
+      assert.is_nil(get_cookie(SESSION_COOKIE))
+    end)
+
+    it("only redirects to paths of the same host", function()
+      set_state({ state = "xyz", nonce = "n0nce", code_verifier = "verifier", redirect = "//evil.example.com/" })
+      set_args({ state = "xyz", code = "c0de" })
+      idp.tokens = {
+        access_token = "access",
+        id_token = id_token({ iss = ISSUER, aud = { "dashboard" }, nonce = "n0nce", exp = ngx.time() + 300 }),
+      }
+
+      oidc.callback(CONFIG)
+
+      assert.stub(ngx.redirect).was_called_with("/")
+    end)
+  end)
+end)
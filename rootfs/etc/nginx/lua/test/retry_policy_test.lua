@@ -0,0 +1,214 @@
+local ngx_balancer = require("ngx.balancer")
+
+describe("retry_policy", function()
+  local retry_policy
+  local now
+
+  local backend = {
+    name = "default-web-80",
+    retryPolicy = {
+      attempts = 3,
+      methods = { "GET", "HEAD" },
+      perTryTimeout = 1500,
+      budgetPercent = 20,
+      budgetMinRetries = 0,
+    },
+    endpoints = {
+      { address = "10.10.10.1", port = "8080" },
+      { address = "10.10.10.2", port = "8080" },
+    },
+  }
+
+  local function try_peer(method, failed, peer, movable)
+    stub(ngx.req, "get_method", function() return method end)
+    stub(ngx_balancer, "get_last_failure", function()
+      if failed then
+        return "failed", 502
+      end
+      return nil
+    end)
+    return retry_policy.before_try(backend.name, peer, movable)
+  end
+
+  local function try(method, failed)
+    local _, more_tries = try_peer(method, failed, "10.10.10.1:8080")
+    return more_tries
+  end
+
+  before_each(function()
+    now = 1000
+    stub(ngx, "now", function() return now end)
+    stub(ngx_balancer, "set_timeouts", function() return true end)
+    ngx.ctx.retry_budget_exhausted = nil
+    ngx.ctx.retry_policy_tries = nil
+    ngx.ctx.retry_policy_peer = nil
+    retry_policy = require_without_cache("retry_policy")
+    retry_policy.sync(backend)
+  end)
+
+  after_each(function()
+    ngx.now:revert()
+    ngx.req.get_method:revert()
+    ngx_balancer.get_last_failure:revert()
+    ngx_balancer.set_timeouts:revert()
+  end)
+
+  describe("before_try()", function()
+    it("allows any retry to the backends without policy", function()
+      local peer, more_tries = retry_policy.before_try("default-other-80", "10.10.10.3:80")
+      assert.are.equal("10.10.10.3:80", peer)
+      assert.is_true(more_tries)
+      assert.stub(ngx_balancer.set_timeouts).was_not_called()
+    end)
+
+    it("applies the per try timeout", function()
+      try("GET")
+      assert.stub(ngx_balancer.set_timeouts).was_called_with(nil, 1.5, 1.5)
+    end)
+
+    it("does not retry the methods out of the policy", function()
+      for _ = 1, 10 do
+        try("GET")
+      end
+      assert.is_false(try("POST"))
+      assert.is_nil(ngx.ctx.retry_budget_exhausted)
+    end)
+
+    it("stops retrying when the retries exceed the budget", function()
+      for _ = 1, 9 do
+        try("GET")
+      end
+      -- 10 requests allow 2 retries
+      assert.is_true(try("GET"))
+      assert.is_true(try("GET", true))
+      assert.is_false(try("GET", true))
+      assert.is_true(ngx.ctx.retry_budget_exhausted)
+    end)
+
+    it("allows the minimum retries regardless of the traffic", function()
+      backend.retryPolicy.budgetMinRetries = 1
+      retry_policy.sync(backend)
+
+      assert.is_true(try("GET"))
+      backend.retryPolicy.budgetMinRetries = 0
+    end)
+
+    it("allows the minimum retries over the time the counters span", function()
+      backend.retryPolicy.budgetMinRetries = 1
+      retry_policy.sync(backend)
+
+      -- the counters span the previous window and the 5 seconds of this one
+      now = now + retry_policy.BUDGET_WINDOW + 5
+      for _ = 1, 14 do
+        assert.is_true(try("GET", true))
+      end
+      assert.is_false(try("GET", true))
+      backend.retryPolicy.budgetMinRetries = 0
+    end)
+
+    describe("with a backoff", function()
+      before_each(function()
+        backend.retryPolicy.backoff = 100
+        backend.retryPolicy.budgetMinRetries = 10
+        retry_policy.sync(backend)
+        stub(math, "random", function(m)
+          if m then
+            return m
+          end
+          return 1
+        end)
+      end)
+
+      after_each(function()
+        math.random:revert()
+        backend.retryPolicy.backoff = nil
+        backend.retryPolicy.budgetMinRetries = 0
+      end)
+
+      it("moves the retry away from the endpoint that failed", function()
+        try_peer("GET", false, "10.10.10.1:8080", true)
+        local peer = try_peer("GET", true, "10.10.10.1:8080", true)
+        assert.are.equal("10.10.10.2:8080", peer)
+        assert.are.equal(now + 0.1, retry_policy.budgets[backend.name].backoffs["10.10.10.1:8080"])
+      end)
+
+      it("keeps the endpoint of the balancers not movable", function()
+        try_peer("GET", false, "10.10.10.1:8080", false)
+        local peer = try_peer("GET", true, "10.10.10.1:8080", false)
+        assert.are.equal("10.10.10.1:8080", peer)
+      end)
+
+      it("doubles the backoff with every try up to its maximum", function()
+        local budget = retry_policy.budgets[backend.name]
+        try_peer("GET", false, "10.10.10.1:8080", true)
+        try_peer("GET", true, "10.10.10.2:8080", true)
+        try_peer("GET", true, "10.10.10.1:8080", true)
+        assert.are.equal(now + 0.2, budget.backoffs["10.10.10.2:8080"])
+
+        ngx.ctx.retry_policy_tries = 10
+        try_peer("GET", true, "10.10.10.2:8080", true)
+        assert.are.equal(now + 1, budget.backoffs["10.10.10.1:8080"])
+      end)
+
+      it("keeps the endpoint picked when every endpoint is backing off", function()
+        try_peer("GET", false, "10.10.10.2:8080", true)
+        try_peer("GET", true, "10.10.10.1:8080", true)
+        local peer = try_peer("GET", true, "10.10.10.2:8080", true)
+        assert.are.equal("10.10.10.2:8080", peer)
+      end)
+
+      it("retries the endpoint again after its backoff", function()
+        try_peer("GET", false, "10.10.10.1:8080", true)
+        try_peer("GET", true, "10.10.10.2:8080", true)
+
+        now = now + 1
+        ngx.ctx.retry_policy_tries = nil
+        try_peer("GET", false, "10.10.10.2:8080", true)
+        local peer = try_peer("GET", true, "10.10.10.1:8080", true)
+        assert.are.equal("10.10.10.1:8080", peer)
+      end)
+    end)
+
+    it("forgets the traffic of the windows passed", function()
+      for _ = 1, 10 do
+        try("GET")
+      end
+      try("GET", true)
+      assert.is_false(try("GET", true))
+
+      now = now + 2 * retry_policy.BUDGET_WINDOW
+      for _ = 1, 10 do
+        try("GET")
+      end
+      assert.is_true(try("GET", true))
+    end)
+  end)
+
+  describe("sync()", function()
+    it("removes the budget of the backends without policy", function()
+      retry_policy.sync({ name = backend.name })
+      assert.is_nil(retry_policy.budgets[backend.name])
+    end)
+
+    it("keeps the counters when the policy changes", function()
+      try("GET")
+      retry_policy.sync({ name = backend.name, retryPolicy = { attempts = 2, methods = { "GET" } } })
+      assert.are.equal(1, retry_policy.budgets[backend.name].requests)
+    end)
+
+    it("forgets the backoff of the endpoints removed", function()
+      local budget = retry_policy.budgets[backend.name]
+      budget.backoffs["10.10.10.2:8080"] = now + 1
+      retry_policy.sync({ name = backend.name, retryPolicy = backend.retryPolicy,
+                          endpoints = { backend.endpoints[1] } })
+      assert.is_nil(budget.backoffs["10.10.10.2:8080"])
+    end)
+  end)
+
+  describe("keep()", function()
+    it("drops the budgets of the backends removed", function()
+      retry_policy.keep({})
+      assert.is_nil(retry_policy.budgets[backend.name])
+    end)
+  end)
+end)
@@ -0,0 +1,111 @@
+describe("slow_start", function()
+  local slow_start
+  local now
+
+  local function new_backend(window, addresses)
+    local endpoints = {}
+    for _, address in ipairs(addresses) do
+      table.insert(endpoints, { address = address, port = "8080" })
+    end
+    return { name = "default-web-80", slowStartWindow = window, endpoints = endpoints }
+  end
+
+  before_each(function()
+    now = 1000
+    stub(ngx, "now", function() return now end)
+    slow_start = require_without_cache("slow_start")
+  end)
+
+  after_each(function()
+    ngx.now:revert()
+  end)
+
+  describe("sync()", function()
+    it("considers the endpoints of the first sync warm", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      assert.are.equal(1, slow_start.factor("default-web-80", "10.0.0.1:8080"))
+    end)
+
+    it("ramps up the endpoints added linearly", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+
+      assert.are.equal(slow_start.MIN_FACTOR, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+      now = now + 15
+      assert.are.equal(0.5, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+      now = now + 15
+      assert.are.equal(1, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+    end)
+
+    it("keeps the first seen time across syncs", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+      now = now + 15
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+
+      assert.are.equal(0.5, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+    end)
+
+    it("ramps up again the endpoints coming back", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      now = now + 60
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+
+      assert.are.equal(slow_start.MIN_FACTOR, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+    end)
+
+    it("ramps up the endpoints of a backend scaled from zero", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.sync(new_backend(30, {}))
+      slow_start.sync(new_backend(30, { "10.0.0.2" }))
+
+      assert.are.equal(slow_start.MIN_FACTOR, slow_start.factor("default-web-80", "10.0.0.2:8080"))
+    end)
+
+    it("forgets the backends without window", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.sync(new_backend(0, { "10.0.0.1" }))
+      assert.is_nil(slow_start.backends["default-web-80"])
+    end)
+  end)
+
+  describe("keep()", function()
+    it("drops the state of the backends removed", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.keep({})
+      assert.is_nil(slow_start.backends["default-web-80"])
+    end)
+  end)
+
+  describe("weigh()", function()
+    it("returns the nodes of the backends without window", function()
+      local nodes = { ["10.0.0.1:8080"] = 1 }
+      local weighed, warming = slow_start.weigh("default-web-80", nodes)
+      assert.are.equal(nodes, weighed)
+      assert.is_false(warming)
+    end)
+
+    it("returns the nodes when every endpoint is warm", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+      local nodes = { ["10.0.0.1:8080"] = 1, ["10.0.0.2:8080"] = 2 }
+      local weighed, warming = slow_start.weigh("default-web-80", nodes)
+      assert.are.equal(nodes, weighed)
+      assert.is_false(warming)
+    end)
+
+    it("multiplies the weights by the factor of the endpoints", function()
+      slow_start.sync(new_backend(30, { "10.0.0.1" }))
+      slow_start.sync(new_backend(30, { "10.0.0.1", "10.0.0.2" }))
+      local nodes = { ["10.0.0.1:8080"] = 1, ["10.0.0.2:8080"] = 2 }
+
+      local weighed, warming = slow_start.weigh("default-web-80", nodes)
+      assert.are.same({ ["10.0.0.1:8080"] = 10, ["10.0.0.2:8080"] = 2 }, weighed)
+      assert.is_true(warming)
+
+      now = now + 15
+      weighed = slow_start.weigh("default-web-80", nodes)
+      assert.are.same({ ["10.0.0.1:8080"] = 10, ["10.0.0.2:8080"] = 10 }, weighed)
+    end)
+  end)
+end)
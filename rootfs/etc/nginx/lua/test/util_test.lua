@@ -41,6 +41,19 @@ describe("utility", function()
     end)
   end)
 
+  describe("get_nodes", function()
+    it("returns the weights of the endpoints", function()
+      local endpoints = {
+        { address = "10.10.10.1", port = "8080" },
+        { address = "10.10.10.2", port = "8080", weight = 4 },
+        { address = "10.10.10.3", port = "8080", weight = 0 },
+      }
+      local expected = { ["10.10.10.1:8080"] = 1, ["10.10.10.2:8080"] = 4, ["10.10.10.3:8080"] = 1 }
+
+      assert.are.same(expected, util.get_nodes(endpoints))
+    end)
+  end)
+
   describe("diff_endpoints", function()
     it("returns removed and added endpoints", function()
       local old = {
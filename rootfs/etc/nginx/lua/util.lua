@@ -13,13 +13,21 @@ local re_gmatch     = ngx.re.gmatch
 
 local _M = {}
 
+-- get_weight returns the weight of an endpoint, 1 when it has none
+function _M.get_weight(endpoint)
+  local weight = tonumber(endpoint.weight)
+  if not weight or weight < 1 then
+    return 1
+  end
+  return weight
+end
+
 function _M.get_nodes(endpoints)
   local nodes = {}
-  local weight = 1
 
   for _, endpoint in pairs(endpoints) do
     local endpoint_string = endpoint.address .. ":" .. endpoint.port
-    nodes[endpoint_string] = weight
+    nodes[endpoint_string] = _M.get_weight(endpoint)
   end
 
   return nodes